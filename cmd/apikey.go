@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"okp4/cosmos-faucet/pkg/apikey"
+
+	"github.com/spf13/cobra"
+)
+
+// NewAPIKeyCommand returns a CLI command to manage the API keys granted to machine clients.
+func NewAPIKeyCommand() *cobra.Command {
+	apiKeyCmd := &cobra.Command{
+		Use:   "apikey",
+		Short: "Manage the API keys granted to machine clients",
+	}
+
+	apiKeyCmd.AddCommand(&cobra.Command{
+		Use:   "generate",
+		Short: "Generate a new API key secret along with the hash to store in the API keys file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret, err := apikey.Generate()
+			if err != nil {
+				return err
+			}
+
+			cmd.Printf("secret: %s\nsecretHash: %s\n", secret, apikey.Hash(secret))
+			return nil
+		},
+	})
+
+	return apiKeyCmd
+}
+
+func init() {
+	rootCmd.AddCommand(NewAPIKeyCommand())
+}
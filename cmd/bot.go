@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"okp4/cosmos-faucet/pkg/discord"
+	"okp4/cosmos-faucet/pkg/guard"
+	"okp4/cosmos-faucet/pkg/identity"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagDiscordToken  = "discord-token"
+	FlagDiscordAppID  = "discord-application-id"
+	FlagDiscordGuild  = "discord-guild-id"
+	FlagDiscordMinAge = "discord-min-account-age"
+	FlagDiscordPeriod = "discord-period"
+)
+
+// defaultBotGuards is the default order in which the guards check the fund requests made through a chat bot, the
+// requesters being identified by their chat account.
+var defaultBotGuards = []string{
+	guard.NameEligibility,
+	guard.NameIdentity,
+	guard.NameScreening,
+}
+
+// NewBotCommand returns a CLI command to serve fund requests through chat bots.
+func NewBotCommand() *cobra.Command {
+	botCmd := &cobra.Command{
+		Use:   "bot",
+		Short: "Serve fund requests through chat bots",
+	}
+
+	botCmd.AddCommand(NewDiscordCommand())
+
+	return botCmd
+}
+
+// NewDiscordCommand returns a CLI command to serve fund requests through the /faucet slash command of a Discord bot.
+// nolint: funlen
+func NewDiscordCommand() *cobra.Command {
+	var token string
+	var applicationID string
+	var guildID string
+	var minAge time.Duration
+	var period time.Duration
+	var batchWindow time.Duration
+	var rules eligibilityRules
+	var screeningConf screeningConfig
+	var guards []string
+
+	discordCmd := &cobra.Command{
+		Use:   "discord",
+		Short: "Serve fund requests through the /faucet slash command of a Discord bot",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			conf := types.GetConfig()
+			conf.SetBech32PrefixForAccount(prefix, prefix)
+
+			store := openLedger(ledgerRetention(nil, rules.cooldown, rules.budgetPeriod, period))
+			defer store.Close()
+
+			actorCTX, faucetPID, release := bootstrapFaucet(store, batchWindow)
+			defer release()
+
+			accessList := loadAccessList()
+			stopWatch, err := accessList.Watch()
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not watch access lists")
+			}
+			defer stopWatch()
+
+			guardChain, err := guard.Select(guards,
+				guard.AccessList(accessList),
+				guard.Eligibility(newEligibilityChecker(rules, accessList, store, actorCTX, faucetPID)),
+				guard.Identity(identity.NewPolicy(store, minAge, period)),
+				guard.Screening(newScreeningClient(screeningConf)),
+			)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not configure guards")
+			}
+			guardChain.Reserve(store, faucetAmount(actorCTX, faucetPID))
+
+			opts := []discord.ClientOption{}
+			if guildID != "" {
+				opts = append(opts, discord.WithGuild(guildID))
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			log.Info().Msg("🤖 Starting Discord bot")
+			err = discord.NewBot(
+				discord.NewClient(token, applicationID, opts...),
+				actorCTX,
+				faucetPID,
+				discord.WithGuards(guardChain),
+				discord.WithAddressPrefix(prefix),
+			).Run(ctx)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not run Discord bot")
+			}
+
+			// Poisoning lets the faucet process the pending transaction response before stopping.
+			if err := actorCTX.PoisonFuture(faucetPID).Wait(); err != nil {
+				log.Warn().Err(err).Msg("😥 Could not gracefully stop faucet")
+			}
+		},
+	}
+
+	discordCmd.Flags().StringVar(&token, FlagDiscordToken, "", "token of the Discord bot")
+	discordCmd.Flags().StringVar(&applicationID, FlagDiscordAppID, "", "ID of the Discord application of the bot")
+	discordCmd.Flags().StringVar(
+		&guildID,
+		FlagDiscordGuild,
+		"",
+		"ID of the Discord server to register the /faucet command in, registered globally if empty",
+	)
+	discordCmd.Flags().DurationVar(
+		&minAge,
+		FlagDiscordMinAge,
+		0,
+		"minimum age of the Discord account of the requesters",
+	)
+	discordCmd.Flags().DurationVar(
+		&period,
+		FlagDiscordPeriod,
+		24*time.Hour,
+		"period during which a Discord account can receive funds only once, disabled if 0",
+	)
+	discordCmd.Flags().DurationVar(
+		&batchWindow,
+		FlagBatchWindow,
+		8*time.Second,
+		"Batch temporal window, can be seen a the minimum duration between too transactions.",
+	)
+	addEligibilityFlags(discordCmd, &rules)
+	addScreeningFlags(discordCmd, &screeningConf)
+	discordCmd.Flags().StringSliceVar(
+		&guards,
+		FlagGuards,
+		defaultBotGuards,
+		"ordered guards checking the fund requests, among access, eligibility, identity and screening",
+	)
+
+	return discordCmd
+}
+
+func init() {
+	rootCmd.AddCommand(NewBotCommand())
+}
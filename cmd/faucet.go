@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/actor/system"
+	"okp4/cosmos-faucet/pkg/cosmos"
+	"okp4/cosmos-faucet/pkg/eligibility"
+	"okp4/cosmos-faucet/pkg/faucet"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/screening"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// eligibilityRules holds the eligibility rules configured by the commands serving fund requests.
+type eligibilityRules struct {
+	cooldown     time.Duration
+	maxBalance   int64
+	budget       int64
+	budgetPeriod time.Duration
+}
+
+// addEligibilityFlags adds the flags configuring the eligibility rules to the given command.
+func addEligibilityFlags(cmd *cobra.Command, rules *eligibilityRules) {
+	cmd.Flags().DurationVar(
+		&rules.cooldown,
+		FlagCooldown,
+		0,
+		"minimum duration between two fund requests of a same address, disabled if 0",
+	)
+	cmd.Flags().Int64Var(
+		&rules.maxBalance,
+		FlagMaxBalance,
+		0,
+		"refuse fund requests for addresses having at least this amount of token, disabled if 0",
+	)
+	cmd.Flags().Int64Var(
+		&rules.budget,
+		FlagBudget,
+		0,
+		"maximum amount of token distributed over the budget period, disabled if 0",
+	)
+	cmd.Flags().DurationVar(
+		&rules.budgetPeriod,
+		FlagBudgetPeriod,
+		24*time.Hour,
+		"sliding period over which the budget applies",
+	)
+}
+
+// screeningConfig holds the configuration of the screening of the recipient addresses by an external service.
+type screeningConfig struct {
+	url      string
+	token    string
+	timeout  time.Duration
+	failOpen bool
+	cacheTTL time.Duration
+}
+
+// addScreeningFlags adds the flags configuring the screening of the recipient addresses to the given command.
+func addScreeningFlags(cmd *cobra.Command, conf *screeningConfig) {
+	cmd.Flags().StringVar(
+		&conf.url,
+		FlagScreeningURL,
+		"",
+		"URL of the service screening the recipient addresses, disabled if empty",
+	)
+	cmd.Flags().StringVar(&conf.token, FlagScreeningToken, "", "bearer token authenticating to the screening service")
+	cmd.Flags().DurationVar(
+		&conf.timeout,
+		FlagScreeningTimeout,
+		2*time.Second,
+		"maximum duration to wait for the screening service",
+	)
+	cmd.Flags().BoolVar(
+		&conf.failOpen,
+		FlagScreeningFailOpen,
+		false,
+		"accept the fund requests when the screening service cannot be reached, instead of rejecting them",
+	)
+	cmd.Flags().DurationVar(
+		&conf.cacheTTL,
+		FlagScreeningCache,
+		10*time.Minute,
+		"duration during which the screening result of an address is reused, disabled if 0",
+	)
+}
+
+// newScreeningClient returns the client of the screening service, or nil if no service is configured.
+func newScreeningClient(conf screeningConfig) *screening.Client {
+	if conf.url == "" {
+		return nil
+	}
+
+	return screening.NewClient(conf.url,
+		screening.WithToken(conf.token),
+		screening.WithTimeout(conf.timeout),
+		screening.WithFailOpen(conf.failOpen),
+		screening.WithCacheTTL(conf.cacheTTL),
+	)
+}
+
+// bootstrapFaucet starts the faucet actor, triggering a transaction at the end of each batch window, and returns it
+// along with a function releasing its resources.
+func bootstrapFaucet(store ledger.Store, batchWindow time.Duration) (*actor.RootContext, *actor.PID, func()) {
+	privKey, err := cosmos.ParseMnemonic(mnemonic)
+	if err != nil {
+		log.Panic().Err(err).Msg("❌ Could not parse mnemonic")
+	}
+
+	opts := []faucet.Option{
+		faucet.WithStore(store),
+		faucet.WithBatchWindow(batchWindow, func() *message.TriggerTx {
+			return &message.TriggerTx{
+				Deadline:  time.Now().Add(txTimeout),
+				Memo:      memo,
+				GasLimit:  gasLimit,
+				FeeAmount: types.NewCoins(types.NewInt64Coin(denom, feeAmount)),
+			}
+		}),
+	}
+	release := func() {}
+	if q := openQueue(); q != nil {
+		release = func() { _ = q.Close() }
+		opts = append(opts, faucet.WithQueue(q))
+	}
+
+	actorCTX, faucetPID := system.BootstrapActors(
+		chainID,
+		privKey,
+		types.NewCoins(types.NewInt64Coin(denom, amountSend)),
+		grpcAddress,
+		getTransportCredentials(),
+		opts...,
+	)
+
+	return actorCTX, faucetPID, release
+}
+
+// faucetStatus returns the current status of the faucet, or nil if it could not be retrieved.
+func faucetStatus(actorCTX *actor.RootContext, faucetPID *actor.PID) *message.GetStatusResponse {
+	resp, err := actorCTX.RequestFuture(faucetPID, &message.GetStatus{}, time.Second).Result()
+	status, ok := resp.(*message.GetStatusResponse)
+	if err != nil || !ok {
+		return nil
+	}
+
+	return status
+}
+
+// faucetAmount returns the function giving the amount currently sent by the faucet for each fund request, empty if it
+// could not be retrieved.
+func faucetAmount(actorCTX *actor.RootContext, faucetPID *actor.PID) func() types.Coins {
+	return func() types.Coins {
+		if status := faucetStatus(actorCTX, faucetPID); status != nil {
+			return status.Amount
+		}
+		return nil
+	}
+}
+
+// newEligibilityChecker returns the checker applying the given eligibility rules along with the access lists.
+func newEligibilityChecker(
+	rules eligibilityRules,
+	accessList *access.List,
+	store ledger.Store,
+	actorCTX *actor.RootContext,
+	faucetPID *actor.PID,
+) *eligibility.Checker {
+	checkerOpts := []eligibility.Option{
+		eligibility.WithAccessList(accessList),
+		eligibility.WithStore(store),
+		eligibility.WithCooldown(rules.cooldown),
+		eligibility.WithBudget(types.NewCoins(types.NewInt64Coin(denom, rules.budget)), rules.budgetPeriod),
+		eligibility.WithPaused(func() bool {
+			status := faucetStatus(actorCTX, faucetPID)
+			return status != nil && status.Paused
+		}),
+	}
+	if rules.maxBalance > 0 {
+		grpcClient, err := cosmos.NewGrpcClient(grpcAddress, getTransportCredentials())
+		if err != nil {
+			log.Panic().Err(err).Msg("❌ Could not create grpc client")
+		}
+		checkerOpts = append(checkerOpts,
+			eligibility.WithMaxBalance(grpcClient, types.NewCoins(types.NewInt64Coin(denom, rules.maxBalance))))
+	}
+
+	return eligibility.NewChecker(checkerOpts...)
+}
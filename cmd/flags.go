@@ -13,4 +13,6 @@ const (
 	FlagNoTLS         = "no-tls"
 	FlagTLSSkipVerify = "tls-skip-verify"
 	FlagTxTimeout     = "tx-timeout"
+	FlagAccessList    = "access-list"
+	FlagDataDir       = "data-dir"
 )
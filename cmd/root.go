@@ -4,7 +4,15 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/audit"
+	"okp4/cosmos-faucet/pkg/grant"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/queue"
+	"okp4/cosmos-faucet/pkg/voucher"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -20,6 +28,8 @@ import (
 const (
 	defaultConfigFilename = "config"
 	envPrefix             = "FAUCET"
+	// minLedgerRetention is the minimum duration during which the in memory ledger keeps the records.
+	minLedgerRetention = 24 * time.Hour
 )
 
 var (
@@ -35,6 +45,8 @@ var (
 	noTLS         bool
 	tlsSkipVerify bool
 	txTimeout     time.Duration
+	accessList    string
+	dataDir       string
 )
 
 // NewRootCommand returns the root CLI command with persistent flag handling.
@@ -66,6 +78,14 @@ func Execute() {
 		false,
 		"Encryption with the GRPC endpoint but skip certificates verification")
 	rootCmd.PersistentFlags().DurationVar(&txTimeout, FlagTxTimeout, 5*time.Second, "Transaction timeout")
+	rootCmd.PersistentFlags().StringVar(&accessList,
+		FlagAccessList,
+		"",
+		"Path to the yaml file containing the allow and deny address lists")
+	rootCmd.PersistentFlags().StringVar(&dataDir,
+		FlagDataDir,
+		"",
+		"Directory in which the faucet persists its data, nothing is persisted if empty")
 
 	err := rootCmd.Execute()
 	if err != nil {
@@ -100,6 +120,156 @@ func ReadPersistentFlags(cmd *cobra.Command) error {
 	return nil
 }
 
+func loadAccessList() *access.List {
+	if accessList == "" {
+		return access.NewList(access.Lists{})
+	}
+
+	list, err := access.Load(accessList)
+	if err != nil {
+		log.Panic().Err(err).Str("path", accessList).Msg("❌ Could not load access lists")
+	}
+
+	return list
+}
+
+// loadAPIKeys returns the API keys stored in the given file, or nil if no file is given.
+func loadAPIKeys(path string) []apikey.Key {
+	if path == "" {
+		return nil
+	}
+
+	keys, err := apikey.LoadKeys(path)
+	if err != nil {
+		log.Panic().Err(err).Str("path", path).Msg("❌ Could not load API keys")
+	}
+	if keys == nil {
+		keys = []apikey.Key{}
+	}
+
+	return keys
+}
+
+// loadKeyring returns the keyring holding the given API keys, or nil if no keys file is given.
+func loadKeyring(keys []apikey.Key, store ledger.Store) *apikey.Keyring {
+	if keys == nil {
+		return nil
+	}
+
+	keyring, err := apikey.NewKeyring(keys, store)
+	if err != nil {
+		log.Panic().Err(err).Msg("❌ Could not load API keys")
+	}
+
+	return keyring
+}
+
+// ledgerRetention returns the duration during which the in memory ledger must keep the records, which is the longest
+// of the given windows and of the API keys quota periods, with a minimum of minLedgerRetention.
+func ledgerRetention(keys []apikey.Key, windows ...time.Duration) time.Duration {
+	retention := minLedgerRetention
+	for _, key := range keys {
+		windows = append(windows, key.Quota.Period)
+	}
+	for _, window := range windows {
+		if window > retention {
+			retention = window
+		}
+	}
+
+	return retention
+}
+
+// openLedger returns the distribution ledger, only kept in memory over the given retention, or without limit if 0, if
+// no data directory is configured.
+func openLedger(retention time.Duration) ledger.Store {
+	if dataDir == "" {
+		return ledger.NewMemoryStore(ledger.WithRetention(retention))
+	}
+
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not create data directory")
+	}
+	store, err := ledger.NewBoltStore(filepath.Join(dataDir, "ledger.db"))
+	if err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not open ledger")
+	}
+
+	return store
+}
+
+// openGrants returns the store of the grants pending approval, only kept in memory if no data directory is configured.
+func openGrants() grant.Store {
+	if dataDir == "" {
+		return grant.NewMemoryStore()
+	}
+
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not create data directory")
+	}
+	store, err := grant.NewBoltStore(filepath.Join(dataDir, "grants.db"))
+	if err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not open grants")
+	}
+
+	return store
+}
+
+// openVouchers returns the store of the voucher codes, only kept in memory if no data directory is configured.
+func openVouchers() voucher.Store {
+	if dataDir == "" {
+		return voucher.NewMemoryStore()
+	}
+
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not create data directory")
+	}
+	store, err := voucher.NewBoltStore(filepath.Join(dataDir, "vouchers.db"))
+	if err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not open vouchers")
+	}
+
+	return store
+}
+
+// openQueue returns the write-ahead queue of fund requests, or nil if no data directory is configured.
+func openQueue() queue.Queue {
+	if dataDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not create data directory")
+	}
+	q, err := queue.NewBoltQueue(filepath.Join(dataDir, "queue.db"))
+	if err != nil {
+		log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not open request queue")
+	}
+
+	return q
+}
+
+// openAuditLog returns the log of administrative actions stored in the given file, defaulting to the data directory.
+// Returns nil if none of them is configured, the actions being only reported through the application logs.
+func openAuditLog(path string) *audit.Log {
+	if path == "" {
+		if dataDir == "" {
+			return nil
+		}
+		if err := os.MkdirAll(dataDir, 0o700); err != nil {
+			log.Panic().Err(err).Str("path", dataDir).Msg("❌ Could not create data directory")
+		}
+		path = filepath.Join(dataDir, "audit.log")
+	}
+
+	auditLog, err := audit.Open(path)
+	if err != nil {
+		log.Panic().Err(err).Str("path", path).Msg("❌ Could not open audit log")
+	}
+
+	return auditLog
+}
+
 func getTransportCredentials() credentials.TransportCredentials {
 	switch {
 	case noTLS:
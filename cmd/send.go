@@ -4,17 +4,29 @@ import (
 	"okp4/cosmos-faucet/pkg/actor/message"
 	"okp4/cosmos-faucet/pkg/actor/system"
 	"okp4/cosmos-faucet/pkg/cosmos"
-	"sync"
+	"okp4/cosmos-faucet/pkg/faucet"
+	"okp4/cosmos-faucet/pkg/guard"
 	"time"
 
-	"github.com/asynkron/protoactor-go/actor"
 	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
+// defaultSendGuards is the default order in which the guards check the fund requests sent from the command line.
+var defaultSendGuards = []string{
+	guard.NameEligibility,
+	guard.NameScreening,
+}
+
 // NewSendCommand returns a CLI command to interactively send amount token(s) to given address.
+// nolint: funlen
 func NewSendCommand() *cobra.Command {
+	var rules eligibilityRules
+	var screeningConf screeningConfig
+	var guards []string
+
 	sendCmd := &cobra.Command{
 		Use:   "send <address>",
 		Short: "Send tokens to a given address",
@@ -33,25 +45,39 @@ func NewSendCommand() *cobra.Command {
 				log.Panic().Err(err).Str("toAddress", args[0]).Msg("❌ Could not parse address")
 			}
 
+			store := openLedger(ledgerRetention(nil, rules.cooldown, rules.budgetPeriod))
+			defer store.Close()
+
 			actorCTX, faucetPID := system.BootstrapActors(
 				chainID,
 				privKey,
 				types.NewCoins(types.NewInt64Coin(denom, amountSend)),
 				grpcAddress,
 				getTransportCredentials(),
+				faucet.WithStore(store),
+			)
+
+			accessList := loadAccessList()
+			guardChain, err := guard.Select(guards,
+				guard.AccessList(accessList),
+				guard.Eligibility(newEligibilityChecker(rules, accessList, store, actorCTX, faucetPID)),
+				guard.Screening(newScreeningClient(screeningConf)),
 			)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not configure guards")
+			}
+			guardChain.Reserve(store, faucetAmount(actorCTX, faucetPID))
 
-			wg := sync.WaitGroup{}
-			wg.Add(1)
-			subPID := actorCTX.Spawn(actor.PropsFromFunc(func(c actor.Context) {
-				if _, ok := c.Message().(*message.BroadcastTxResponse); ok {
-					wg.Done()
-					c.Stop(c.Self())
-				}
-			}))
+			req := &guard.Request{ID: uuid.NewString(), Address: toAddress}
+			if err := guardChain.Check(cmd.Context(), req); err != nil {
+				log.Panic().Err(err).Str("toAddress", args[0]).Msg("❌ Address not allowed to receive funds")
+			}
 
+			subPID, done := faucet.SubscribeTx(actorCTX)
 			actorCTX.Send(faucetPID, &message.RequestFunds{
+				ID:           req.ID,
 				Address:      toAddress,
+				Amount:       req.Amount,
 				TxSubscriber: subPID,
 			})
 			actorCTX.Send(faucetPID, &message.TriggerTx{
@@ -61,11 +87,26 @@ func NewSendCommand() *cobra.Command {
 				FeeAmount: types.NewCoins(types.NewInt64Coin(denom, feeAmount)),
 			})
 
-			wg.Wait()
-			actorCTX.Stop(faucetPID)
+			txErr := <-done
+			// Poisoning lets the faucet process the pending transaction response before stopping.
+			if err := actorCTX.PoisonFuture(faucetPID).Wait(); err != nil {
+				log.Warn().Err(err).Msg("😥 Could not gracefully stop faucet")
+			}
+			if txErr != nil {
+				log.Panic().Err(txErr).Str("toAddress", args[0]).Msg("❌ Could not send tokens")
+			}
 		},
 	}
 
+	addEligibilityFlags(sendCmd, &rules)
+	addScreeningFlags(sendCmd, &screeningConf)
+	sendCmd.Flags().StringSliceVar(
+		&guards,
+		FlagGuards,
+		defaultSendGuards,
+		"ordered guards checking the fund request, among access, eligibility and screening",
+	)
+
 	return sendCmd
 }
 
@@ -3,29 +3,87 @@ package cmd
 import (
 	"okp4/cosmos-faucet/graph"
 	"okp4/cosmos-faucet/graph/model"
+	"okp4/cosmos-faucet/internal/clientip"
 	"okp4/cosmos-faucet/internal/server"
-	"okp4/cosmos-faucet/pkg/actor/message"
-	"okp4/cosmos-faucet/pkg/actor/system"
 	"okp4/cosmos-faucet/pkg/captcha"
-	"okp4/cosmos-faucet/pkg/cosmos"
+	"okp4/cosmos-faucet/pkg/guard"
+	"okp4/cosmos-faucet/pkg/identity"
+	faucetmetrics "okp4/cosmos-faucet/pkg/metrics"
+	"okp4/cosmos-faucet/pkg/ownership"
+	"okp4/cosmos-faucet/pkg/webhook"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
 const (
-	FlagAddress       = "address"
-	FlagBatchWindow   = "batch-window"
-	FlagMetrics       = "metrics"
-	FlagHealth        = "health"
-	FlagCaptchaSecret = "captcha-secret"
-	FlagCaptchaURL    = "captcha-verify-url"
-	FlagCaptchaScore  = "captcha-min-score"
-	FlagEnableCaptcha = "captcha"
+	FlagAddress           = "address"
+	FlagBatchWindow       = "batch-window"
+	FlagMetrics           = "metrics"
+	FlagHealth            = "health"
+	FlagCaptchaProvider   = "captcha-provider"
+	FlagCaptchaSecret     = "captcha-secret"
+	FlagCaptchaURL        = "captcha-verify-url"
+	FlagCaptchaScore      = "captcha-min-score"
+	FlagEnableCaptcha     = "captcha"
+	FlagCaptchaAction     = "captcha-action"
+	FlagCaptchaHosts      = "captcha-hostnames"
+	FlagCaptchaMaxAge     = "captcha-max-age"
+	FlagCaptchaTiers      = "captcha-tiers"
+	FlagPowDifficulty     = "captcha-pow-difficulty"
+	FlagPowMax            = "captcha-pow-max-difficulty"
+	FlagPowLoadStep       = "captcha-pow-load-step"
+	FlagAdminToken        = "admin-token"
+	FlagAdminAddress      = "admin-address"
+	FlagAuditLog          = "audit-log"
+	FlagAPIKeys           = "api-keys"
+	FlagOwnership         = "ownership-proof"
+	FlagOwnershipTTL      = "ownership-challenge-ttl"
+	FlagCooldown          = "cooldown"
+	FlagMaxBalance        = "max-balance"
+	FlagBudget            = "budget"
+	FlagBudgetPeriod      = "budget-period"
+	FlagGuards            = "guards"
+	FlagIPLimit           = "ip-limit"
+	FlagIPLimitPeriod     = "ip-limit-period"
+	FlagSubnetLimit       = "subnet-limit"
+	FlagTrustedProxies    = "trusted-proxies"
+	FlagRateLimit         = "rate-limit"
+	FlagSubnetRateLimit   = "subnet-rate-limit"
+	FlagOAuthProvider     = "oauth-provider"
+	FlagOAuthClientID     = "oauth-client-id"
+	FlagOAuthSecret       = "oauth-client-secret"
+	FlagOAuthRedirect     = "oauth-redirect-url"
+	FlagOAuthReturn       = "oauth-return-url"
+	FlagOAuthMinAge       = "oauth-min-account-age"
+	FlagOAuthPeriod       = "oauth-period"
+	FlagSessionSecret     = "session-secret"
+	FlagSessionTTL        = "session-ttl"
+	FlagWebhooks          = "webhooks"
+	FlagWebhookRetries    = "webhook-retries"
+	FlagLowBalance        = "webhook-low-balance"
+	FlagScreeningURL      = "screening-url"
+	FlagScreeningToken    = "screening-token"
+	FlagScreeningTimeout  = "screening-timeout"
+	FlagScreeningFailOpen = "screening-fail-open"
+	FlagScreeningCache    = "screening-cache-ttl"
 )
 
+// defaultGuards is the default order in which the guards check the fund requests, the cheapest checks first. The access
+// lists being part of the eligibility rules, the access guard is not needed unless the eligibility one is removed.
+var defaultGuards = []string{
+	guard.NameEligibility,
+	guard.NameIdentity,
+	guard.NameIPLimit,
+	guard.NameOwnership,
+	guard.NameScreening,
+	guard.NameAPIKey,
+	guard.NameCaptcha,
+}
+
 // NewStartCommand returns a CLI command to start the REST api allowing to send tokens.
 // nolint: funlen
 func NewStartCommand() *cobra.Command {
@@ -34,6 +92,30 @@ func NewStartCommand() *cobra.Command {
 	var metrics bool
 	var health bool
 	var captchaConf captcha.ResolverConfig
+	var captchaTiers map[string]int64
+	var adminToken string
+	var adminAddress string
+	var auditLog string
+	var apiKeys string
+	var ownershipProof bool
+	var ownershipTTL time.Duration
+	var oauthProvider string
+	var oauthConf identity.Config
+	var oauthReturnURL string
+	var oauthMinAge time.Duration
+	var oauthPeriod time.Duration
+	var sessionSecret string
+	var sessionTTL time.Duration
+	var webhooks string
+	var webhookRetries int
+	var lowBalance int64
+	var rules eligibilityRules
+	var screeningConf screeningConfig
+	var guards []string
+	var ipLimits guard.IPLimits
+	var trustedProxies []string
+	var rateLimit int
+	var subnetRateLimit int
 
 	startCmd := &cobra.Command{
 		Use:   "start",
@@ -42,47 +124,140 @@ func NewStartCommand() *cobra.Command {
 			conf := types.GetConfig()
 			conf.SetBech32PrefixForAccount(prefix, prefix)
 
-			privKey, err := cosmos.ParseMnemonic(mnemonic)
+			keys := loadAPIKeys(apiKeys)
+			store := openLedger(ledgerRetention(keys,
+				rules.cooldown, rules.budgetPeriod, ipLimits.Period, oauthPeriod))
+			defer store.Close()
+
+			grants := openGrants()
+			defer grants.Close()
+
+			vouchers := openVouchers()
+			defer vouchers.Close()
+
+			actorCTX, faucetPID, release := bootstrapFaucet(store, batchWindow)
+			defer release()
+
+			if metrics {
+				faucetMetrics := faucetmetrics.New(prometheus.DefaultRegisterer)
+				defer actorCTX.ActorSystem().EventStream.Unsubscribe(
+					faucetMetrics.Subscribe(actorCTX.ActorSystem().EventStream))
+			}
+
+			if webhooks != "" {
+				endpoints, err := webhook.Load(webhooks)
+				if err != nil {
+					log.Panic().Err(err).Str("path", webhooks).Msg("❌ Could not load webhooks")
+				}
+				notifier := webhook.NewNotifier(endpoints,
+					webhook.WithRetries(webhookRetries, time.Second),
+					webhook.WithLowBalance(types.NewCoins(types.NewInt64Coin(denom, lowBalance))),
+				)
+				defer notifier.Close()
+				defer actorCTX.ActorSystem().EventStream.Unsubscribe(
+					notifier.Subscribe(actorCTX.ActorSystem().EventStream))
+			}
+
+			accessList := loadAccessList()
+			stopWatch, err := accessList.Watch()
 			if err != nil {
-				log.Panic().Err(err).Msg("❌ Could not parse mnemonic")
+				log.Panic().Err(err).Msg("❌ Could not watch access lists")
+			}
+			defer stopWatch()
+
+			captchaResolver, err := captcha.NewCaptchaResolver(captchaConf)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not configure captcha verification")
+			}
+
+			tiers, err := captcha.NewTiers(captchaTiers, denom)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not configure captcha tiers")
+			}
+
+			keyring := loadKeyring(keys, store)
+
+			var ownershipVerifier *ownership.Verifier
+			if ownershipProof {
+				ownershipVerifier = ownership.NewVerifier(prefix, ownershipTTL)
+			}
+
+			var identityProvider identity.Provider
+			var identityPolicy *identity.Policy
+			var sessions *identity.Sessions
+			if oauthProvider != "" {
+				identityProvider, err = identity.NewProvider(oauthProvider, oauthConf)
+				if err != nil {
+					log.Panic().Err(err).Msg("❌ Could not configure identity provider")
+				}
+				sessions, err = identity.NewSessions(sessionSecret, sessionTTL)
+				if err != nil {
+					log.Panic().Err(err).Msg("❌ Could not configure sessions")
+				}
+				identityPolicy = identity.NewPolicy(store, oauthMinAge, oauthPeriod)
 			}
 
-			actorCTX, faucetPID := system.BootstrapActors(
-				chainID,
-				privKey,
-				types.NewCoins(types.NewInt64Coin(denom, amountSend)),
-				grpcAddress,
-				getTransportCredentials(),
+			eligibilityChecker := newEligibilityChecker(rules, accessList, store, actorCTX, faucetPID)
+			guardChain, err := guard.Select(guards,
+				guard.AccessList(accessList),
+				guard.Eligibility(eligibilityChecker),
+				guard.Identity(identityPolicy),
+				guard.IPLimit(store, ipLimits),
+				guard.Ownership(ownershipVerifier),
+				guard.Screening(newScreeningClient(screeningConf)),
+				guard.APIKey(keyring),
+				guard.Captcha(captchaResolver, tiers),
 			)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not configure guards")
+			}
+			guardChain.Reserve(store, faucetAmount(actorCTX, faucetPID))
 
 			graphqlResolver := &graph.Resolver{
-				Faucet:          faucetPID,
-				Context:         actorCTX,
-				AddressPrefix:   prefix,
-				CaptchaResolver: captcha.NewCaptchaResolver(captchaConf),
+				Faucet:             faucetPID,
+				Context:            actorCTX,
+				AddressPrefix:      prefix,
+				CaptchaResolver:    captchaResolver,
+				AccessList:         accessList,
+				EligibilityChecker: eligibilityChecker,
+				Ledger:             store,
+				GrantStore:         grants,
+				VoucherStore:       vouchers,
+				OwnershipVerifier:  ownershipVerifier,
+				Guards:             guardChain,
+				Events:             actorCTX.ActorSystem().EventStream,
 				Config: &model.Configuration{
-					AmountSend: amountSend,
-					ChainID:    chainID,
-					Denom:      denom,
-					FeeAmount:  feeAmount,
-					GasLimit:   gasLimit,
-					Memo:       memo,
-					Prefix:     prefix,
+					AmountSend:             amountSend,
+					ChainID:                chainID,
+					Denom:                  denom,
+					FeeAmount:              feeAmount,
+					GasLimit:               gasLimit,
+					Memo:                   memo,
+					Prefix:                 prefix,
+					OwnershipProofRequired: ownershipProof,
 				},
 			}
 
-			go func() {
-				for range time.Tick(batchWindow) {
-					actorCTX.Send(faucetPID, &message.TriggerTx{
-						Deadline:  time.Now().Add(txTimeout),
-						Memo:      memo,
-						GasLimit:  gasLimit,
-						FeeAmount: types.NewCoins(types.NewInt64Coin(denom, feeAmount)),
-					})
-				}
-			}()
+			proxies, err := clientip.ParseNetworks(trustedProxies)
+			if err != nil {
+				log.Panic().Err(err).Msg("❌ Could not parse trusted proxies")
+			}
+
+			auditLogger := openAuditLog(auditLog)
+			defer func() { _ = auditLogger.Close() }()
+			graphqlResolver.Audit = auditLogger
 
-			server.NewServer(graphqlResolver, health, metrics).Start(addr)
+			server.NewServer(
+				graphqlResolver,
+				server.WithHealth(health),
+				server.WithMetrics(metrics),
+				server.WithAdminToken(adminToken),
+				server.WithAdminAddress(adminAddress),
+				server.WithKeyring(keyring),
+				server.WithTrustedProxies(proxies),
+				server.WithRateLimit(rateLimit, subnetRateLimit),
+				server.WithIdentityProvider(identityProvider, sessions, oauthReturnURL),
+			).Start(addr)
 		},
 	}
 
@@ -101,6 +276,12 @@ func NewStartCommand() *cobra.Command {
 		false,
 		"enable captcha verification",
 	)
+	startCmd.Flags().StringVar(
+		&captchaConf.Provider,
+		FlagCaptchaProvider,
+		captcha.ProviderRecaptchaV3,
+		"captcha provider, one of recaptcha-v3, recaptcha-v2, hcaptcha, turnstile or pow",
+	)
 	startCmd.Flags().StringVar(
 		&captchaConf.Secret,
 		FlagCaptchaSecret,
@@ -110,14 +291,193 @@ func NewStartCommand() *cobra.Command {
 	startCmd.Flags().StringVar(
 		&captchaConf.VerifyURL,
 		FlagCaptchaURL,
-		"https://www.google.com/recaptcha/api/siteverify",
-		"set Captcha verify URL",
+		"",
+		"set Captcha verify URL, defaults to the one of the captcha provider",
 	)
 	startCmd.Flags().Float64Var(
 		&captchaConf.MinScore,
 		FlagCaptchaScore,
 		0.5,
-		"set Captcha min score",
+		"set Captcha min score, only applying to the providers giving a score",
+	)
+	startCmd.Flags().StringVar(
+		&captchaConf.Action,
+		FlagCaptchaAction,
+		"",
+		"action the captcha tokens must have been issued for, not checked if empty",
+	)
+	startCmd.Flags().StringSliceVar(
+		&captchaConf.Hostnames,
+		FlagCaptchaHosts,
+		nil,
+		"hostnames of the sites the captcha may be solved on, not checked if empty",
+	)
+	startCmd.Flags().DurationVar(
+		&captchaConf.MaxAge,
+		FlagCaptchaMaxAge,
+		0,
+		"maximum duration between the resolution of a captcha and its verification, not checked if 0",
+	)
+	startCmd.Flags().StringToInt64Var(
+		&captchaTiers,
+		FlagCaptchaTiers,
+		nil,
+		"amounts granted by minimum captcha score (e.g. 0.3=100000,0.7=1000000), only applying to the providers giving a score",
+	)
+	startCmd.Flags().IntVar(
+		&captchaConf.Pow.Difficulty,
+		FlagPowDifficulty,
+		20,
+		"number of leading zero bits required by the proof of work captcha",
+	)
+	startCmd.Flags().IntVar(
+		&captchaConf.Pow.MaxDifficulty,
+		FlagPowMax,
+		0,
+		"difficulty the load can raise the proof of work captcha up to, constant difficulty if not above it",
+	)
+	startCmd.Flags().IntVar(
+		&captchaConf.Pow.LoadStep,
+		FlagPowLoadStep,
+		100,
+		"number of proof of work challenges answered per minute adding one bit to the difficulty",
+	)
+	startCmd.Flags().StringVar(
+		&adminToken,
+		FlagAdminToken,
+		"",
+		"bearer token granting access to the admin GraphQL operations, disabled if empty",
+	)
+	startCmd.Flags().StringVar(
+		&adminAddress,
+		FlagAdminAddress,
+		"",
+		"address of a separate listener granting access to the admin GraphQL operations without token, disabled if empty",
+	)
+	startCmd.Flags().StringVar(
+		&auditLog,
+		FlagAuditLog,
+		"",
+		"file recording the administrative actions, defaults to audit.log in the data directory if any",
+	)
+	startCmd.Flags().StringVar(
+		&apiKeys,
+		FlagAPIKeys,
+		"",
+		"path to the yaml file containing the API keys granted to machine clients, disabled if empty",
+	)
+	startCmd.Flags().BoolVar(
+		&ownershipProof,
+		FlagOwnership,
+		false,
+		"require the requesters to prove the ownership of the recipient address by signing a challenge (ADR-036)",
+	)
+	startCmd.Flags().DurationVar(
+		&ownershipTTL,
+		FlagOwnershipTTL,
+		5*time.Minute,
+		"duration after which an ownership challenge cannot be answered anymore",
+	)
+	addEligibilityFlags(startCmd, &rules)
+	addScreeningFlags(startCmd, &screeningConf)
+	startCmd.Flags().StringSliceVar(
+		&guards,
+		FlagGuards,
+		defaultGuards,
+		"ordered guards checking the fund requests, among access, eligibility, identity, ip, ownership, screening, apikey and captcha",
+	)
+	startCmd.Flags().IntVar(
+		&ipLimits.PerIP,
+		FlagIPLimit,
+		0,
+		"maximum number of fund requests from a same ip address over the ip limit period, disabled if 0",
+	)
+	startCmd.Flags().IntVar(
+		&ipLimits.PerSubnet,
+		FlagSubnetLimit,
+		0,
+		"maximum number of fund requests from a same /24 (IPv4) or /64 (IPv6) subnet over the ip limit period, disabled if 0",
+	)
+	startCmd.Flags().DurationVar(
+		&ipLimits.Period,
+		FlagIPLimitPeriod,
+		24*time.Hour,
+		"sliding period over which the ip and subnet limits apply",
+	)
+	startCmd.Flags().StringVar(
+		&oauthProvider,
+		FlagOAuthProvider,
+		"",
+		"identity provider the requesters must log in with to request funds, among github, disabled if empty",
+	)
+	startCmd.Flags().StringVar(&oauthConf.ClientID, FlagOAuthClientID, "", "client ID of the OAuth application")
+	startCmd.Flags().StringVar(&oauthConf.ClientSecret, FlagOAuthSecret, "", "client secret of the OAuth application")
+	startCmd.Flags().StringVar(
+		&oauthConf.RedirectURL,
+		FlagOAuthRedirect,
+		"",
+		"callback URL registered in the OAuth application, i.e. the /auth/callback endpoint of the faucet",
+	)
+	startCmd.Flags().StringVar(
+		&oauthReturnURL,
+		FlagOAuthReturn,
+		"/",
+		"URL the requesters are redirected to once logged in, e.g. the faucet frontend",
+	)
+	startCmd.Flags().DurationVar(
+		&oauthMinAge,
+		FlagOAuthMinAge,
+		0,
+		"minimum age of the requester account at the identity provider",
+	)
+	startCmd.Flags().DurationVar(
+		&oauthPeriod,
+		FlagOAuthPeriod,
+		24*time.Hour,
+		"period during which an account at the identity provider can receive funds only once, disabled if 0",
+	)
+	startCmd.Flags().StringVar(
+		&sessionSecret,
+		FlagSessionSecret,
+		"",
+		"secret signing the sessions of the logged in requesters, a random one invalidating them on restart if empty",
+	)
+	startCmd.Flags().DurationVar(&sessionTTL, FlagSessionTTL, 24*time.Hour, "duration after which the sessions expire")
+	startCmd.Flags().StringVar(
+		&webhooks,
+		FlagWebhooks,
+		"",
+		"path to the yaml file containing the endpoints notified of the faucet events, disabled if empty",
+	)
+	startCmd.Flags().IntVar(
+		&webhookRetries,
+		FlagWebhookRetries,
+		5,
+		"number of delivery attempts of a webhook notification, retried with an exponential backoff",
+	)
+	startCmd.Flags().Int64Var(
+		&lowBalance,
+		FlagLowBalance,
+		0,
+		"faucet balance under which a low_balance event is notified to the webhooks, disabled if 0",
+	)
+	startCmd.Flags().StringSliceVar(
+		&trustedProxies,
+		FlagTrustedProxies,
+		nil,
+		"ip addresses or networks of the reverse proxies trusted to give the client ip address in X-Forwarded-For",
+	)
+	startCmd.Flags().IntVar(
+		&rateLimit,
+		FlagRateLimit,
+		0,
+		"maximum number of GraphQL requests per minute from a same ip address, disabled if 0",
+	)
+	startCmd.Flags().IntVar(
+		&subnetRateLimit,
+		FlagSubnetRateLimit,
+		0,
+		"maximum number of GraphQL requests per minute from a same /24 (IPv4) or /64 (IPv6) subnet, disabled if 0",
 	)
 
 	return startCmd
@@ -0,0 +1,203 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"math"
+	"okp4/cosmos-faucet/graph/scalar"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/captcha"
+	"okp4/cosmos-faucet/pkg/eligibility"
+	"okp4/cosmos-faucet/pkg/grant"
+	"okp4/cosmos-faucet/pkg/guard"
+	"okp4/cosmos-faucet/pkg/identity"
+	"okp4/cosmos-faucet/pkg/ownership"
+	"okp4/cosmos-faucet/pkg/screening"
+	"okp4/cosmos-faucet/pkg/voucher"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorCode is the machine-readable code of an error, given in the `code` extension of the GraphQL errors.
+type ErrorCode string
+
+const (
+	CodeInvalidAddress   ErrorCode = "INVALID_ADDRESS"
+	CodeInvalidArgument  ErrorCode = "INVALID_ARGUMENT"
+	CodeCaptchaFailed    ErrorCode = "CAPTCHA_FAILED"
+	CodeRateLimited      ErrorCode = "RATE_LIMITED"
+	CodeFaucetPaused     ErrorCode = "FAUCET_PAUSED"
+	CodeDenylisted       ErrorCode = "DENYLISTED"
+	CodeNotAllowlisted   ErrorCode = "NOT_ALLOWLISTED"
+	CodeBalanceTooHigh   ErrorCode = "BALANCE_TOO_HIGH"
+	CodeBudgetExhausted  ErrorCode = "BUDGET_EXHAUSTED"
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeAmountNotAllowed ErrorCode = "AMOUNT_NOT_ALLOWED"
+	CodeQuotaExceeded    ErrorCode = "QUOTA_EXCEEDED"
+	CodeInvalidVoucher   ErrorCode = "INVALID_VOUCHER"
+	CodeOwnershipFailed  ErrorCode = "OWNERSHIP_PROOF_FAILED"
+	CodeLoginRequired    ErrorCode = "LOGIN_REQUIRED"
+	CodeAccountTooRecent ErrorCode = "ACCOUNT_TOO_RECENT"
+	CodeAddressFlagged   ErrorCode = "ADDRESS_FLAGGED"
+	CodeTxFailed         ErrorCode = "TX_FAILED"
+	CodeGrantPending     ErrorCode = "GRANT_PENDING"
+	CodeInternal         ErrorCode = "INTERNAL_ERROR"
+)
+
+const (
+	// extensionCode is the GraphQL error extension holding the error code.
+	extensionCode = "code"
+	// extensionRetryAfter is the GraphQL error extension holding the number of seconds after which the operation may
+	// succeed.
+	extensionRetryAfter = "retryAfter"
+)
+
+// ErrInvalidAddress is returned when a given address cannot be decoded.
+var ErrInvalidAddress = scalar.ErrInvalidAddress
+
+// ErrInvalidArgument is returned when an operation argument is not valid.
+var ErrInvalidArgument = errors.New("invalid argument")
+
+// ErrUnauthorized is returned when accessing an admin operation without being authenticated as administrator.
+var ErrUnauthorized = errors.New("unauthorized: admin access required")
+
+// ErrTxFailed is returned when the transaction including a fund request could not be made, submitted or confirmed.
+var ErrTxFailed = errors.New("transaction failed")
+
+// codes associates the known errors to their code, the first matching one applying.
+var codes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrInvalidAddress, CodeInvalidAddress},
+	{ErrInvalidArgument, CodeInvalidArgument},
+	{ErrUnauthorized, CodeUnauthorized},
+	{ErrTxFailed, CodeTxFailed},
+	{access.ErrInvalidEntry, CodeInvalidArgument},
+	{apikey.ErrAmountNotAllowed, CodeAmountNotAllowed},
+	{apikey.ErrQuotaExceeded, CodeQuotaExceeded},
+	{grant.ErrNotFound, CodeInvalidArgument},
+	{grant.ErrNotPending, CodeInvalidArgument},
+	{grant.ErrPending, CodeGrantPending},
+	{voucher.ErrNotFound, CodeInvalidVoucher},
+	{voucher.ErrRedeemed, CodeInvalidVoucher},
+	{voucher.ErrExpired, CodeInvalidVoucher},
+	{ownership.ErrMissingProof, CodeOwnershipFailed},
+	{ownership.ErrNoChallenge, CodeOwnershipFailed},
+	{ownership.ErrInvalidProof, CodeOwnershipFailed},
+	{captcha.ErrChallengeNotSupported, CodeInvalidArgument},
+	{captcha.ErrScoreNotSupported, CodeInvalidArgument},
+	{captcha.ErrMissingToken, CodeCaptchaFailed},
+	{captcha.ErrVerificationFailed, CodeCaptchaFailed},
+	{eligibility.ErrCooldown, CodeRateLimited},
+	{guard.ErrIPLimited, CodeRateLimited},
+	{guard.ErrSubnetLimited, CodeRateLimited},
+	{identity.ErrUnauthenticated, CodeLoginRequired},
+	{identity.ErrAccountTooRecent, CodeAccountTooRecent},
+	{identity.ErrAlreadyFunded, CodeRateLimited},
+	{screening.ErrFlagged, CodeAddressFlagged},
+	{eligibility.ErrPaused, CodeFaucetPaused},
+	{eligibility.ErrBalanceTooHigh, CodeBalanceTooHigh},
+	{eligibility.ErrBudgetExhausted, CodeBudgetExhausted},
+	{access.ErrDenied, CodeDenylisted},
+	{access.ErrNotAllowed, CodeNotAllowlisted},
+}
+
+// ErrorPresenter presents the errors returned by the resolvers with their code, and when known the number of seconds
+// after which the operation may succeed, in the GraphQL error extensions.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if _, ok := gqlErr.Extensions[extensionCode]; ok {
+		return gqlErr
+	}
+
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]interface{}{}
+	}
+	gqlErr.Extensions[extensionCode] = errorCode(err)
+
+	var retryAfter time.Duration
+	var ineligibleErr *eligibility.IneligibleError
+	var quotaErr *apikey.QuotaError
+	var ipLimitErr *guard.IPLimitError
+	var deniedErr *identity.DeniedError
+	switch {
+	case errors.As(err, &ineligibleErr):
+		retryAfter = ineligibleErr.Decision.RetryAfter
+	case errors.As(err, &quotaErr):
+		retryAfter = quotaErr.RetryAfter
+	case errors.As(err, &ipLimitErr):
+		retryAfter = ipLimitErr.RetryAfter
+	case errors.As(err, &deniedErr):
+		retryAfter = deniedErr.RetryAfter
+	}
+	if retryAfter > 0 {
+		gqlErr.Extensions[extensionRetryAfter] = int64(math.Ceil(retryAfter.Seconds()))
+	}
+
+	return gqlErr
+}
+
+func errorCode(err error) ErrorCode {
+	var rejection *guard.Rejection
+	if errors.As(err, &rejection) && rejection.Code != "" {
+		return ErrorCode(rejection.Code)
+	}
+
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+
+	// Remaining GraphQL errors are raised by the request handling itself, e.g. when decoding the arguments.
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		return CodeInvalidArgument
+	}
+
+	return CodeInternal
+}
+
+type subscriptionErrorKey struct{}
+
+// subscriptionError holds the error ending a subscription, if any.
+type subscriptionError struct {
+	mu  sync.Mutex
+	err error
+}
+
+// SubscriptionErrors is an operation middleware sending the error ending a subscription as the last response of its
+// stream, the subscriptions being otherwise completed without telling whether they succeeded.
+func SubscriptionErrors(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	holder := &subscriptionError{}
+	responses := next(context.WithValue(ctx, subscriptionErrorKey{}, holder))
+
+	return func(ctx context.Context) *graphql.Response {
+		if resp := responses(ctx); resp != nil {
+			return resp
+		}
+
+		holder.mu.Lock()
+		err := holder.err
+		holder.err = nil
+		holder.mu.Unlock()
+		if err == nil {
+			return nil
+		}
+		return &graphql.Response{Errors: gqlerror.List{ErrorPresenter(ctx, err)}}
+	}
+}
+
+// endSubscription sets the error to send to the client once the subscription channel is closed.
+func endSubscription(ctx context.Context, err error) {
+	if holder, ok := ctx.Value(subscriptionErrorKey{}).(*subscriptionError); ok {
+		holder.mu.Lock()
+		defer holder.mu.Unlock()
+		holder.err = err
+	}
+}
@@ -0,0 +1,255 @@
+package graph
+
+import (
+	"math"
+	"okp4/cosmos-faucet/graph/model"
+	"okp4/cosmos-faucet/pkg/actor/event"
+	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/eligibility"
+	"okp4/cosmos-faucet/pkg/grant"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/voucher"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// maxPageSize is the maximum number of items a paginated query can return.
+const maxPageSize = 100
+
+// maxStatsBuckets is the maximum number of intervals the statistics can be computed over at once.
+const maxStatsBuckets = 1000
+
+var ineligibilityReasons = map[eligibility.Reason]model.IneligibilityReason{
+	eligibility.ReasonDenylisted:      model.IneligibilityReasonDenylisted,
+	eligibility.ReasonNotAllowlisted:  model.IneligibilityReasonNotAllowlisted,
+	eligibility.ReasonCooldown:        model.IneligibilityReasonCooldown,
+	eligibility.ReasonBalanceTooHigh:  model.IneligibilityReasonBalanceTooHigh,
+	eligibility.ReasonPaused:          model.IneligibilityReasonFaucetPaused,
+	eligibility.ReasonBudgetExhausted: model.IneligibilityReasonBudgetExhausted,
+}
+
+func toCoins(coins types.Coins) []*model.Coin {
+	result := make([]*model.Coin, 0, len(coins))
+	for _, coin := range coins {
+		result = append(result, &model.Coin{
+			Denom:  coin.Denom,
+			Amount: coin.Amount.Int64(),
+		})
+	}
+
+	return result
+}
+
+func toDistribution(record ledger.Record) *model.Distribution {
+	distribution := &model.Distribution{
+		ID:        record.ID,
+		Address:   record.Address,
+		Amount:    toCoins(record.Amount),
+		Status:    model.RequestStatus(strings.ToUpper(string(record.Status))),
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}
+	if record.TxHash != "" {
+		code := int(record.Code)
+		distribution.TxHash = &record.TxHash
+		distribution.Code = &code
+	}
+
+	return distribution
+}
+
+func toRequest(record ledger.Record) *model.Request {
+	request := &model.Request{
+		ID:        record.ID,
+		Address:   record.Address,
+		Status:    model.RequestStatus(strings.ToUpper(string(record.Status))),
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}
+	if record.TxHash != "" {
+		code := int(record.Code)
+		request.TxHash = &record.TxHash
+		request.Code = &code
+	}
+	if record.Error != "" {
+		request.Error = &record.Error
+	}
+
+	return request
+}
+
+func toGrant(g grant.Grant) *model.Grant {
+	result := &model.Grant{
+		ID:            g.ID,
+		Address:       g.Address,
+		Amount:        toCoins(g.Amount),
+		Justification: g.Justification,
+		Status:        model.GrantStatus(strings.ToUpper(string(g.Status))),
+		CreatedAt:     g.CreatedAt,
+		DecidedAt:     g.DecidedAt,
+	}
+	if g.Reason != "" {
+		result.Reason = &g.Reason
+	}
+	if g.RequestID != "" {
+		result.RequestID = &g.RequestID
+	}
+
+	return result
+}
+
+func toVoucher(v voucher.Voucher) *model.Voucher {
+	result := &model.Voucher{
+		Code:       v.Code,
+		Amount:     toCoins(v.Amount),
+		CreatedAt:  v.CreatedAt,
+		ExpiresAt:  v.ExpiresAt,
+		RedeemedAt: v.RedeemedAt,
+	}
+	if v.Batch != "" {
+		result.Batch = &v.Batch
+	}
+	if v.Address != "" {
+		result.Address = &v.Address
+	}
+	if v.RequestID != "" {
+		result.RequestID = &v.RequestID
+	}
+
+	return result
+}
+
+func toStatus(status *message.GetStatusResponse) *model.Status {
+	result := &model.Status{
+		Balance:       toCoins(status.Balances),
+		NodeReachable: status.NodeReachable,
+		QueueLength:   status.QueueLength,
+		State:         model.FaucetStateRunning,
+	}
+	if status.Paused {
+		result.State = model.FaucetStatePaused
+	}
+	if status.LastTxHash != "" {
+		result.LastTxHash = &status.LastTxHash
+	}
+	if status.LastTxHeight != 0 {
+		result.LastTxHeight = &status.LastTxHeight
+	}
+	if !status.NextTrigger.IsZero() {
+		result.NextTriggerAt = &status.NextTrigger
+	}
+	if !status.NodeCheckedAt.IsZero() {
+		result.NodeCheckedAt = &status.NodeCheckedAt
+	}
+
+	return result
+}
+
+func toStats(stats ledger.Stats) *model.Stats {
+	result := &model.Stats{
+		Distributed:    toCoins(stats.Distributed),
+		FailedRequests: stats.Failed,
+		Recipients:     stats.Recipients,
+		Requests:       stats.Requests,
+		Start:          stats.Start,
+	}
+	if stats.AverageConfirmation > 0 {
+		average := stats.AverageConfirmation.Seconds()
+		result.AverageConfirmationTime = &average
+	}
+
+	return result
+}
+
+func toStatsReport(total ledger.Stats, buckets []ledger.Stats) *model.StatsReport {
+	report := &model.StatsReport{
+		Buckets: make([]*model.Stats, 0, len(buckets)),
+		Total:   toStats(total),
+	}
+	for _, bucket := range buckets {
+		report.Buckets = append(report.Buckets, toStats(bucket))
+	}
+
+	return report
+}
+
+func toDistributionConnection(records []ledger.Record, hasNext bool) *model.DistributionConnection {
+	connection := &model.DistributionConnection{
+		Edges:    make([]*model.DistributionEdge, 0, len(records)),
+		PageInfo: &model.PageInfo{HasNextPage: hasNext},
+	}
+	for _, record := range records {
+		connection.Edges = append(connection.Edges, &model.DistributionEdge{
+			Cursor: ledger.Cursor(record),
+			Node:   toDistribution(record),
+		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+
+	return connection
+}
+
+func toEligibility(decision eligibility.Decision) *model.Eligibility {
+	result := &model.Eligibility{Eligible: decision.Eligible()}
+	if reason, ok := ineligibilityReasons[decision.Reason]; ok {
+		result.Reason = &reason
+	}
+	if decision.RetryAfter > 0 {
+		retryAfter := int64(math.Ceil(decision.RetryAfter.Seconds()))
+		result.RetryAfter = &retryAfter
+	}
+
+	return result
+}
+
+// toFaucetEvent maps the events published by the actors to the faucet events, returning nil for unrelated events.
+func toFaucetEvent(evt interface{}) *model.FaucetEvent {
+	switch evt := evt.(type) {
+	case *event.RequestAccepted:
+		return &model.FaucetEvent{
+			Type:       model.FaucetEventTypeRequestAccepted,
+			Address:    &evt.Address,
+			Amount:     toCoins(evt.Amount),
+			RequestIds: []string{evt.RequestID},
+			Time:       evt.Time,
+		}
+	case *event.BatchTriggered:
+		return &model.FaucetEvent{
+			Type:       model.FaucetEventTypeBatchTriggered,
+			RequestIds: evt.RequestIDs,
+			Time:       evt.Time,
+		}
+	case *event.TxBroadcast:
+		code := int(evt.Code)
+		return &model.FaucetEvent{
+			Type:       model.FaucetEventTypeTxBroadcast,
+			TxHash:     &evt.TxHash,
+			Code:       &code,
+			RequestIds: evt.RequestIDs,
+			Time:       evt.Time,
+		}
+	case *event.TxConfirmed:
+		code := int(evt.Code)
+		return &model.FaucetEvent{
+			Type:       model.FaucetEventTypeTxConfirmed,
+			TxHash:     &evt.TxHash,
+			Code:       &code,
+			Height:     &evt.Height,
+			RequestIds: evt.RequestIDs,
+			Time:       evt.Time,
+		}
+	case *event.TxFailed:
+		return &model.FaucetEvent{
+			Type:       model.FaucetEventTypeTxFailed,
+			Error:      &evt.Error,
+			RequestIds: evt.RequestIDs,
+			Time:       evt.Time,
+		}
+	default:
+		return nil
+	}
+}
@@ -2,6 +2,40 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Represent a hashcash-style challenge, solved by finding a nonce such that the SHA-256 hash of `<challenge>:<nonce>`
+// starts with at least `difficulty` zero bits, this string being the captcha token to give when requesting funds
+type CaptchaChallenge struct {
+	// Challenge to solve, an opaque string
+	Challenge string `json:"challenge"`
+	// Number of leading zero bits the hash of the solution must have
+	Difficulty int `json:"difficulty"`
+	// Time after which the challenge cannot be answered anymore
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Represent a nonce to sign to prove the ownership of an address
+type Challenge struct {
+	// Time after which the challenge cannot be answered anymore
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Nonce to sign, as the data of an ADR-036 offline signature
+	Nonce string `json:"nonce"`
+}
+
+// Represent an amount of token
+type Coin struct {
+	// Token amount
+	Amount int64 `json:"amount"`
+	// Token denom
+	Denom string `json:"denom"`
+}
+
 // Represent the actual server configuration
 type Configuration struct {
 	// Amount value of token to send
@@ -16,18 +50,206 @@ type Configuration struct {
 	GasLimit uint64 `json:"gasLimit"`
 	// Memo used when send transaction
 	Memo string `json:"memo"`
+	// Whether the proof of address ownership is required to request funds
+	OwnershipProofRequired bool `json:"ownershipProofRequired"`
 	// Address prefix
 	Prefix string `json:"prefix"`
 }
 
+// Represent a past send of tokens made by the faucet
+type Distribution struct {
+	// Address on which the tokens are sent
+	Address string `json:"address"`
+	// Amounts of tokens sent
+	Amount []*Coin `json:"amount"`
+	// Result code of the transaction, once submitted
+	Code *int `json:"code,omitempty"`
+	// Time at which the fund request has been received
+	CreatedAt time.Time `json:"createdAt"`
+	// Unique identifier of the fund request
+	ID string `json:"id"`
+	// Processing status of the fund request
+	Status RequestStatus `json:"status"`
+	// Hash of the transaction including the send, once submitted
+	TxHash *string `json:"txHash,omitempty"`
+	// Time of the last change of the distribution
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// A paginated list of distributions, as defined by the Relay specification
+type DistributionConnection struct {
+	// A list of edges
+	Edges []*DistributionEdge `json:"edges"`
+	// Information to aid in pagination
+	PageInfo *PageInfo `json:"pageInfo"`
+}
+
+// An edge in a distribution connection
+type DistributionEdge struct {
+	// A cursor for use in pagination
+	Cursor string `json:"cursor"`
+	// The distribution at the end of the edge
+	Node *Distribution `json:"node"`
+}
+
+// Represent whether an address can currently request funds
+type Eligibility struct {
+	// Whether the address can currently request funds
+	Eligible bool `json:"eligible"`
+	// The reason why the address cannot request funds, if not eligible
+	Reason *IneligibilityReason `json:"reason,omitempty"`
+	// Number of seconds after which the address may be eligible again, if known
+	RetryAfter *int64 `json:"retryAfter,omitempty"`
+}
+
+// Represent an activity of the faucet
+type FaucetEvent struct {
+	// Address on which the funds are requested, for accepted requests
+	Address *string `json:"address,omitempty"`
+	// Amount of tokens requested, for accepted requests
+	Amount []*Coin `json:"amount,omitempty"`
+	// Result code of the transaction, once submitted
+	Code *int `json:"code,omitempty"`
+	// Description of the failure, for failed transactions
+	Error *string `json:"error,omitempty"`
+	// Height of the block including the transaction, for confirmed transactions
+	Height *int64 `json:"height,omitempty"`
+	// Identifiers of the fund requests concerned by the event
+	RequestIds []string `json:"requestIds"`
+	// Time at which the event occurred
+	Time time.Time `json:"time"`
+	// Hash of the transaction, once submitted
+	TxHash *string `json:"txHash,omitempty"`
+	// Kind of activity
+	Type FaucetEventType `json:"type"`
+}
+
+// Represent a request of an amount of token larger than the standard one, which must be approved
+type Grant struct {
+	// Address on which the tokens are requested
+	Address string `json:"address"`
+	// Requested amount
+	Amount []*Coin `json:"amount"`
+	// Time at which the grant has been requested
+	CreatedAt time.Time `json:"createdAt"`
+	// Time at which the grant has been approved or rejected
+	DecidedAt *time.Time `json:"decidedAt,omitempty"`
+	// Unique identifier of the grant
+	ID string `json:"id"`
+	// Justification given by the requester
+	Justification string `json:"justification"`
+	// Reason given when rejecting the grant, if any
+	Reason *string `json:"reason,omitempty"`
+	// Identifier of the fund request made once approved, to be given to the `request` query
+	RequestID *string `json:"requestId,omitempty"`
+	// Approval status of the grant
+	Status GrantStatus `json:"status"`
+}
+
+// Information about pagination in a connection, as defined by the Relay specification
+type PageInfo struct {
+	// When paginating forwards, the cursor to continue
+	EndCursor *string `json:"endCursor,omitempty"`
+	// When paginating forwards, are there more items?
+	HasNextPage bool `json:"hasNextPage"`
+	// When paginating backwards, are there more items?
+	HasPreviousPage bool `json:"hasPreviousPage"`
+	// When paginating backwards, the cursor to continue
+	StartCursor *string `json:"startCursor,omitempty"`
+}
+
+// Represent the processing state of a fund request
+type Request struct {
+	// Address on which the tokens are requested
+	Address string `json:"address"`
+	// Result code of the transaction, once submitted
+	Code *int `json:"code,omitempty"`
+	// Time at which the fund request has been received
+	CreatedAt time.Time `json:"createdAt"`
+	// Description of the error if the request failed
+	Error *string `json:"error,omitempty"`
+	// Unique identifier of the fund request
+	ID string `json:"id"`
+	// Processing status of the fund request
+	Status RequestStatus `json:"status"`
+	// Hash of the transaction including the send, once submitted
+	TxHash *string `json:"txHash,omitempty"`
+	// Time of the last change of the request status
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
 // All inputs needed to send token to a given address
 type SendInput struct {
-	// Captcha token
-	CaptchaToken *string `json:"captchaToken"`
+	// Amount of token to send instead of the configured one, only allowed to the clients authenticated with an API key
+	Amount *int64 `json:"amount,omitempty"`
+	// Captcha token, not required for the clients authenticated with an API key
+	CaptchaToken *string `json:"captchaToken,omitempty"`
+	// Denom of the token to send instead of the configured one, only allowed to the clients authenticated with an API key
+	Denom *string `json:"denom,omitempty"`
+	// Base64 encoded compressed secp256k1 public key of the address, required along with the signature when the proof of
+	// address ownership is enabled
+	PubKey *string `json:"pubKey,omitempty"`
+	// Base64 encoded ADR-036 signature by the address of the nonce issued by the `challenge` query, required when the
+	// proof of address ownership is enabled
+	Signature *string `json:"signature,omitempty"`
 	// Address where to send token(s)
 	ToAddress string `json:"toAddress"`
 }
 
+// Represent the statistics of the fund requests received over a period
+type Stats struct {
+	// Average number of seconds between the reception of a fund request and the confirmation of its transaction
+	AverageConfirmationTime *float64 `json:"averageConfirmationTime,omitempty"`
+	// Total amount of the confirmed fund requests
+	Distributed []*Coin `json:"distributed"`
+	// Number of fund requests whose transaction failed
+	FailedRequests int `json:"failedRequests"`
+	// Number of unique addresses having requested funds
+	Recipients int `json:"recipients"`
+	// Number of fund requests
+	Requests int `json:"requests"`
+	// Beginning of the period
+	Start time.Time `json:"start"`
+}
+
+// Represent the period over which to compute statistics
+type StatsRange struct {
+	// Beginning of the period, aligned on the start of its interval
+	From time.Time `json:"from"`
+	// Granularity of the statistics
+	Interval *StatsInterval `json:"interval,omitempty"`
+	// End of the period (exclusive), now if not given
+	To *time.Time `json:"to,omitempty"`
+}
+
+// Represent the statistics of the fund requests over a range, per interval
+type StatsReport struct {
+	// Statistics of each interval of the range, from the oldest to the most recent
+	Buckets []*Stats `json:"buckets"`
+	// Statistics over the whole range
+	Total *Stats `json:"total"`
+}
+
+// Represent the current status of the faucet
+type Status struct {
+	// Balances of the faucet as of the last check of the node
+	Balance []*Coin `json:"balance"`
+	// Hash of the last successfully submitted transaction
+	LastTxHash *string `json:"lastTxHash,omitempty"`
+	// Height of the last transaction confirmed in a block
+	LastTxHeight *int64 `json:"lastTxHeight,omitempty"`
+	// Time at which the next transaction is scheduled, if any
+	NextTriggerAt *time.Time `json:"nextTriggerAt,omitempty"`
+	// Time of the last check of the node, if any
+	NodeCheckedAt *time.Time `json:"nodeCheckedAt,omitempty"`
+	// Whether the last check of the node succeeded
+	NodeReachable bool `json:"nodeReachable"`
+	// Number of fund requests waiting to be included in a transaction
+	QueueLength int `json:"queueLength"`
+	// Whether the faucet is running or paused
+	State FaucetState `json:"state"`
+}
+
 // Represent a transaction response
 type TxResponse struct {
 	// Return the result code of transaction.
@@ -40,5 +262,319 @@ type TxResponse struct {
 	// Corresponding to the transaction hash.
 	Hash string `json:"hash"`
 	// Description of error if available.
-	RawLog *string `json:"rawLog"`
+	RawLog *string `json:"rawLog,omitempty"`
+}
+
+// Represent a single use code unlocking an amount of token
+type Voucher struct {
+	// Address to which the tokens have been sent, once redeemed
+	Address *string `json:"address,omitempty"`
+	// Amount of token unlocked by the voucher
+	Amount []*Coin `json:"amount"`
+	// Name of the batch the voucher has been generated with, if any
+	Batch *string `json:"batch,omitempty"`
+	// Code to redeem
+	Code string `json:"code"`
+	// Time at which the voucher has been generated
+	CreatedAt time.Time `json:"createdAt"`
+	// Time after which the voucher cannot be redeemed anymore
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Time at which the voucher has been redeemed
+	RedeemedAt *time.Time `json:"redeemedAt,omitempty"`
+	// Identifier of the fund request made once redeemed
+	RequestID *string `json:"requestId,omitempty"`
+}
+
+// Represent the kind of activity of the faucet
+type FaucetEventType string
+
+const (
+	// A transaction including the pending fund requests has been triggered
+	FaucetEventTypeBatchTriggered FaucetEventType = "BATCH_TRIGGERED"
+	// A fund request has been accepted and is waiting to be included in a transaction
+	FaucetEventTypeRequestAccepted FaucetEventType = "REQUEST_ACCEPTED"
+	// A transaction has been submitted to the blockchain
+	FaucetEventTypeTxBroadcast FaucetEventType = "TX_BROADCAST"
+	// A submitted transaction has been included in a block
+	FaucetEventTypeTxConfirmed FaucetEventType = "TX_CONFIRMED"
+	// A transaction could not be made, submitted or confirmed
+	FaucetEventTypeTxFailed FaucetEventType = "TX_FAILED"
+)
+
+var AllFaucetEventType = []FaucetEventType{
+	FaucetEventTypeBatchTriggered,
+	FaucetEventTypeRequestAccepted,
+	FaucetEventTypeTxBroadcast,
+	FaucetEventTypeTxConfirmed,
+	FaucetEventTypeTxFailed,
+}
+
+func (e FaucetEventType) IsValid() bool {
+	switch e {
+	case FaucetEventTypeBatchTriggered, FaucetEventTypeRequestAccepted, FaucetEventTypeTxBroadcast, FaucetEventTypeTxConfirmed, FaucetEventTypeTxFailed:
+		return true
+	}
+	return false
+}
+
+func (e FaucetEventType) String() string {
+	return string(e)
+}
+
+func (e *FaucetEventType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FaucetEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FaucetEventType", str)
+	}
+	return nil
+}
+
+func (e FaucetEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Represent whether the faucet is processing the fund requests
+type FaucetState string
+
+const (
+	// The faucet is paused, no transaction being submitted until resumed
+	FaucetStatePaused FaucetState = "PAUSED"
+	// The faucet is submitting the fund requests
+	FaucetStateRunning FaucetState = "RUNNING"
+)
+
+var AllFaucetState = []FaucetState{
+	FaucetStatePaused,
+	FaucetStateRunning,
+}
+
+func (e FaucetState) IsValid() bool {
+	switch e {
+	case FaucetStatePaused, FaucetStateRunning:
+		return true
+	}
+	return false
+}
+
+func (e FaucetState) String() string {
+	return string(e)
+}
+
+func (e *FaucetState) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FaucetState(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FaucetState", str)
+	}
+	return nil
+}
+
+func (e FaucetState) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Represent the approval status of a grant
+type GrantStatus string
+
+const (
+	// The grant has been approved and its funds requested to the faucet
+	GrantStatusApproved GrantStatus = "APPROVED"
+	// The grant is waiting to be reviewed
+	GrantStatusPending GrantStatus = "PENDING"
+	// The grant has been rejected
+	GrantStatusRejected GrantStatus = "REJECTED"
+)
+
+var AllGrantStatus = []GrantStatus{
+	GrantStatusApproved,
+	GrantStatusPending,
+	GrantStatusRejected,
+}
+
+func (e GrantStatus) IsValid() bool {
+	switch e {
+	case GrantStatusApproved, GrantStatusPending, GrantStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e GrantStatus) String() string {
+	return string(e)
+}
+
+func (e *GrantStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = GrantStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid GrantStatus", str)
+	}
+	return nil
+}
+
+func (e GrantStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Represent the reason why an address cannot currently request funds
+type IneligibilityReason string
+
+const (
+	// The address balance is above the maximum allowed to request funds
+	IneligibilityReasonBalanceTooHigh IneligibilityReason = "BALANCE_TOO_HIGH"
+	// The faucet has distributed its whole budget over the budget period
+	IneligibilityReasonBudgetExhausted IneligibilityReason = "BUDGET_EXHAUSTED"
+	// The address has already received funds during the cooldown period
+	IneligibilityReasonCooldown IneligibilityReason = "COOLDOWN"
+	// The address matches an entry of the denylist
+	IneligibilityReasonDenylisted IneligibilityReason = "DENYLISTED"
+	// The faucet is paused
+	IneligibilityReasonFaucetPaused IneligibilityReason = "FAUCET_PAUSED"
+	// An allowlist is configured and the address doesn't match any of its entries
+	IneligibilityReasonNotAllowlisted IneligibilityReason = "NOT_ALLOWLISTED"
+)
+
+var AllIneligibilityReason = []IneligibilityReason{
+	IneligibilityReasonBalanceTooHigh,
+	IneligibilityReasonBudgetExhausted,
+	IneligibilityReasonCooldown,
+	IneligibilityReasonDenylisted,
+	IneligibilityReasonFaucetPaused,
+	IneligibilityReasonNotAllowlisted,
+}
+
+func (e IneligibilityReason) IsValid() bool {
+	switch e {
+	case IneligibilityReasonBalanceTooHigh, IneligibilityReasonBudgetExhausted, IneligibilityReasonCooldown, IneligibilityReasonDenylisted, IneligibilityReasonFaucetPaused, IneligibilityReasonNotAllowlisted:
+		return true
+	}
+	return false
+}
+
+func (e IneligibilityReason) String() string {
+	return string(e)
+}
+
+func (e *IneligibilityReason) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IneligibilityReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IneligibilityReason", str)
+	}
+	return nil
+}
+
+func (e IneligibilityReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Represent the processing status of a fund request
+type RequestStatus string
+
+const (
+	// The request has been included in a transaction successfully written in a block
+	RequestStatusConfirmed RequestStatus = "CONFIRMED"
+	// The request could not be processed, its transaction being rejected or not included in a block
+	RequestStatusFailed RequestStatus = "FAILED"
+	// The request is waiting to be included in a transaction
+	RequestStatusQueued RequestStatus = "QUEUED"
+	// The request has been included in a transaction successfully submitted
+	RequestStatusSubmitted RequestStatus = "SUBMITTED"
+)
+
+var AllRequestStatus = []RequestStatus{
+	RequestStatusConfirmed,
+	RequestStatusFailed,
+	RequestStatusQueued,
+	RequestStatusSubmitted,
+}
+
+func (e RequestStatus) IsValid() bool {
+	switch e {
+	case RequestStatusConfirmed, RequestStatusFailed, RequestStatusQueued, RequestStatusSubmitted:
+		return true
+	}
+	return false
+}
+
+func (e RequestStatus) String() string {
+	return string(e)
+}
+
+func (e *RequestStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RequestStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RequestStatus", str)
+	}
+	return nil
+}
+
+func (e RequestStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Represent the granularity of the statistics
+type StatsInterval string
+
+const (
+	// Statistics aggregated per UTC day
+	StatsIntervalDay StatsInterval = "DAY"
+	// Statistics aggregated per hour
+	StatsIntervalHour StatsInterval = "HOUR"
+)
+
+var AllStatsInterval = []StatsInterval{
+	StatsIntervalDay,
+	StatsIntervalHour,
+}
+
+func (e StatsInterval) IsValid() bool {
+	switch e {
+	case StatsIntervalDay, StatsIntervalHour:
+		return true
+	}
+	return false
+}
+
+func (e StatsInterval) String() string {
+	return string(e)
+}
+
+func (e *StatsInterval) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StatsInterval(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StatsInterval", str)
+	}
+	return nil
+}
+
+func (e StatsInterval) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
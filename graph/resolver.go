@@ -1,10 +1,32 @@
 package graph
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"okp4/cosmos-faucet/graph/model"
+	"okp4/cosmos-faucet/internal/clientip"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/audit"
 	"okp4/cosmos-faucet/pkg/captcha"
+	"okp4/cosmos-faucet/pkg/eligibility"
+	"okp4/cosmos-faucet/pkg/grant"
+	"okp4/cosmos-faucet/pkg/guard"
+	"okp4/cosmos-faucet/pkg/identity"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/ownership"
+	"okp4/cosmos-faucet/pkg/voucher"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // This file will not be regenerated automatically.
@@ -12,9 +34,288 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	Faucet          *actor.PID
-	Context         *actor.RootContext
-	AddressPrefix   string
-	CaptchaResolver captcha.Resolver
-	Config          *model.Configuration
+	Faucet             *actor.PID
+	Context            *actor.RootContext
+	AddressPrefix      string
+	CaptchaResolver    captcha.Resolver
+	OwnershipVerifier  *ownership.Verifier
+	Guards             *guard.Chain
+	AccessList         *access.List
+	EligibilityChecker *eligibility.Checker
+	Ledger             ledger.Store
+	GrantStore         grant.Store
+	VoucherStore       voucher.Store
+	Events             *eventstream.EventStream
+	Audit              *audit.Log
+	Config             *model.Configuration
+
+	configMu sync.RWMutex
+}
+
+// requestTimeout is the maximum duration to wait for the response of an actor.
+const requestTimeout = 5 * time.Second
+
+// maxJustificationLength is the maximum number of characters of a grant justification.
+const maxJustificationLength = 2000
+
+// maxVouchers is the maximum number of vouchers generated at once.
+const maxVouchers = 1000
+
+// eventsBufferSize is the number of events buffered for a subscriber, the events being dropped for slow subscribers
+// once full so they cannot block the actors publishing them.
+const eventsBufferSize = 64
+
+func (r *Resolver) editAccessLists(
+	ctx context.Context,
+	action, entry string,
+	edit func(string) error,
+) (*access.Lists, error) {
+	err := edit(entry)
+	r.audit(ctx, action, map[string]interface{}{"entry": entry}, err)
+	if err != nil {
+		log.Err(err).Str("entry", entry).Msg("❌ Could not edit access lists")
+		return nil, err
+	}
+
+	lists := r.AccessList.Lists()
+	return &lists, nil
+}
+
+// parseAddress decodes the given bech32 address, which must have the configured prefix.
+func (r *Resolver) parseAddress(address string) (types.AccAddress, error) {
+	addr, err := types.GetFromBech32(address, r.AddressPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAddress, err)
+	}
+
+	return addr, nil
+}
+
+// newRequestFunds submits the given send input to the guards, returning the fund request to send to the faucet if
+// accepted.
+func (r *Resolver) newRequestFunds(ctx context.Context, input model.SendInput) (*message.RequestFunds, error) {
+	addr, err := r.parseAddress(input.ToAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &guard.Request{
+		ID:              uuid.NewString(),
+		Address:         addr,
+		RequesterIP:     clientip.FromContext(ctx),
+		RequesterSubnet: clientip.SubnetFromContext(ctx),
+		CaptchaToken:    input.CaptchaToken,
+		APIKey:          apikey.FromContext(ctx),
+		Identity:        identity.FromContext(ctx),
+	}
+	if input.PubKey != nil {
+		req.PubKey = *input.PubKey
+	}
+	if input.Signature != nil {
+		req.Signature = *input.Signature
+	}
+	if input.Amount != nil || input.Denom != nil {
+		if req.APIKey == nil {
+			return nil, fmt.Errorf("%w: an api key is required to choose the amount", apikey.ErrAmountNotAllowed)
+		}
+		if req.Amount, err = r.requestedAmount(input); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.Guards.Check(ctx, req); err != nil {
+		return nil, err
+	}
+
+	msg := &message.RequestFunds{
+		ID:              req.ID,
+		Address:         addr,
+		Amount:          req.Amount,
+		RequesterIP:     req.RequesterIP,
+		RequesterSubnet: req.RequesterSubnet,
+		Captcha:         req.Captcha,
+	}
+	if req.APIKey != nil {
+		msg.APIKey = req.APIKey.Name
+	}
+	if req.Identity != nil {
+		msg.Identity = req.Identity.Key()
+	}
+
+	return msg, nil
+}
+
+// screen checks the recipient of funds not requested through the send operations, e.g. from a voucher or a grant,
+// against the access lists and the screening guard, if configured.
+func (r *Resolver) screen(ctx context.Context, addr types.AccAddress) error {
+	if err := r.AccessList.Check(addr.String()); err != nil {
+		return err
+	}
+
+	return r.Guards.Only(guard.NameScreening).Check(ctx, &guard.Request{
+		Address:         addr,
+		RequesterIP:     clientip.FromContext(ctx),
+		RequesterSubnet: clientip.SubnetFromContext(ctx),
+	})
+}
+
+// checkRedeemer submits the requester of a voucher redemption to the ip and captcha guards, if configured, as for the
+// send operations.
+func (r *Resolver) checkRedeemer(ctx context.Context, addr types.AccAddress, captchaToken *string) error {
+	return r.Guards.Only(guard.NameIPLimit, guard.NameCaptcha).Check(ctx, &guard.Request{
+		Address:         addr,
+		RequesterIP:     clientip.FromContext(ctx),
+		RequesterSubnet: clientip.SubnetFromContext(ctx),
+		CaptchaToken:    captchaToken,
+		APIKey:          apikey.FromContext(ctx),
+	})
+}
+
+// approveGrant screens the address of the pending grant with the given ID before approving it, returning the approved
+// grant along with its address.
+func (r *Resolver) approveGrant(ctx context.Context, id, requestID string) (*grant.Grant, types.AccAddress, error) {
+	pending, err := r.GrantStore.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	addr, err := r.parseAddress(pending.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.screen(ctx, addr); err != nil {
+		return nil, nil, err
+	}
+
+	approved, err := grant.Approve(r.GrantStore, id, requestID)
+	return approved, addr, err
+}
+
+// requestedAmount returns the amount to send given the send input, defaulting to the configured one.
+func (r *Resolver) requestedAmount(input model.SendInput) (types.Coins, error) {
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	amount, denom := r.Config.AmountSend, r.Config.Denom
+	if input.Amount != nil {
+		amount = *input.Amount
+	}
+	if input.Denom != nil {
+		denom = *input.Denom
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	if err := types.ValidateDenom(denom); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidArgument, err)
+	}
+
+	return types.NewCoins(types.NewInt64Coin(denom, amount)), nil
+}
+
+// audit records the outcome of the given administrative action.
+func (r *Resolver) audit(ctx context.Context, action string, params map[string]interface{}, err error) {
+	entry := audit.Entry{
+		Action:   action,
+		Params:   params,
+		RemoteIP: clientip.FromContext(ctx),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	r.Audit.Record(entry)
+}
+
+// controlFaucet sends the given control message to the faucet, recording the action in the audit log, and returns the
+// resulting faucet status.
+func (r *Resolver) controlFaucet(
+	ctx context.Context,
+	action string,
+	params map[string]interface{},
+	msg interface{},
+) (*model.Status, error) {
+	resp, err := r.Context.RequestFuture(r.Faucet, msg, requestTimeout).Result()
+	if err == nil {
+		if _, ok := resp.(*message.GetStatusResponse); !ok {
+			err = errors.New("wrong response message")
+		}
+	}
+	r.audit(ctx, action, params, err)
+	if err != nil {
+		log.Err(err).Str("action", action).Msg("❌ Could not control faucet")
+		return nil, err
+	}
+
+	return toStatus(resp.(*message.GetStatusResponse)), nil
+}
+
+// newGrant submits the large grant request to the captcha, ip and eligibility guards, returning the grant pending
+// approval if accepted.
+func (r *Resolver) newGrant(
+	ctx context.Context,
+	address string,
+	amount int64,
+	justification string,
+	captchaToken *string,
+) (*grant.Grant, error) {
+	addr, err := r.parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.AccessList.Check(addr.String()); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	justification = strings.TrimSpace(justification)
+	if justification == "" || len(justification) > maxJustificationLength {
+		return nil, fmt.Errorf("%w: justification must be given in at most %d characters", ErrInvalidArgument, maxJustificationLength)
+	}
+
+	r.configMu.RLock()
+	denom := r.Config.Denom
+	r.configMu.RUnlock()
+
+	req := &guard.Request{
+		Address:         addr,
+		Amount:          types.NewCoins(types.NewInt64Coin(denom, amount)),
+		RequesterIP:     clientip.FromContext(ctx),
+		RequesterSubnet: clientip.SubnetFromContext(ctx),
+		CaptchaToken:    captchaToken,
+		APIKey:          apikey.FromContext(ctx),
+		Identity:        identity.FromContext(ctx),
+	}
+	if err := r.Guards.Only(guard.NameCaptcha, guard.NameIPLimit, guard.NameEligibility).Check(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return &grant.Grant{
+		ID:            uuid.NewString(),
+		Address:       addr.String(),
+		Amount:        req.Amount,
+		Justification: justification,
+		RequesterIP:   req.RequesterIP,
+		Status:        grant.StatusPending,
+		CreatedAt:     time.Now().UTC(),
+	}, nil
+}
+
+// newVouchers checks the given voucher generation parameters, returning the generated vouchers.
+func (r *Resolver) newVouchers(count int, amount int64, expiresAt time.Time, batch string) ([]voucher.Voucher, error) {
+	if count <= 0 || count > maxVouchers {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidArgument, maxVouchers)
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiry must be in the future", ErrInvalidArgument)
+	}
+
+	r.configMu.RLock()
+	denom := r.Config.Denom
+	r.configMu.RUnlock()
+
+	return voucher.Generate(count, types.NewCoins(types.NewInt64Coin(denom, amount)), expiresAt, batch)
 }
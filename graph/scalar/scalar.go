@@ -9,6 +9,9 @@ import (
 	"github.com/cosmos/cosmos-sdk/types"
 )
 
+// ErrInvalidAddress is returned when an address cannot be decoded.
+var ErrInvalidAddress = errors.New("invalid address")
+
 func MarshalAddress(a string) graphql.Marshaler {
 	return graphql.WriterFunc(func(w io.Writer) {
 		_, _ = w.Write([]byte(a))
@@ -18,10 +21,10 @@ func MarshalAddress(a string) graphql.Marshaler {
 func UnmarshalAddress(v interface{}) (string, error) {
 	value, ok := v.(string)
 	if !ok {
-		return "", errors.New("address must be a string")
+		return "", fmt.Errorf("%w: address must be a string", ErrInvalidAddress)
 	}
 	if _, err := types.AccAddressFromBech32(value); err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %s", ErrInvalidAddress, err)
 	}
 	return value, nil
 }
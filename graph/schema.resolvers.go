@@ -5,74 +5,560 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"okp4/cosmos-faucet/graph/generated"
 	"okp4/cosmos-faucet/graph/model"
+	"okp4/cosmos-faucet/internal/clientip"
+	"okp4/cosmos-faucet/pkg/access"
 	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/captcha"
+	"okp4/cosmos-faucet/pkg/grant"
+	"okp4/cosmos-faucet/pkg/identity"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/voucher"
+	"strings"
+	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
 	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+// AllowAddress is the resolver for the allowAddress field.
+func (r *mutationResolver) AllowAddress(ctx context.Context, entry string) (*access.Lists, error) {
+	return r.editAccessLists(ctx, "allowAddress", entry, r.AccessList.Allow)
+}
+
+// ApproveGrant is the resolver for the approveGrant field.
+func (r *mutationResolver) ApproveGrant(ctx context.Context, id string) (*model.Grant, error) {
+	requestID := uuid.NewString()
+	approved, addr, err := r.approveGrant(ctx, id, requestID)
+	r.audit(ctx, "approveGrant", map[string]interface{}{"id": id, "requestID": requestID}, err)
+	if err != nil {
+		log.Err(err).Str("id", id).Msg("❌ Could not serve approveGrant mutation")
+		return nil, err
+	}
+
+	r.Context.Send(r.Faucet, &message.RequestFunds{
+		ID:              requestID,
+		Address:         addr,
+		Amount:          approved.Amount,
+		RequesterIP:     approved.RequesterIP,
+		RequesterSubnet: clientip.Subnet(approved.RequesterIP),
+	})
+
+	return toGrant(*approved), nil
+}
+
+// DenyAddress is the resolver for the denyAddress field.
+func (r *mutationResolver) DenyAddress(ctx context.Context, entry string) (*access.Lists, error) {
+	return r.editAccessLists(ctx, "denyAddress", entry, r.AccessList.Deny)
+}
+
+// DisallowAddress is the resolver for the disallowAddress field.
+func (r *mutationResolver) DisallowAddress(ctx context.Context, entry string) (*access.Lists, error) {
+	return r.editAccessLists(ctx, "disallowAddress", entry, r.AccessList.Disallow)
+}
+
+// FlushQueue is the resolver for the flushQueue field.
+func (r *mutationResolver) FlushQueue(ctx context.Context) (*model.Status, error) {
+	return r.controlFaucet(ctx, "flushQueue", nil, &message.Flush{})
+}
+
+// GenerateVouchers is the resolver for the generateVouchers field.
+func (r *mutationResolver) GenerateVouchers(
+	ctx context.Context,
+	count int,
+	amount int64,
+	expiresAt time.Time,
+	batch *string,
+) ([]*model.Voucher, error) {
+	var name string
+	if batch != nil {
+		name = *batch
+	}
+
+	vouchers, err := r.newVouchers(count, amount, expiresAt, name)
+	if err == nil {
+		err = r.VoucherStore.Put(vouchers...)
+	}
+	r.audit(ctx, "generateVouchers", map[string]interface{}{
+		"count":     count,
+		"amount":    amount,
+		"expiresAt": expiresAt,
+		"batch":     name,
+	}, err)
+	if err != nil {
+		log.Err(err).Msg("❌ Could not serve generateVouchers mutation")
+		return nil, err
+	}
+
+	result := make([]*model.Voucher, 0, len(vouchers))
+	for _, v := range vouchers {
+		result = append(result, toVoucher(v))
+	}
+	return result, nil
+}
+
+// Pause is the resolver for the pause field.
+func (r *mutationResolver) Pause(ctx context.Context) (*model.Status, error) {
+	return r.controlFaucet(ctx, "pause", nil, &message.Pause{})
+}
+
+// RedeemVoucher is the resolver for the redeemVoucher field.
+func (r *mutationResolver) RedeemVoucher(
+	ctx context.Context,
+	code string,
+	address string,
+	captchaToken *string,
+) (string, error) {
+	addr, err := r.parseAddress(address)
+	if err == nil {
+		err = r.screen(ctx, addr)
+	}
+	if err == nil {
+		err = r.checkRedeemer(ctx, addr, captchaToken)
+	}
+	if err != nil {
+		log.Err(err).Str("address", address).Msg("❌ Could not serve redeemVoucher mutation")
+		return "", err
+	}
+
+	requestID := uuid.NewString()
+	redeemed, err := r.VoucherStore.Redeem(voucher.NormalizeCode(code), addr.String(), requestID, time.Now().UTC())
+	if err != nil {
+		log.Err(err).Str("address", address).Msg("❌ Could not serve redeemVoucher mutation")
+		return "", err
+	}
+
+	r.Context.Send(r.Faucet, &message.RequestFunds{
+		ID:              requestID,
+		Address:         addr,
+		Amount:          redeemed.Amount,
+		RequesterIP:     clientip.FromContext(ctx),
+		RequesterSubnet: clientip.SubnetFromContext(ctx),
+	})
+	log.Info().Str("address", address).Str("requestID", requestID).Msg("🎟️  Redeem voucher")
+
+	return requestID, nil
+}
+
+// RejectGrant is the resolver for the rejectGrant field.
+func (r *mutationResolver) RejectGrant(ctx context.Context, id string, reason *string) (*model.Grant, error) {
+	var why string
+	if reason != nil {
+		why = *reason
+	}
+
+	rejected, err := grant.Reject(r.GrantStore, id, why)
+	r.audit(ctx, "rejectGrant", map[string]interface{}{"id": id, "reason": why}, err)
+	if err != nil {
+		log.Err(err).Str("id", id).Msg("❌ Could not serve rejectGrant mutation")
+		return nil, err
+	}
+
+	return toGrant(*rejected), nil
+}
+
+// RemoveRequest is the resolver for the removeRequest field.
+func (r *mutationResolver) RemoveRequest(ctx context.Context, id string) (bool, error) {
+	resp, err := r.Context.RequestFuture(r.Faucet, &message.RemoveRequest{ID: id}, requestTimeout).Result()
+	removed := false
+	if err == nil {
+		if resp, ok := resp.(*message.RemoveRequestResponse); ok {
+			removed = resp.Removed
+		} else {
+			err = errors.New("wrong response message")
+		}
+	}
+	r.audit(ctx, "removeRequest", map[string]interface{}{"id": id, "removed": removed}, err)
+	if err != nil {
+		log.Err(err).Str("id", id).Msg("❌ Could not serve removeRequest mutation")
+		return false, err
+	}
+
+	return removed, nil
+}
+
+// RequestLargeGrant is the resolver for the requestLargeGrant field.
+func (r *mutationResolver) RequestLargeGrant(
+	ctx context.Context,
+	address string,
+	amount int64,
+	justification string,
+	captchaToken *string,
+) (*model.Grant, error) {
+	g, err := r.newGrant(ctx, address, amount, justification, captchaToken)
+	if err == nil {
+		err = r.GrantStore.Add(*g)
+	}
+	if err != nil {
+		log.Err(err).Str("address", address).Msg("❌ Could not serve requestLargeGrant mutation")
+		return nil, err
+	}
+
+	log.Info().Str("address", address).Str("grantID", g.ID).Msg("📝 Register grant pending approval")
+	return toGrant(*g), nil
+}
+
+// Resume is the resolver for the resume field.
+func (r *mutationResolver) Resume(ctx context.Context) (*model.Status, error) {
+	return r.controlFaucet(ctx, "resume", nil, &message.Resume{})
+}
+
 // Send is the resolver for the send field.
-func (r *mutationResolver) Send(ctx context.Context, input model.SendInput) (*string, error) {
-	addr, err := types.GetFromBech32(input.ToAddress, r.AddressPrefix)
+func (r *mutationResolver) Send(ctx context.Context, input model.SendInput) (string, error) {
+	msg, err := r.newRequestFunds(ctx, input)
 	if err != nil {
 		log.Err(err).Str("toAddress", input.ToAddress).Msg("❌ Could not serve send mutation")
+		return "", err
+	}
+
+	r.Context.Send(r.Faucet, msg)
+	return msg.ID, nil
+}
+
+// SetAmount is the resolver for the setAmount field.
+func (r *mutationResolver) SetAmount(ctx context.Context, amount int64) (*model.Status, error) {
+	params := map[string]interface{}{"amount": amount}
+	if amount <= 0 {
+		err := fmt.Errorf("%w: amount must be positive", ErrInvalidArgument)
+		r.audit(ctx, "setAmount", params, err)
 		return nil, err
 	}
 
-	if err = r.CaptchaResolver.CheckRecaptcha(ctx, input.CaptchaToken); err != nil {
-		log.Err(err).Str("toAddress", input.ToAddress).Msg("❌ Could not serve send mutation")
+	r.configMu.RLock()
+	denom := r.Config.Denom
+	r.configMu.RUnlock()
+
+	status, err := r.controlFaucet(ctx, "setAmount", params, &message.SetAmount{
+		Amount: types.NewCoins(types.NewInt64Coin(denom, amount)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.configMu.Lock()
+	r.Config.AmountSend = amount
+	r.configMu.Unlock()
+
+	return status, nil
+}
+
+// SetCaptchaMinScore is the resolver for the setCaptchaMinScore field.
+func (r *mutationResolver) SetCaptchaMinScore(ctx context.Context, score float64) (float64, error) {
+	params := map[string]interface{}{"score": score}
+	if score < 0 || score > 1 {
+		err := fmt.Errorf("%w: score must be between 0 and 1", ErrInvalidArgument)
+		r.audit(ctx, "setCaptchaMinScore", params, err)
+		return 0, err
+	}
+
+	err := r.CaptchaResolver.SetMinScore(score)
+	r.audit(ctx, "setCaptchaMinScore", params, err)
+	if err != nil {
+		log.Err(err).Msg("❌ Could not set captcha min score")
+		return 0, err
+	}
+
+	return r.CaptchaResolver.MinScore(), nil
+}
+
+// UndenyAddress is the resolver for the undenyAddress field.
+func (r *mutationResolver) UndenyAddress(ctx context.Context, entry string) (*access.Lists, error) {
+	return r.editAccessLists(ctx, "undenyAddress", entry, r.AccessList.Undeny)
+}
+
+// AccessLists is the resolver for the accessLists field.
+func (r *queryResolver) AccessLists(ctx context.Context) (*access.Lists, error) {
+	lists := r.AccessList.Lists()
+	return &lists, nil
+}
+
+// CaptchaChallenge is the resolver for the captchaChallenge field.
+func (r *queryResolver) CaptchaChallenge(ctx context.Context) (*model.CaptchaChallenge, error) {
+	challenger, ok := r.CaptchaResolver.(captcha.Challenger)
+	if !ok || !r.CaptchaResolver.Enabled() {
+		return nil, captcha.ErrChallengeNotSupported
+	}
+
+	challenge, err := challenger.IssueChallenge()
+	if err != nil {
+		log.Err(err).Msg("❌ Could not serve captchaChallenge query")
 		return nil, err
 	}
 
-	r.Context.Send(r.Faucet, &message.RequestFunds{Address: addr})
-	return nil, nil
+	return &model.CaptchaChallenge{
+		Challenge:  challenge.Challenge,
+		Difficulty: challenge.Difficulty,
+		ExpiresAt:  challenge.ExpiresAt,
+	}, nil
+}
+
+// Challenge is the resolver for the challenge field.
+func (r *queryResolver) Challenge(ctx context.Context, address string) (*model.Challenge, error) {
+	if r.OwnershipVerifier == nil {
+		return nil, fmt.Errorf("%w: proof of address ownership is not enabled", ErrInvalidArgument)
+	}
+
+	challenge, err := r.OwnershipVerifier.Issue(address)
+	if err != nil {
+		log.Err(err).Str("address", address).Msg("❌ Could not serve challenge query")
+		return nil, err
+	}
+
+	return &model.Challenge{Nonce: challenge.Nonce, ExpiresAt: challenge.ExpiresAt}, nil
 }
 
 // Configuration is the resolver for the configuration field.
 func (r *queryResolver) Configuration(ctx context.Context) (*model.Configuration, error) {
-	return r.Config, nil
+	r.configMu.RLock()
+	defer r.configMu.RUnlock()
+
+	config := *r.Config
+	return &config, nil
 }
 
-// Send is the resolver for the send field.
-func (r *subscriptionResolver) Send(ctx context.Context, input model.SendInput) (<-chan *model.TxResponse, error) {
-	addr, err := types.GetFromBech32(input.ToAddress, r.AddressPrefix)
+// Distribution is the resolver for the distribution field.
+func (r *queryResolver) Distribution(ctx context.Context, id string) (*model.Distribution, error) {
+	record, err := r.Ledger.Get(id)
+	if errors.Is(err, ledger.ErrNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		log.Err(err).Str("toAddress", input.ToAddress).Msg("❌ Could not serve send mutation")
 		return nil, err
 	}
 
-	if err := r.CaptchaResolver.CheckRecaptcha(ctx, input.CaptchaToken); err != nil {
+	return toDistribution(*record), nil
+}
+
+// Distributions is the resolver for the distributions field.
+func (r *queryResolver) Distributions(
+	ctx context.Context,
+	address *string,
+	first *int,
+	after *string,
+) (*model.DistributionConnection, error) {
+	query := ledger.Query{First: maxPageSize}
+	if address != nil {
+		query.Address = *address
+	}
+	if first != nil {
+		if *first < 1 {
+			return nil, fmt.Errorf("%w: first must be positive", ErrInvalidArgument)
+		}
+		if *first < maxPageSize {
+			query.First = *first
+		}
+	}
+	if after != nil {
+		query.After = *after
+	}
+
+	records, hasNext, err := r.Ledger.List(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDistributionConnection(records, hasNext), nil
+}
+
+// Eligibility is the resolver for the eligibility field.
+func (r *queryResolver) Eligibility(ctx context.Context, address string) (*model.Eligibility, error) {
+	addr, err := r.parseAddress(address)
+	if err != nil {
+		log.Err(err).Str("address", address).Msg("❌ Could not serve eligibility query")
+		return nil, err
+	}
+
+	decision, err := r.EligibilityChecker.Check(ctx, addr.String())
+	if err != nil {
+		log.Err(err).Str("address", address).Msg("❌ Could not serve eligibility query")
+		return nil, err
+	}
+
+	return toEligibility(decision), nil
+}
+
+// Grant is the resolver for the grant field.
+func (r *queryResolver) Grant(ctx context.Context, id string) (*model.Grant, error) {
+	g, err := r.GrantStore.Get(id)
+	if errors.Is(err, grant.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Err(err).Str("id", id).Msg("❌ Could not serve grant query")
+		return nil, err
+	}
+
+	return toGrant(*g), nil
+}
+
+// Grants is the resolver for the grants field.
+func (r *queryResolver) Grants(ctx context.Context, status *model.GrantStatus) ([]*model.Grant, error) {
+	var filter grant.Status
+	if status != nil {
+		filter = grant.Status(strings.ToLower(string(*status)))
+	}
+
+	grants, err := r.GrantStore.List(filter)
+	if err != nil {
+		log.Err(err).Msg("❌ Could not serve grants query")
+		return nil, err
+	}
+
+	result := make([]*model.Grant, 0, len(grants))
+	for _, g := range grants {
+		result = append(result, toGrant(g))
+	}
+	return result, nil
+}
+
+// Identity is the resolver for the identity field.
+func (r *queryResolver) Identity(ctx context.Context) (*identity.Identity, error) {
+	return identity.FromContext(ctx), nil
+}
+
+// Request is the resolver for the request field.
+func (r *queryResolver) Request(ctx context.Context, id string) (*model.Request, error) {
+	resp, err := r.Context.RequestFuture(r.Faucet, &message.GetRequest{ID: id}, requestTimeout).Result()
+	if err != nil {
+		log.Err(err).Str("id", id).Msg("❌ Could not serve request query")
+		return nil, err
+	}
+
+	switch resp := resp.(type) {
+	case *message.GetRequestResponse:
+		if resp.Record == nil {
+			return nil, nil
+		}
+		return toRequest(*resp.Record), nil
+	default:
+		return nil, errors.New("wrong response message")
+	}
+}
+
+// Stats is the resolver for the stats field.
+func (r *queryResolver) Stats(ctx context.Context, rangeArg model.StatsRange) (*model.StatsReport, error) {
+	interval := time.Hour
+	if rangeArg.Interval != nil && *rangeArg.Interval == model.StatsIntervalDay {
+		interval = 24 * time.Hour
+	}
+	to := time.Now()
+	if rangeArg.To != nil {
+		to = *rangeArg.To
+	}
+	if !rangeArg.From.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidArgument)
+	}
+	if to.Sub(rangeArg.From)/interval > maxStatsBuckets {
+		return nil, fmt.Errorf("%w: range cannot exceed %d intervals", ErrInvalidArgument, maxStatsBuckets)
+	}
+
+	total, buckets, err := ledger.ComputeStats(r.Ledger, rangeArg.From, to, interval)
+	if err != nil {
+		log.Err(err).Msg("❌ Could not serve stats query")
+		return nil, err
+	}
+
+	return toStatsReport(total, buckets), nil
+}
+
+// Status is the resolver for the status field.
+func (r *queryResolver) Status(ctx context.Context) (*model.Status, error) {
+	resp, err := r.Context.RequestFuture(r.Faucet, &message.GetStatus{}, requestTimeout).Result()
+	if err != nil {
+		log.Err(err).Msg("❌ Could not serve status query")
+		return nil, err
+	}
+
+	switch resp := resp.(type) {
+	case *message.GetStatusResponse:
+		return toStatus(resp), nil
+	default:
+		return nil, errors.New("wrong response message")
+	}
+}
+
+// Vouchers is the resolver for the vouchers field.
+func (r *queryResolver) Vouchers(ctx context.Context, batch *string) ([]*model.Voucher, error) {
+	var name string
+	if batch != nil {
+		name = *batch
+	}
+
+	vouchers, err := r.VoucherStore.List(name)
+	if err != nil {
+		log.Err(err).Msg("❌ Could not serve vouchers query")
+		return nil, err
+	}
+
+	result := make([]*model.Voucher, 0, len(vouchers))
+	for _, v := range vouchers {
+		result = append(result, toVoucher(v))
+	}
+	return result, nil
+}
+
+// FaucetEvents is the resolver for the faucetEvents field.
+func (r *subscriptionResolver) FaucetEvents(ctx context.Context) (<-chan *model.FaucetEvent, error) {
+	events := make(chan *model.FaucetEvent, eventsBufferSize)
+	sub := r.Events.Subscribe(func(evt interface{}) {
+		faucetEvent := toFaucetEvent(evt)
+		if faucetEvent == nil {
+			return
+		}
+
+		select {
+		case events <- faucetEvent:
+		default:
+			log.Warn().Msg("😥 Drop faucet event for slow subscriber")
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		r.Events.Unsubscribe(sub)
+		close(events)
+	}()
+
+	return events, nil
+}
+
+// Send is the resolver for the send field.
+func (r *subscriptionResolver) Send(ctx context.Context, input model.SendInput) (<-chan *model.TxResponse, error) {
+	msg, err := r.newRequestFunds(ctx, input)
+	if err != nil {
 		log.Err(err).Str("toAddress", input.ToAddress).Msg("❌ Could not serve send mutation")
 		return nil, err
 	}
 
 	txResponseChan := make(chan *model.TxResponse)
-	r.Context.Send(
-		r.Faucet,
-		&message.RequestFunds{
-			Address: addr,
-			TxSubscriber: r.Context.Spawn(
-				actor.PropsFromFunc(
-					func(c actor.Context) {
-						if msg, ok := c.Message().(*message.BroadcastTxResponse); ok {
-							txResponseChan <- &model.TxResponse{
-								Hash:      msg.TxResponse.TxHash,
-								Code:      int(msg.TxResponse.Code),
-								RawLog:    &msg.TxResponse.RawLog,
-								GasWanted: msg.TxResponse.GasWanted,
-								GasUsed:   msg.TxResponse.GasUsed,
-							}
-							close(txResponseChan)
-							c.Stop(c.Self())
-						}
-					},
-				),
-			),
-		},
+	msg.TxSubscriber = r.Context.Spawn(
+		actor.PropsFromFunc(
+			func(c actor.Context) {
+				switch resp := c.Message().(type) {
+				case *message.BroadcastTxResponse:
+					txResponseChan <- &model.TxResponse{
+						Hash:      resp.TxResponse.TxHash,
+						Code:      int(resp.TxResponse.Code),
+						RawLog:    &resp.TxResponse.RawLog,
+						GasWanted: resp.TxResponse.GasWanted,
+						GasUsed:   resp.TxResponse.GasUsed,
+					}
+					close(txResponseChan)
+					c.Stop(c.Self())
+				case *message.TxFailed:
+					endSubscription(ctx, fmt.Errorf("%w: %s", ErrTxFailed, resp.Error))
+					close(txResponseChan)
+					c.Stop(c.Self())
+				}
+			},
+		),
 	)
+	r.Context.Send(r.Faucet, msg)
 
 	return txResponseChan, nil
 }
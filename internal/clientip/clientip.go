@@ -0,0 +1,130 @@
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+const (
+	// ipv4SubnetBits is the prefix length of the IPv4 subnets, typically allocated to a single organization.
+	ipv4SubnetBits = 24
+	// ipv6SubnetBits is the prefix length of the IPv6 subnets, typically allocated to a single site.
+	ipv6SubnetBits = 64
+)
+
+type contextKey struct{}
+
+// Middleware returns a middleware storing the IP address of the client issuing the request in the request context. When
+// the request comes from one of the trusted proxies, the client IP address is taken from the X-Forwarded-For header,
+// skipping the trusted proxies it went through.
+func Middleware(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			ip := resolve(host, r.Header.Values(forwardedForHeader), trustedProxies)
+			next.ServeHTTP(w, r.WithContext(WithIP(r.Context(), ip)))
+		})
+	}
+}
+
+// resolve returns the client IP address given the address of the peer and the X-Forwarded-For header values, walking
+// the forwarded addresses from the closest one as long as they are appended by trusted proxies.
+func resolve(peer string, forwardedFor []string, trustedProxies []*net.IPNet) string {
+	if len(trustedProxies) == 0 {
+		return peer
+	}
+
+	var hops []string
+	for _, value := range forwardedFor {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	ip := peer
+	for i := len(hops) - 1; i >= 0 && trusted(ip, trustedProxies); i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		ip = hops[i]
+	}
+	return ip
+}
+
+func trusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNetworks parses the given IP addresses or CIDR networks, e.g. the trusted proxies.
+func ParseNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Subnet returns the /24 IPv4 or /64 IPv6 subnet of the given IP address in CIDR notation, an empty string if the IP
+// address is not valid.
+func Subnet(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(ipv4SubnetBits, 32)), Mask: net.CIDRMask(ipv4SubnetBits, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(ipv6SubnetBits, 128)), Mask: net.CIDRMask(ipv6SubnetBits, 128)}).String()
+}
+
+// WithIP returns a copy of the context holding the given client IP address.
+func WithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromContext returns the client IP address held by the context, or an empty string if unknown.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKey{}).(string)
+	return ip
+}
+
+// SubnetFromContext returns the subnet of the client IP address held by the context, or an empty string if unknown.
+func SubnetFromContext(ctx context.Context) string {
+	return Subnet(FromContext(ctx))
+}
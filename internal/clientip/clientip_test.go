@@ -0,0 +1,137 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolve(t *testing.T) {
+	trustedProxies, err := ParseNetworks([]string{"10.0.0.0/8", "2001:db8:ffff::/48"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name         string
+		peer         string
+		forwardedFor []string
+		trusted      bool
+		want         string
+	}{
+		{"no trusted proxy configured", "10.0.0.1", []string{"198.51.100.1"}, false, "10.0.0.1"},
+		{"an untrusted peer sending a spoofed header", "203.0.113.7", []string{"198.51.100.1"}, true, "203.0.113.7"},
+		{"a trusted peer without header", "10.0.0.1", nil, true, "10.0.0.1"},
+		{"a trusted peer", "10.0.0.1", []string{"198.51.100.1"}, true, "198.51.100.1"},
+		{
+			"a chain of trusted proxies after a spoofed hop", "10.0.0.1",
+			[]string{"192.0.2.66, 198.51.100.1, 10.0.0.3, 10.0.0.2"}, true, "198.51.100.1",
+		},
+		{
+			"a chain of trusted proxies over several headers", "10.0.0.1",
+			[]string{"192.0.2.66", "198.51.100.1, 10.0.0.2"}, true, "198.51.100.1",
+		},
+		{"only trusted proxies", "10.0.0.1", []string{"10.0.0.3, 10.0.0.2"}, true, "10.0.0.3"},
+		{"a malformed hop", "10.0.0.1", []string{"198.51.100.1, not-an-ip"}, true, "10.0.0.1"},
+		{"a malformed hop behind trusted proxies", "10.0.0.1", []string{"garbage, 10.0.0.2"}, true, "10.0.0.2"},
+		{"empty hops", "10.0.0.1", []string{" , 198.51.100.1 ,"}, true, "198.51.100.1"},
+		{"a trusted IPv6 proxy", "2001:db8:ffff::1", []string{"2001:db8:1::7"}, true, "2001:db8:1::7"},
+	}
+
+	for _, c := range cases {
+		Convey("Given "+c.name, t, func() {
+			proxies := trustedProxies
+			if !c.trusted {
+				proxies = nil
+			}
+
+			Convey("Then the client IP address should be "+c.want, func() {
+				So(resolve(c.peer, c.forwardedFor, proxies), ShouldEqual, c.want)
+			})
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given the middleware trusting a proxy", t, func() {
+		networks, err := ParseNetworks([]string{"10.0.0.1"})
+		So(err, ShouldBeNil)
+
+		var ip string
+		handler := Middleware(networks)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			ip = FromContext(r.Context())
+		}))
+
+		Convey("When a request comes through the proxy", func() {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			req.RemoteAddr = "10.0.0.1:4242"
+			req.Header.Add(forwardedForHeader, "198.51.100.1")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then the forwarded address should be stored in the context", func() {
+				So(ip, ShouldEqual, "198.51.100.1")
+			})
+		})
+
+		Convey("When a request comes from another peer", func() {
+			req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			req.RemoteAddr = "10.0.0.2:4242"
+			req.Header.Add(forwardedForHeader, "198.51.100.1")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			Convey("Then the peer address should be stored in the context", func() {
+				So(ip, ShouldEqual, "10.0.0.2")
+			})
+		})
+	})
+}
+
+func TestParseNetworks(t *testing.T) {
+	Convey("When parsing IP addresses and networks", t, func() {
+		networks, err := ParseNetworks([]string{"10.0.0.1", "192.168.0.0/16", "2001:db8::1", "2001:db8::/32"})
+
+		Convey("Then the addresses should be single host networks", func() {
+			So(err, ShouldBeNil)
+			So(networks, ShouldHaveLength, 4)
+			So(networks[0].String(), ShouldEqual, "10.0.0.1/32")
+			So(networks[1].String(), ShouldEqual, "192.168.0.0/16")
+			So(networks[2].String(), ShouldEqual, "2001:db8::1/128")
+			So(networks[3].String(), ShouldEqual, "2001:db8::/32")
+		})
+	})
+
+	for _, entry := range []string{"", "not-an-ip", "10.0.0.256", "10.0.0.0/33", "2001:db8::/129"} {
+		Convey("When parsing the invalid entry "+entry, t, func() {
+			_, err := ParseNetworks([]string{entry})
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	}
+}
+
+func TestSubnet(t *testing.T) {
+	cases := []struct {
+		ip   string
+		want string
+	}{
+		{"198.51.100.42", "198.51.100.0/24"},
+		{"::ffff:198.51.100.42", "198.51.100.0/24"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"2001:db8:1:2::", "2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "2001:db8:1:3::/64"},
+		{"not-an-ip", ""},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		Convey("When getting the subnet of "+c.ip, t, func() {
+			Convey("Then it should be "+c.want, func() {
+				So(Subnet(c.ip), ShouldEqual, c.want)
+			})
+		})
+	}
+}
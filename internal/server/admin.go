@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"okp4/cosmos-faucet/graph"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gorilla/mux"
+)
+
+const bearerPrefix = "Bearer "
+
+type adminContextKey struct{}
+
+// adminMiddleware flags the request context as administrator when it bears the given token.
+func adminMiddleware(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if token != "" && strings.HasPrefix(header, bearerPrefix) &&
+				subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, bearerPrefix)), []byte(token)) == 1 {
+				r = r.WithContext(context.WithValue(r.Context(), adminContextKey{}, true))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// trustedAdminMiddleware flags every request context as administrator, for the listener dedicated to administration.
+func trustedAdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), adminContextKey{}, true)))
+	})
+}
+
+// adminDirective implements the `@admin` directive, rejecting unauthenticated access to the annotated fields.
+func adminDirective(ctx context.Context, _ interface{}, next graphql.Resolver) (interface{}, error) {
+	if isAdmin, _ := ctx.Value(adminContextKey{}).(bool); !isAdmin {
+		return nil, graph.ErrUnauthorized
+	}
+
+	return next(ctx)
+}
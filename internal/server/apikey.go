@@ -0,0 +1,33 @@
+package server
+
+import (
+	"net/http"
+	"okp4/cosmos-faucet/pkg/apikey"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const apiKeyHeader = "X-Api-Key"
+
+// apiKeyMiddleware stores in the request context the API key given in the request header, rejecting the requests with
+// an unknown key.
+func apiKeyMiddleware(keyring *apikey.Keyring) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get(apiKeyHeader)
+			if secret == "" || keyring == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := keyring.Authenticate(secret)
+			if err != nil {
+				log.Debug().Err(err).Msg("Reject request with invalid API key")
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(apikey.WithKey(r.Context(), key)))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"okp4/cosmos-faucet/pkg/identity"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	sessionCookie = "faucet_session"
+	stateCookie   = "faucet_oauth_state"
+	// stateTTL is the time given to the requester to log in at the identity provider.
+	stateTTL = 10 * time.Minute
+)
+
+// oauthHandler serves the OAuth authorization code flow logging in the requesters at the identity provider.
+type oauthHandler struct {
+	provider  identity.Provider
+	sessions  *identity.Sessions
+	returnURL string
+}
+
+// login redirects the requester to the identity provider, with a state bound to its browser to prevent cross-site
+// request forgery.
+func (h oauthHandler) login(w http.ResponseWriter, r *http.Request) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(nonce)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   int(stateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, h.provider.AuthCodeURL(state), http.StatusFound)
+}
+
+// callback exchanges the authorization code given back by the identity provider for the identity of the requester,
+// issuing its session.
+func (h oauthHandler) callback(w http.ResponseWriter, r *http.Request) {
+	state, err := r.Cookie(stateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(state.Value), []byte(r.URL.Query().Get("state"))) != 1 {
+		http.Error(w, "invalid oauth state", http.StatusBadRequest)
+		return
+	}
+	clearCookie(w, r, stateCookie)
+
+	id, err := h.provider.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		log.Warn().Err(err).Str("provider", h.provider.Name()).Msg("😥 Could not log in requester")
+		http.Error(w, "could not log in", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := h.sessions.Issue(*id)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(h.sessions.TTL().Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	log.Info().Str("identity", id.Key()).Str("login", id.Login).Msg("🔑 Requester logged in")
+	http.Redirect(w, r, h.returnURL, http.StatusFound)
+}
+
+// logout ends the session of the requester.
+func (h oauthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	clearCookie(w, r, sessionCookie)
+	http.Redirect(w, r, h.returnURL, http.StatusFound)
+}
+
+func clearCookie(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// sessionMiddleware stores in the request context the identity of the logged in requester, ignoring the invalid or
+// expired sessions.
+func sessionMiddleware(sessions *identity.Sessions) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(sessionCookie)
+			if sessions == nil || err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, err := sessions.Verify(cookie.Value)
+			if err != nil {
+				log.Debug().Err(err).Msg("Ignore invalid session")
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(identity.WithIdentity(r.Context(), id)))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"okp4/cosmos-faucet/internal/clientip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// rateLimitWindow is the period over which the requests of a client are counted.
+const rateLimitWindow = time.Minute
+
+// rateLimiter counts the requests per IP address and per subnet over fixed windows.
+type rateLimiter struct {
+	perIP     int
+	perSubnet int
+	mu        sync.Mutex
+	start     time.Time
+	ips       map[string]int
+	subnets   map[string]int
+	now       func() time.Time
+}
+
+func newRateLimiter(perIP, perSubnet int) *rateLimiter {
+	return &rateLimiter{
+		perIP:     perIP,
+		perSubnet: perSubnet,
+		ips:       map[string]int{},
+		subnets:   map[string]int{},
+		now:       time.Now,
+	}
+}
+
+// allow counts a request of the given IP address, returning false and the duration to wait if one of the limits is
+// reached.
+func (l *rateLimiter) allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.start) >= rateLimitWindow {
+		l.start = now
+		l.ips = map[string]int{}
+		l.subnets = map[string]int{}
+	}
+	retryAfter := l.start.Add(rateLimitWindow).Sub(now)
+
+	subnet := clientip.Subnet(ip)
+	if l.perIP > 0 && l.ips[ip] >= l.perIP {
+		return false, retryAfter
+	}
+	if l.perSubnet > 0 && subnet != "" && l.subnets[subnet] >= l.perSubnet {
+		return false, retryAfter
+	}
+
+	l.ips[ip]++
+	if subnet != "" {
+		l.subnets[subnet]++
+	}
+	return true, 0
+}
+
+// rateLimitMiddleware rejects with the HTTP 429 status the GraphQL requests of the clients exceeding the number of
+// requests allowed per minute from their IP address or their subnet.
+func rateLimitMiddleware(limiter *rateLimiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limiter == nil || r.URL.Path != graphqlPath {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := clientip.FromContext(r.Context())
+			if ok, retryAfter := limiter.allow(ip); !ok {
+				log.Debug().Str("ip", ip).Msg("Reject request exceeding rate limit")
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
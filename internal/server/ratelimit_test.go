@@ -0,0 +1,136 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"okp4/cosmos-faucet/internal/clientip"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	type step struct {
+		ip         string
+		elapsed    time.Duration
+		allowed    bool
+		retryAfter time.Duration
+	}
+
+	cases := []struct {
+		name      string
+		perIP     int
+		perSubnet int
+		steps     []step
+	}{
+		{
+			name:  "a limit per ip address",
+			perIP: 2,
+			steps: []step{
+				{"198.51.100.1", 0, true, 0},
+				{"198.51.100.1", 10 * time.Second, true, 0},
+				{"198.51.100.1", 20 * time.Second, false, 40 * time.Second},
+				{"198.51.100.2", 20 * time.Second, true, 0},
+				{"198.51.100.1", 59 * time.Second, false, time.Second},
+				{"198.51.100.1", time.Minute, true, 0},
+				{"198.51.100.1", time.Minute, true, 0},
+				{"198.51.100.1", 90 * time.Second, false, 30 * time.Second},
+			},
+		},
+		{
+			name:      "a limit per subnet",
+			perSubnet: 3,
+			steps: []step{
+				{"198.51.100.1", 0, true, 0},
+				{"198.51.100.2", 0, true, 0},
+				{"198.51.100.3", 30 * time.Second, true, 0},
+				{"198.51.100.4", 30 * time.Second, false, 30 * time.Second},
+				{"198.51.101.1", 30 * time.Second, true, 0},
+				{"198.51.100.4", time.Minute, true, 0},
+			},
+		},
+		{
+			name:      "a limit per IPv6 /64 subnet",
+			perSubnet: 2,
+			steps: []step{
+				{"2001:db8:1:2::1", 0, true, 0},
+				{"2001:db8:1:2:ffff::1", 0, true, 0},
+				{"2001:db8:1:2::3", 15 * time.Second, false, 45 * time.Second},
+				{"2001:db8:1:3::1", 15 * time.Second, true, 0},
+			},
+		},
+		{
+			name:      "both limits",
+			perIP:     1,
+			perSubnet: 2,
+			steps: []step{
+				{"198.51.100.1", 0, true, 0},
+				{"198.51.100.1", 0, false, time.Minute},
+				{"198.51.100.2", 0, true, 0},
+				{"198.51.100.3", 0, false, time.Minute},
+			},
+		},
+		{
+			name: "no limit",
+			steps: []step{
+				{"198.51.100.1", 0, true, 0},
+				{"198.51.100.1", 0, true, 0},
+				{"198.51.100.1", 0, true, 0},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		Convey("Given a rate limiter with "+c.name, t, func() {
+			start := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+			now := start
+			limiter := newRateLimiter(c.perIP, c.perSubnet)
+			limiter.now = func() time.Time { return now }
+
+			Convey("Then the requests should be allowed until a limit is reached in the window", func() {
+				for _, s := range c.steps {
+					now = start.Add(s.elapsed)
+					allowed, retryAfter := limiter.allow(s.ip)
+					So(allowed, ShouldEqual, s.allowed)
+					So(retryAfter, ShouldEqual, s.retryAfter)
+				}
+			})
+		})
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	Convey("Given the rate limit middleware allowing a single request per minute", t, func() {
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		limiter := newRateLimiter(1, 0)
+		limiter.now = func() time.Time { return now }
+		handler := rateLimitMiddleware(limiter)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		serve := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			req = req.WithContext(clientip.WithIP(req.Context(), "198.51.100.1"))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		Convey("When a client exceeds the limit", func() {
+			first := serve(graphqlPath)
+			now = now.Add(20500 * time.Millisecond)
+			second := serve(graphqlPath)
+
+			Convey("Then it should be told when to retry", func() {
+				So(first.Code, ShouldEqual, http.StatusOK)
+				So(second.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(second.Header().Get("Retry-After"), ShouldEqual, "40")
+			})
+
+			Convey("Then its requests to other paths should not be limited", func() {
+				So(serve("/health").Code, ShouldEqual, http.StatusOK)
+			})
+		})
+	})
+}
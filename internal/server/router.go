@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"okp4/cosmos-faucet/graph"
 	"okp4/cosmos-faucet/graph/generated"
+	"okp4/cosmos-faucet/internal/clientip"
 	"okp4/cosmos-faucet/internal/server/handlers"
+	"okp4/cosmos-faucet/pkg/identity"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -13,32 +15,68 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
 
-func (s *httpServer) createRoutes(graphqlResolver *graph.Resolver, health, metrics bool) {
-	s.router.Path("/").
-		HandlerFunc(playground.Handler("GraphQL playground", "/graphql")).
+// graphqlPath is the path of the GraphQL endpoint.
+const graphqlPath = "/graphql"
+
+func (s *httpServer) createRoutes(graphqlResolver *graph.Resolver) {
+	var sessions *identity.Sessions
+	if s.oauth != nil {
+		sessions = s.oauth.sessions
+	}
+
+	s.router.Use(
+		clientip.Middleware(s.proxies),
+		rateLimitMiddleware(s.limiter),
+		adminMiddleware(s.adminToken),
+		apiKeyMiddleware(s.keyring),
+		sessionMiddleware(sessions),
+	)
+	createGraphQLRoutes(s.router, graphqlResolver)
+
+	if s.oauth != nil {
+		s.router.Path("/auth/login").HandlerFunc(s.oauth.login).Methods("GET")
+		s.router.Path("/auth/callback").HandlerFunc(s.oauth.callback).Methods("GET")
+		s.router.Path("/auth/logout").HandlerFunc(s.oauth.logout).Methods("GET", "POST")
+	}
+
+	if s.adminAddress != "" {
+		s.adminRouter = mux.NewRouter().StrictSlash(true)
+		s.adminRouter.Use(clientip.Middleware(s.proxies), trustedAdminMiddleware, apiKeyMiddleware(s.keyring))
+		createGraphQLRoutes(s.adminRouter, graphqlResolver)
+	}
+
+	if s.health {
+		s.router.Path("/health").
+			HandlerFunc(handlers.NewHealthRequestHandlerFunc()).
+			Methods("GET")
+	}
+	if s.metrics {
+		s.router.Path("/metrics").
+			Handler(handlers.NewMetricsRequestHandler()).
+			Methods("GET")
+	}
+}
+
+func createGraphQLRoutes(router *mux.Router, graphqlResolver *graph.Resolver) {
+	router.Path("/").
+		HandlerFunc(playground.Handler("GraphQL playground", graphqlPath)).
 		Methods("GET")
-	s.router.Path("/graphql").
+	router.Path(graphqlPath).
 		Handler(
 			newGraphQLServer(
 				generated.NewExecutableSchema(generated.Config{
 					Resolvers: graphqlResolver,
+					Directives: generated.DirectiveRoot{
+						Admin: adminDirective,
+					},
 				}),
 			),
 		).
 		Methods("GET", "POST", "OPTIONS")
-	if health {
-		s.router.Path("/health").
-			HandlerFunc(handlers.NewHealthRequestHandlerFunc()).
-			Methods("GET")
-	}
-	if metrics {
-		s.router.Path("/metrics").
-			Handler(handlers.NewMetricsRequestHandler()).
-			Methods("GET")
-	}
 }
 
 func newGraphQLServer(schema graphql.ExecutableSchema) http.Handler {
@@ -57,6 +95,8 @@ func newGraphQLServer(schema graphql.ExecutableSchema) http.Handler {
 	srv.AddTransport(transport.POST{})
 	srv.AddTransport(transport.MultipartForm{})
 
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.AroundOperations(graph.SubscriptionErrors)
 	srv.SetQueryCache(lru.New(1000))
 
 	srv.Use(extension.Introspection{})
@@ -1,8 +1,11 @@
 package server
 
 import (
+	"net"
 	"net/http"
 	"okp4/cosmos-faucet/graph"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/identity"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
@@ -14,20 +17,110 @@ type HTTPServer interface {
 }
 
 type httpServer struct {
-	router *mux.Router
+	router       *mux.Router
+	adminRouter  *mux.Router
+	health       bool
+	metrics      bool
+	adminToken   string
+	adminAddress string
+	keyring      *apikey.Keyring
+	proxies      []*net.IPNet
+	limiter      *rateLimiter
+	oauth        *oauthHandler
+}
+
+// Option configures the httpServer.
+type Option func(server *httpServer)
+
+// WithHealth enables the health endpoint.
+func WithHealth(enable bool) Option {
+	return func(server *httpServer) {
+		server.health = enable
+	}
+}
+
+// WithMetrics enables the metrics endpoint.
+func WithMetrics(enable bool) Option {
+	return func(server *httpServer) {
+		server.metrics = enable
+	}
+}
+
+// WithAdminToken sets the bearer token granting access to the admin GraphQL fields, which are unreachable if empty.
+func WithAdminToken(token string) Option {
+	return func(server *httpServer) {
+		server.adminToken = token
+	}
+}
+
+// WithAdminAddress sets the address of a separate listener serving the GraphQL api with the admin fields reachable
+// without token, meant to be exposed on a private network only. No such listener is started if empty.
+func WithAdminAddress(address string) Option {
+	return func(server *httpServer) {
+		server.adminAddress = address
+	}
+}
+
+// WithKeyring enables the authentication of the machine clients through the API keys of the given keyring.
+func WithKeyring(keyring *apikey.Keyring) Option {
+	return func(server *httpServer) {
+		server.keyring = keyring
+	}
+}
+
+// WithTrustedProxies sets the networks of the reverse proxies trusted to give the client IP address in the
+// X-Forwarded-For header.
+func WithTrustedProxies(proxies []*net.IPNet) Option {
+	return func(server *httpServer) {
+		server.proxies = proxies
+	}
+}
+
+// WithRateLimit limits the number of requests per minute from a same IP address and from a same /24 IPv4 or /64 IPv6
+// subnet, each limit being disabled if 0.
+func WithRateLimit(perIP, perSubnet int) Option {
+	return func(server *httpServer) {
+		if perIP > 0 || perSubnet > 0 {
+			server.limiter = newRateLimiter(perIP, perSubnet)
+		}
+	}
+}
+
+// WithIdentityProvider enables the login of the requesters at the given identity provider, through the `/auth/login`
+// and `/auth/callback` endpoints, the requesters being redirected to the return URL once logged in.
+func WithIdentityProvider(provider identity.Provider, sessions *identity.Sessions, returnURL string) Option {
+	return func(server *httpServer) {
+		if provider == nil {
+			return
+		}
+		if returnURL == "" {
+			returnURL = "/"
+		}
+		server.oauth = &oauthHandler{provider: provider, sessions: sessions, returnURL: returnURL}
+	}
 }
 
 // NewServer creates a new httpServer containing router.
-func NewServer(graphqlResolver *graph.Resolver, health, metrics bool) HTTPServer {
+func NewServer(graphqlResolver *graph.Resolver, opts ...Option) HTTPServer {
 	server := &httpServer{
 		router: mux.NewRouter().StrictSlash(true),
 	}
-	server.createRoutes(graphqlResolver, health, metrics)
+	for _, opt := range opts {
+		opt(server)
+	}
+	server.createRoutes(graphqlResolver)
 	return server
 }
 
 // Start starts the http server on specified address.
 func (s httpServer) Start(address string) {
+	if s.adminRouter != nil {
+		go func() {
+			log.Info().Msgf("\U0001F6E1  Admin server listening at %s", s.adminAddress)
+			log.Fatal().Err(http.ListenAndServe(s.adminAddress, s.adminRouter)).Msg("Admin server listening stopped")
+		}()
+	}
+
 	log.Info().Msgf("\U0001F9BB Server listening at %s", address)
 	log.Fatal().Err(http.ListenAndServe(address, s.router)).Msg("Server listening stopped")
 }
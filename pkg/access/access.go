@@ -0,0 +1,278 @@
+package access
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrDenied is returned when an address matches an entry of the denylist.
+var ErrDenied = errors.New("address is denylisted")
+
+// ErrNotAllowed is returned when an allowlist is configured and the address doesn't match any of its entries.
+var ErrNotAllowed = errors.New("address is not allowlisted")
+
+// ErrInvalidEntry is returned when adding an empty entry to the lists.
+var ErrInvalidEntry = errors.New("access list entry must not be empty")
+
+// wildcard is the suffix denoting a prefix pattern entry.
+const wildcard = "*"
+
+// Lists contains the allow and deny entries as stored in the lists file.
+//
+// An entry is either an exact address or a prefix pattern ending with '*' (e.g. `okp41abc*`).
+type Lists struct {
+	Allow []string `yaml:"allow" json:"allow"`
+	Deny  []string `yaml:"deny" json:"deny"`
+}
+
+// List is a thread safe allow/deny list of addresses, optionally backed by a file.
+//
+// An empty allowlist allows any address not explicitly denied, a non empty allowlist only allows its entries.
+type List struct {
+	mu    sync.RWMutex
+	path  string
+	lists Lists
+}
+
+// NewList returns a List initialized with the given entries, not backed by any file.
+func NewList(lists Lists) *List {
+	return &List{lists: lists}
+}
+
+// Load returns a List backed by the given file. A missing file is considered as empty lists and will be created on the
+// first edition.
+func Load(path string) (*List, error) {
+	list := &List{path: filepath.Clean(path)}
+	if err := list.Reload(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Reload reads again the lists from the backing file, if any.
+func (l *List) Reload() error {
+	if l.path == "" {
+		return nil
+	}
+
+	var lists Lists
+	bz, err := os.ReadFile(l.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := yaml.Unmarshal(bz, &lists); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lists = lists
+
+	return nil
+}
+
+// Watch reloads the lists each time the backing file changes, until the returned stop function is called.
+func (l *List) Watch() (func(), error) {
+	if l.path == "" {
+		return func() {}, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watching the directory allows to catch files replaced by editors through renaming.
+	if err := watcher.Add(filepath.Dir(l.path)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != l.path {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				if err := l.Reload(); err != nil {
+					log.Warn().Err(err).Str("path", l.path).Msg("😥 Could not reload access lists")
+					continue
+				}
+				log.Info().Str("path", l.path).Msg("🔄 Access lists reloaded")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn().Err(err).Str("path", l.path).Msg("😥 Error while watching access lists")
+			}
+		}
+	}()
+
+	return func() { _ = watcher.Close() }, nil
+}
+
+// Check returns an error if the given address is not allowed to receive funds.
+func (l *List) Check(address string) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if matchAny(l.lists.Deny, address) {
+		return ErrDenied
+	}
+	if len(l.lists.Allow) > 0 && !matchAny(l.lists.Allow, address) {
+		return ErrNotAllowed
+	}
+
+	return nil
+}
+
+// Lists returns a copy of the current entries.
+func (l *List) Lists() Lists {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return Lists{
+		Allow: append([]string{}, l.lists.Allow...),
+		Deny:  append([]string{}, l.lists.Deny...),
+	}
+}
+
+// Allow adds an entry to the allowlist, persisting the change in the backing file if any.
+func (l *List) Allow(entry string) error {
+	if strings.TrimSpace(entry) == "" {
+		return ErrInvalidEntry
+	}
+
+	return l.edit(func(lists *Lists) {
+		lists.Allow = add(lists.Allow, entry)
+	})
+}
+
+// Disallow removes an entry from the allowlist, persisting the change in the backing file if any.
+func (l *List) Disallow(entry string) error {
+	return l.edit(func(lists *Lists) {
+		lists.Allow = remove(lists.Allow, entry)
+	})
+}
+
+// Deny adds an entry to the denylist, persisting the change in the backing file if any.
+func (l *List) Deny(entry string) error {
+	if strings.TrimSpace(entry) == "" {
+		return ErrInvalidEntry
+	}
+
+	return l.edit(func(lists *Lists) {
+		lists.Deny = add(lists.Deny, entry)
+	})
+}
+
+// Undeny removes an entry from the denylist, persisting the change in the backing file if any.
+func (l *List) Undeny(entry string) error {
+	return l.edit(func(lists *Lists) {
+		lists.Deny = remove(lists.Deny, entry)
+	})
+}
+
+func (l *List) edit(fn func(lists *Lists)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lists := Lists{
+		Allow: append([]string{}, l.lists.Allow...),
+		Deny:  append([]string{}, l.lists.Deny...),
+	}
+	fn(&lists)
+
+	if l.path != "" {
+		bz, err := yaml.Marshal(&lists)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(l.path, bz); err != nil {
+			return err
+		}
+	}
+	l.lists = lists
+
+	return nil
+}
+
+// writeFile atomically replaces the content of the given file, by writing a temporary file in the same directory then
+// renaming it, so the watchers never read a partially written file.
+func writeFile(path string, bz []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(bz); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func matchAny(entries []string, address string) bool {
+	for _, entry := range entries {
+		if match(entry, address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func match(entry, address string) bool {
+	if prefix := strings.TrimSuffix(entry, wildcard); prefix != entry {
+		return strings.HasPrefix(address, prefix)
+	}
+
+	return entry == address
+}
+
+func add(entries []string, entry string) []string {
+	for _, e := range entries {
+		if e == entry {
+			return entries
+		}
+	}
+
+	return append(entries, entry)
+}
+
+func remove(entries []string, entry string) []string {
+	filtered := entries[:0]
+	for _, e := range entries {
+		if e != entry {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,118 @@
+package access
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	addr1 = "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27"
+	addr2 = "okp41pmkq300lrngpkeprygfrtag0xpgp9z92c7eskm"
+)
+
+func TestCheck(t *testing.T) {
+	Convey("Given empty lists", t, func() {
+		list := NewList(Lists{})
+
+		Convey("Then any address should be allowed", func() {
+			So(list.Check(addr1), ShouldBeNil)
+			So(list.Check(addr2), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a denylist with an exact address", t, func() {
+		list := NewList(Lists{Deny: []string{addr1}})
+
+		Convey("Then only this address should be denied", func() {
+			So(list.Check(addr1), ShouldEqual, ErrDenied)
+			So(list.Check(addr2), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an allowlist with a prefix pattern", t, func() {
+		list := NewList(Lists{Allow: []string{"okp41rhd*"}})
+
+		Convey("Then only matching addresses should be allowed", func() {
+			So(list.Check(addr1), ShouldBeNil)
+			So(list.Check(addr2), ShouldEqual, ErrNotAllowed)
+		})
+
+		Convey("And a denylist matching an allowed address", func() {
+			So(list.Deny(addr1), ShouldBeNil)
+
+			Convey("Then the denylist should prevail", func() {
+				So(list.Check(addr1), ShouldEqual, ErrDenied)
+			})
+		})
+	})
+}
+
+func TestEdit(t *testing.T) {
+	Convey("Given lists backed by a non existing file", t, func() {
+		path := filepath.Join(t.TempDir(), "access.yml")
+		list, err := Load(path)
+		So(err, ShouldBeNil)
+		So(list.Lists(), ShouldResemble, Lists{Allow: []string{}, Deny: []string{}})
+
+		Convey("When editing the lists", func() {
+			So(list.Allow("okp41*"), ShouldBeNil)
+			So(list.Allow("okp41*"), ShouldBeNil)
+			So(list.Deny(addr1), ShouldBeNil)
+			So(list.Deny(addr2), ShouldBeNil)
+			So(list.Undeny(addr1), ShouldBeNil)
+
+			Convey("Then the lists should be updated", func() {
+				So(list.Lists(), ShouldResemble, Lists{Allow: []string{"okp41*"}, Deny: []string{addr2}})
+			})
+
+			Convey("And the changes should be persisted", func() {
+				reloaded, err := Load(path)
+				So(err, ShouldBeNil)
+				So(reloaded.Lists(), ShouldResemble, list.Lists())
+			})
+
+			Convey("And no temporary file should be left behind", func() {
+				entries, err := os.ReadDir(filepath.Dir(path))
+				So(err, ShouldBeNil)
+				So(entries, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When adding empty entries", func() {
+			Convey("Then they should be refused", func() {
+				So(errors.Is(list.Allow(""), ErrInvalidEntry), ShouldBeTrue)
+				So(errors.Is(list.Deny("  "), ErrInvalidEntry), ShouldBeTrue)
+				So(list.Lists(), ShouldResemble, Lists{Allow: []string{}, Deny: []string{}})
+			})
+		})
+	})
+}
+
+func TestWatch(t *testing.T) {
+	Convey("Given watched lists backed by a file", t, func() {
+		path := filepath.Join(t.TempDir(), "access.yml")
+		So(os.WriteFile(path, []byte("deny: []\n"), 0o600), ShouldBeNil)
+		list, err := Load(path)
+		So(err, ShouldBeNil)
+		stop, err := list.Watch()
+		So(err, ShouldBeNil)
+		defer stop()
+
+		Convey("When the file is changed", func() {
+			So(os.WriteFile(path, []byte("deny:\n  - "+addr1+"\n"), 0o600), ShouldBeNil)
+
+			Convey("Then the lists should be reloaded", func() {
+				deadline := time.Now().Add(2 * time.Second)
+				for list.Check(addr1) == nil && time.Now().Before(deadline) {
+					time.Sleep(10 * time.Millisecond)
+				}
+				So(list.Check(addr1), ShouldEqual, ErrDenied)
+			})
+		})
+	})
+}
@@ -0,0 +1,97 @@
+package event
+
+import (
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// RequestAccepted is published when a fund request is registered by the faucet.
+type RequestAccepted struct {
+	// RequestID is the identifier of the fund request.
+	RequestID string
+
+	// Address on which the funds are requested.
+	Address string
+
+	// Amount of tokens requested.
+	Amount types.Coins
+
+	// Time at which the request has been accepted.
+	Time time.Time
+}
+
+// BatchTriggered is published when the faucet triggers a transaction including the pending fund requests.
+type BatchTriggered struct {
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+
+	// Time at which the transaction has been triggered.
+	Time time.Time
+}
+
+// TxBroadcast is published when a transaction has been submitted to the blockchain.
+type TxBroadcast struct {
+	// TxHash is the hash of the submitted transaction.
+	TxHash string
+
+	// Code is the result code of the submission.
+	Code uint32
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+
+	// Time at which the transaction has been submitted.
+	Time time.Time
+}
+
+// TxConfirmed is published when a submitted transaction has been included in a block.
+type TxConfirmed struct {
+	// TxHash is the hash of the confirmed transaction.
+	TxHash string
+
+	// Code is the result code of the transaction execution.
+	Code uint32
+
+	// Height of the block including the transaction.
+	Height int64
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+
+	// Time at which the inclusion has been observed.
+	Time time.Time
+}
+
+// TxFailed is published when a transaction could not be made, submitted or confirmed.
+type TxFailed struct {
+	// Error describes the failure.
+	Error string
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+
+	// Time at which the failure has been observed.
+	Time time.Time
+}
+
+// FaucetPaused is published when the faucet gets paused.
+type FaucetPaused struct {
+	// Time at which the faucet has been paused.
+	Time time.Time
+}
+
+// FaucetResumed is published when a paused faucet gets resumed.
+type FaucetResumed struct {
+	// Time at which the faucet has been resumed.
+	Time time.Time
+}
+
+// BalancesRefreshed is published when the faucet balances have been retrieved from the node.
+type BalancesRefreshed struct {
+	// Balances of the faucet.
+	Balances types.Coins
+
+	// Time at which the balances have been retrieved.
+	Time time.Time
+}
@@ -1,6 +1,7 @@
 package message
 
 import (
+	"okp4/cosmos-faucet/pkg/ledger"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
@@ -10,9 +11,30 @@ import (
 
 // RequestFunds represents a message to request funds.
 type RequestFunds struct {
+	// ID uniquely identifies the request, generated by the faucet if empty.
+	ID string
+
 	// Address on which to send requested funds.
 	Address types.AccAddress
 
+	// Amount to send, the faucet amount being sent if empty.
+	Amount types.Coins
+
+	// RequesterIP is the IP address of the requester, if known.
+	RequesterIP string
+
+	// RequesterSubnet is the /24 IPv4 or /64 IPv6 subnet of the requester IP address, if known.
+	RequesterSubnet string
+
+	// Captcha describes the outcome of the captcha verification, empty if no verification took place.
+	Captcha string
+
+	// APIKey is the name of the API key the request has been made with, if any.
+	APIKey string
+
+	// Identity identifies the account of the requester at an identity provider, if logged in.
+	Identity string
+
 	// TxSubscriber denotes an actor on which to forward the response of the submitted transaction containing the
 	// associated send message (i.e. BroadcastTxResponse).
 	TxSubscriber *actor.PID
@@ -45,6 +67,9 @@ type MakeTx struct {
 	// Msgs contains the messages to embed in the transaction.
 	Msgs []types.Msg
 
+	// RequestIDs contains the identifiers of the fund requests the messages originate from.
+	RequestIDs []string
+
 	// Memo is the 'memo' field content of the transaction.
 	Memo string
 
@@ -82,4 +107,142 @@ type BroadcastTx struct {
 type BroadcastTxResponse struct {
 	// TxResponse is the submitted transaction response.
 	TxResponse *types.TxResponse
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction, if any.
+	RequestIDs []string
+}
+
+// GetTx represents a message to retrieve a transaction by its hash.
+type GetTx struct {
+	// Deadline the deadline before which the transaction shall be retrieved.
+	Deadline time.Time
+
+	// Hash of the transaction to retrieve.
+	Hash string
+}
+
+// GetTxResponse represents a message emitted in response to GetTx.
+type GetTxResponse struct {
+	// TxResponse is the retrieved transaction, nil if not found (e.g. not yet included in a block).
+	TxResponse *types.TxResponse
+}
+
+// CheckTx represents a message to check whether a submitted transaction has been included in a block.
+type CheckTx struct {
+	// Hash of the submitted transaction.
+	Hash string
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+
+	// Attempt is the number of checks already made.
+	Attempt int
+}
+
+// TxConfirmed represents a message notifying that a submitted transaction has been included in a block.
+type TxConfirmed struct {
+	// TxResponse is the transaction response as included in the block.
+	TxResponse *types.TxResponse
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+}
+
+// TxFailed represents a message notifying that a transaction could not be made, submitted or confirmed.
+type TxFailed struct {
+	// Error describes the failure.
+	Error error
+
+	// RequestIDs contains the identifiers of the fund requests included in the transaction.
+	RequestIDs []string
+}
+
+// GetRequest represents a message to retrieve the state of a fund request.
+type GetRequest struct {
+	// ID of the fund request.
+	ID string
+}
+
+// GetRequestResponse represents a message emitted in response to GetRequest.
+type GetRequestResponse struct {
+	// Record is the state of the fund request, nil if unknown.
+	Record *ledger.Record
+}
+
+// GetBalances represents a message to retrieve the balances of an address.
+type GetBalances struct {
+	// Deadline the deadline before which the balances shall be retrieved.
+	Deadline time.Time
+
+	// Address on which to retrieve the balances.
+	Address string
+}
+
+// GetBalancesResponse represents a message emitted in response to GetBalances.
+type GetBalancesResponse struct {
+	// Balances of the address.
+	Balances types.Coins
+}
+
+// Pause represents a message to pause the faucet, no transaction being triggered until resumed. The faucet responds
+// with its status (i.e. GetStatusResponse).
+type Pause struct{}
+
+// Resume represents a message to resume a paused faucet. The faucet responds with its status (i.e. GetStatusResponse).
+type Resume struct{}
+
+// SetAmount represents a message to change the amount sent for the next fund requests. The faucet responds with its
+// status (i.e. GetStatusResponse).
+type SetAmount struct {
+	// Amount to send.
+	Amount types.Coins
+}
+
+// Flush represents a message to trigger a transaction including the pending fund requests without waiting for the end
+// of the batch window. The faucet responds with its status (i.e. GetStatusResponse).
+type Flush struct{}
+
+// RemoveRequest represents a message to remove a fund request waiting to be included in a transaction.
+type RemoveRequest struct {
+	// ID of the fund request.
+	ID string
+}
+
+// RemoveRequestResponse represents a message emitted in response to RemoveRequest.
+type RemoveRequestResponse struct {
+	// Removed tells whether the fund request was pending and has been removed.
+	Removed bool
+}
+
+// GetStatus represents a message to retrieve the current status of the faucet.
+type GetStatus struct{}
+
+// GetStatusResponse represents a message emitted in response to GetStatus.
+type GetStatusResponse struct {
+	// Paused tells whether the faucet is paused.
+	Paused bool
+
+	// QueueLength is the number of fund requests waiting to be included in a transaction.
+	QueueLength int
+
+	// NextTrigger is the time at which the next transaction is scheduled, zero if not scheduled.
+	NextTrigger time.Time
+
+	// LastTxHash is the hash of the last submitted transaction, empty if none.
+	LastTxHash string
+
+	// LastTxHeight is the height of the last confirmed transaction, 0 if none.
+	LastTxHeight int64
+
+	// NodeReachable tells whether the last check of the node succeeded.
+	NodeReachable bool
+
+	// NodeCheckedAt is the time of the last check of the node, zero if never checked.
+	NodeCheckedAt time.Time
+
+	// Balances of the faucet as of the last check of the node.
+	Balances types.Coins
+
+	// Amount sent for each fund request.
+	Amount types.Coins
 }
@@ -3,6 +3,7 @@ package system
 import (
 	"okp4/cosmos-faucet/pkg/cosmos"
 	"okp4/cosmos-faucet/pkg/faucet"
+	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
 	crypto "github.com/cosmos/cosmos-sdk/crypto/types"
@@ -12,12 +13,22 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
+const (
+	// confirmInterval is the delay between two checks of the inclusion of a submitted transaction in a block.
+	confirmInterval = 2 * time.Second
+	// confirmAttempts is the number of checks after which a submitted transaction not found in a block is failed.
+	confirmAttempts = 30
+	// refreshInterval is the delay between two checks of the node connectivity and the faucet balances.
+	refreshInterval = 30 * time.Second
+)
+
 func BootstrapActors(
 	chainID string,
 	privKey crypto.PrivKey,
 	sendAmount types.Coins,
 	grpcAddress string,
 	tls credentials.TransportCredentials,
+	opts ...faucet.Option,
 ) (*actor.RootContext, *actor.PID) {
 	cosmosClientProps := actor.PropsFromProducer(func() actor.Actor {
 		grpcClient, err := cosmos.NewGrpcClient(grpcAddress, tls)
@@ -28,21 +39,28 @@ func BootstrapActors(
 		return grpcClient
 	})
 
+	actorSystem := actor.NewActorSystem()
 	txHandlerProps := actor.PropsFromProducer(func() actor.Actor {
 		return cosmos.NewTxHandler(
 			cosmos.WithChainID(chainID),
 			cosmos.WithPrivateKey(privKey),
 			cosmos.WithTxConfig(simapp.MakeTestEncodingConfig().TxConfig),
 			cosmos.WithCosmosClientProps(cosmosClientProps),
+			cosmos.WithConfirmation(confirmInterval, confirmAttempts),
+			cosmos.WithEventStream(actorSystem.EventStream),
 		)
 	})
 
-	actorCTX := actor.NewActorSystem().Root
+	actorCTX := actorSystem.Root
 	return actorCTX, actorCTX.Spawn(actor.PropsFromProducer(func() actor.Actor {
 		return faucet.NewFaucet(
-			faucet.WithAmount(sendAmount),
-			faucet.WithAddress(types.AccAddress(privKey.PubKey().Address())),
-			faucet.WithTxHandlerProps(txHandlerProps),
+			append([]faucet.Option{
+				faucet.WithAmount(sendAmount),
+				faucet.WithAddress(types.AccAddress(privKey.PubKey().Address())),
+				faucet.WithTxHandlerProps(txHandlerProps),
+				faucet.WithCosmosClientProps(cosmosClientProps, refreshInterval),
+				faucet.WithEventStream(actorSystem.EventStream),
+			}, opts...)...,
 		)
 	}))
 }
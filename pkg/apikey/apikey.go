@@ -0,0 +1,219 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrInvalidKey is returned when an API key doesn't match any of the configured ones.
+var ErrInvalidKey = errors.New("invalid api key")
+
+// ErrAmountNotAllowed is returned when the requested amount is not allowed for the API key.
+var ErrAmountNotAllowed = errors.New("amount not allowed for api key")
+
+// ErrQuotaExceeded is returned when the API key has made all the fund requests allowed by its quota.
+var ErrQuotaExceeded = errors.New("api key quota exceeded")
+
+// secretSize is the number of random bytes of a generated secret.
+const secretSize = 32
+
+// Quota limits the number of fund requests made with an API key over a sliding period.
+type Quota struct {
+	// Requests is the maximum number of fund requests over the period, unlimited if 0.
+	Requests int `yaml:"requests"`
+	// Period is the sliding period over which the fund requests are counted.
+	Period time.Duration `yaml:"period"`
+}
+
+// Key represents an API key granted to a machine client, as stored in the keys file.
+type Key struct {
+	// Name identifies the client owning the key, recorded along with its fund requests.
+	Name string `yaml:"name"`
+	// SecretHash is the hex encoded SHA-256 hash of the key secret, the secret itself never being stored.
+	SecretHash string `yaml:"secretHash"`
+	// Quota limits the number of fund requests made with the key.
+	Quota Quota `yaml:"quota"`
+	// Assets associates the denoms the key can request to the maximum amount of a single request. Only the default
+	// amount can be requested if empty.
+	Assets map[string]int64 `yaml:"assets"`
+}
+
+// Allows returns ErrAmountNotAllowed if the given amount cannot be requested with the key, an empty amount standing for
+// the default one which any key can request.
+func (k *Key) Allows(amount types.Coins) error {
+	for _, coin := range amount {
+		max, ok := k.Assets[coin.Denom]
+		if !ok {
+			return fmt.Errorf("%w: denom %s", ErrAmountNotAllowed, coin.Denom)
+		}
+		if coin.Amount.GT(types.NewInt(max)) {
+			return fmt.Errorf("%w: at most %d%s", ErrAmountNotAllowed, max, coin.Denom)
+		}
+	}
+
+	return nil
+}
+
+// QuotaError is returned when the API key quota is exceeded, unwrapping to ErrQuotaExceeded.
+type QuotaError struct {
+	// RetryAfter is the duration after which a new fund request fits in the quota.
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrQuotaExceeded, e.RetryAfter.Round(time.Second))
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Keys contains the API keys as stored in the keys file.
+type Keys struct {
+	Keys []Key `yaml:"keys"`
+}
+
+// Keyring authenticates the API keys and enforces their quotas against the distribution ledger.
+type Keyring struct {
+	keys  map[string]*Key
+	store ledger.Store
+	now   func() time.Time
+}
+
+// NewKeyring returns a Keyring holding the given keys, their fund requests being counted from the given store.
+func NewKeyring(keys []Key, store ledger.Store) (*Keyring, error) {
+	keyring := &Keyring{
+		keys:  make(map[string]*Key, len(keys)),
+		store: store,
+		now:   time.Now,
+	}
+
+	names := make(map[string]bool, len(keys))
+	for i := range keys {
+		key := keys[i]
+		if key.Name == "" || names[key.Name] {
+			return nil, fmt.Errorf("api key name must be unique and not empty: %q", key.Name)
+		}
+		if bz, err := hex.DecodeString(key.SecretHash); err != nil || len(bz) != sha256.Size {
+			return nil, fmt.Errorf("api key %s: secret hash must be a hex encoded SHA-256 hash", key.Name)
+		}
+		if other, ok := keyring.keys[key.SecretHash]; ok {
+			return nil, fmt.Errorf("api key %s: secret hash already used by api key %s", key.Name, other.Name)
+		}
+		if key.Quota.Requests > 0 && key.Quota.Period <= 0 {
+			return nil, fmt.Errorf("api key %s: quota period must be positive when limiting the requests", key.Name)
+		}
+		names[key.Name] = true
+		keyring.keys[key.SecretHash] = &key
+	}
+
+	return keyring, nil
+}
+
+// Load returns a Keyring holding the keys stored in the given yaml file.
+func Load(path string, store ledger.Store) (*Keyring, error) {
+	keys, err := LoadKeys(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeyring(keys, store)
+}
+
+// LoadKeys returns the keys stored in the given yaml file.
+func LoadKeys(path string) ([]Key, error) {
+	bz, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys Keys
+	if err := yaml.Unmarshal(bz, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys.Keys, nil
+}
+
+// Hash returns the hash of the given secret, as stored in the keys file.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate returns a new random secret, to be given to a client while its hash is stored in the keys file.
+func Generate() (string, error) {
+	bz := make([]byte, secretSize)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bz), nil
+}
+
+// Authenticate returns the key having the given secret, or ErrInvalidKey.
+func (k *Keyring) Authenticate(secret string) (*Key, error) {
+	key, ok := k.keys[Hash(secret)]
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+// CheckQuota returns a *QuotaError if the given key has made all the fund requests allowed by its quota, the failed
+// requests not being counted.
+func (k *Keyring) CheckQuota(key *Key) error {
+	if k.store == nil || key.Quota.Requests <= 0 {
+		return nil
+	}
+
+	now := k.now()
+	since := now.Add(-key.Quota.Period)
+	count := 0
+	var quotaErr error
+	err := ledger.Walk(k.store, ledger.Query{}, func(record ledger.Record) bool {
+		if !record.CreatedAt.After(since) {
+			return false
+		}
+		if record.APIKey != key.Name || record.Status == ledger.StatusFailed {
+			return true
+		}
+
+		count++
+		if count >= key.Quota.Requests {
+			quotaErr = &QuotaError{RetryAfter: record.CreatedAt.Add(key.Quota.Period).Sub(now)}
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return quotaErr
+}
+
+type contextKey struct{}
+
+// WithKey returns a copy of the context holding the API key the request has been authenticated with.
+func WithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the API key held by the context, or nil if the request is not authenticated with an API key.
+func FromContext(ctx context.Context) *Key {
+	key, _ := ctx.Value(contextKey{}).(*Key)
+	return key
+}
@@ -0,0 +1,177 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var now = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestLoad(t *testing.T) {
+	Convey("Given a keys file", t, func() {
+		path := filepath.Join(t.TempDir(), "keys.yml")
+		So(os.WriteFile(path, []byte(fmt.Sprintf(`
+keys:
+  - name: ci
+    secretHash: %s
+    quota:
+      requests: 10
+      period: 1h
+    assets:
+      uknow: 5000000
+`, Hash("secret"))), 0o600), ShouldBeNil)
+
+		Convey("When loading it", func() {
+			keyring, err := Load(path, nil)
+			So(err, ShouldBeNil)
+
+			Convey("Then the key should be authenticated by its secret", func() {
+				key, err := keyring.Authenticate("secret")
+				So(err, ShouldBeNil)
+				So(key.Name, ShouldEqual, "ci")
+				So(key.Quota, ShouldResemble, Quota{Requests: 10, Period: time.Hour})
+				So(key.Assets, ShouldResemble, map[string]int64{"uknow": 5000000})
+			})
+
+			Convey("Then an unknown secret should be rejected", func() {
+				key, err := keyring.Authenticate("other")
+				So(key, ShouldBeNil)
+				So(err, ShouldEqual, ErrInvalidKey)
+			})
+		})
+	})
+
+	Convey("Given invalid keys", t, func() {
+		cases := map[string][]Key{
+			"missing name":    {{SecretHash: Hash("secret")}},
+			"duplicated name": {{Name: "ci", SecretHash: Hash("a")}, {Name: "ci", SecretHash: Hash("b")}},
+			"clear secret":    {{Name: "ci", SecretHash: "secret"}},
+			"shared secret":   {{Name: "ci", SecretHash: Hash("secret")}, {Name: "cd", SecretHash: Hash("secret")}},
+			"quota period":    {{Name: "cd", SecretHash: Hash("secret"), Quota: Quota{Requests: 10}}},
+		}
+
+		for name, keys := range cases {
+			Convey("Then a keyring with "+name+" should not be created", func() {
+				_, err := NewKeyring(keys, nil)
+				So(err, ShouldNotBeNil)
+			})
+		}
+
+		Convey("Then the error should name the invalid key", func() {
+			for _, name := range []string{"shared secret", "quota period"} {
+				_, err := NewKeyring(cases[name], nil)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldStartWith, "api key cd:")
+			}
+		})
+	})
+}
+
+func TestAllows(t *testing.T) {
+	Convey("Given a key allowed to request an asset", t, func() {
+		key := &Key{Name: "ci", Assets: map[string]int64{"uknow": 100}}
+
+		Convey("Then amounts up to the maximum should be allowed", func() {
+			So(key.Allows(types.NewCoins(types.NewInt64Coin("uknow", 100))), ShouldBeNil)
+		})
+
+		Convey("Then greater amounts should not be allowed", func() {
+			err := key.Allows(types.NewCoins(types.NewInt64Coin("uknow", 101)))
+			So(errors.Is(err, ErrAmountNotAllowed), ShouldBeTrue)
+		})
+
+		Convey("Then other assets should not be allowed", func() {
+			err := key.Allows(types.NewCoins(types.NewInt64Coin("uother", 1)))
+			So(errors.Is(err, ErrAmountNotAllowed), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a key without assets", t, func() {
+		key := &Key{Name: "ci"}
+
+		Convey("Then the default amount should be allowed", func() {
+			So(key.Allows(nil), ShouldBeNil)
+		})
+
+		Convey("Then a chosen amount should not be allowed", func() {
+			err := key.Allows(types.NewCoins(types.NewInt64Coin("uknow", 1)))
+			So(errors.Is(err, ErrAmountNotAllowed), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCheckQuota(t *testing.T) {
+	Convey("Given a keyring with a key limited to 2 requests per hour", t, func() {
+		store := ledger.NewMemoryStore()
+		key := Key{Name: "ci", SecretHash: Hash("secret"), Quota: Quota{Requests: 2, Period: time.Hour}}
+		keyring, err := NewKeyring([]Key{key}, store)
+		So(err, ShouldBeNil)
+		keyring.now = func() time.Time { return now }
+
+		put := func(id, apiKey string, age time.Duration, status ledger.Status) {
+			So(store.Put(ledger.Record{ID: id, APIKey: apiKey, Status: status, CreatedAt: now.Add(-age)}), ShouldBeNil)
+		}
+
+		Convey("When the key made a single request, besides old, failed and other requests", func() {
+			put("old", "ci", 2*time.Hour, ledger.StatusConfirmed)
+			put("failed", "ci", 20*time.Minute, ledger.StatusFailed)
+			put("other", "cd", 15*time.Minute, ledger.StatusConfirmed)
+			put("recent", "ci", 10*time.Minute, ledger.StatusConfirmed)
+
+			Convey("Then the quota should not be exceeded", func() {
+				So(keyring.CheckQuota(&key), ShouldBeNil)
+			})
+		})
+
+		Convey("When the key made two requests", func() {
+			put("first", "ci", 40*time.Minute, ledger.StatusConfirmed)
+			put("second", "ci", 10*time.Minute, ledger.StatusQueued)
+
+			Convey("Then the quota should be exceeded until the oldest one leaves the period", func() {
+				err := keyring.CheckQuota(&key)
+				So(errors.Is(err, ErrQuotaExceeded), ShouldBeTrue)
+
+				var quotaErr *QuotaError
+				So(errors.As(err, &quotaErr), ShouldBeTrue)
+				So(quotaErr.RetryAfter, ShouldEqual, 20*time.Minute)
+			})
+		})
+	})
+}
+
+func TestContext(t *testing.T) {
+	Convey("Given a context holding a key", t, func() {
+		key := &Key{Name: "ci"}
+		ctx := WithKey(context.Background(), key)
+
+		Convey("Then the key should be retrieved", func() {
+			So(FromContext(ctx), ShouldEqual, key)
+			So(FromContext(context.Background()), ShouldBeNil)
+		})
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	Convey("When generating a secret", t, func() {
+		secret, err := Generate()
+		So(err, ShouldBeNil)
+
+		Convey("Then it should authenticate a key stored with its hash", func() {
+			keyring, err := NewKeyring([]Key{{Name: "ci", SecretHash: Hash(secret)}}, nil)
+			So(err, ShouldBeNil)
+
+			key, err := keyring.Authenticate(secret)
+			So(err, ShouldBeNil)
+			So(key.Name, ShouldEqual, "ci")
+		})
+	})
+}
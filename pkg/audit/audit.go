@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Entry is the record of an administrative action.
+type Entry struct {
+	Time     time.Time              `json:"time"`
+	Action   string                 `json:"action"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	RemoteIP string                 `json:"remoteIP,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// Log is a thread safe append only log of administrative actions, written as JSON lines. A nil Log only reports the
+// actions through the application logs.
+type Log struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLog returns a Log writing its entries to the given writer.
+func NewLog(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Open returns a Log appending its entries to the given file, created if missing.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLog(f), nil
+}
+
+// Record appends the given entry to the log, its time being set to now if missing.
+func (l *Log) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	log.Info().
+		Str("action", entry.Action).
+		Interface("params", entry.Params).
+		Str("remoteIP", entry.RemoteIP).
+		Str("error", entry.Error).
+		Msg("🛡️  Administrative action")
+
+	if l == nil {
+		return
+	}
+
+	bz, err := json.Marshal(entry)
+	if err != nil {
+		log.Err(err).Str("action", entry.Action).Msg("❌ Could not encode audit entry")
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(append(bz, '\n')); err != nil {
+		log.Err(err).Str("action", entry.Action).Msg("❌ Could not write audit entry")
+	}
+}
+
+// Close closes the underlying writer, if closable.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	if closer, ok := l.w.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
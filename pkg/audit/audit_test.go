@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecord(t *testing.T) {
+	Convey("Given an audit log", t, func() {
+		var buf bytes.Buffer
+		auditLog := NewLog(&buf)
+
+		Convey("When recording several entries", func() {
+			auditLog.Record(Entry{Action: "pause", RemoteIP: "127.0.0.1"})
+			auditLog.Record(Entry{
+				Time:   time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+				Action: "setAmount",
+				Params: map[string]interface{}{"amount": 42},
+				Error:  "boom",
+			})
+
+			Convey("Then they should be written as JSON lines", func() {
+				var entries []Entry
+				scanner := bufio.NewScanner(&buf)
+				for scanner.Scan() {
+					var entry Entry
+					So(json.Unmarshal(scanner.Bytes(), &entry), ShouldBeNil)
+					entries = append(entries, entry)
+				}
+
+				So(len(entries), ShouldEqual, 2)
+				So(entries[0].Action, ShouldEqual, "pause")
+				So(entries[0].RemoteIP, ShouldEqual, "127.0.0.1")
+				So(entries[0].Time.IsZero(), ShouldBeFalse)
+				So(entries[1].Time, ShouldResemble, time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC))
+				So(entries[1].Params, ShouldResemble, map[string]interface{}{"amount": float64(42)})
+				So(entries[1].Error, ShouldEqual, "boom")
+			})
+		})
+	})
+
+	Convey("Given a nil audit log", t, func() {
+		var auditLog *Log
+
+		Convey("When recording an entry", func() {
+			Convey("Then it should not panic", func() {
+				So(func() { auditLog.Record(Entry{Action: "pause"}) }, ShouldNotPanic)
+				So(auditLog.Close(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given an audit log file", t, func() {
+		path := filepath.Join(t.TempDir(), "audit.log")
+
+		Convey("When recording entries across openings", func() {
+			for _, action := range []string{"pause", "resume"} {
+				auditLog, err := Open(path)
+				So(err, ShouldBeNil)
+				auditLog.Record(Entry{Action: action})
+				So(auditLog.Close(), ShouldBeNil)
+			}
+
+			Convey("Then the entries should be appended", func() {
+				auditLog, err := Open(path)
+				So(err, ShouldBeNil)
+				So(auditLog.Close(), ShouldBeNil)
+
+				bz, err := os.ReadFile(path)
+				So(err, ShouldBeNil)
+				So(bytes.Count(bz, []byte("\n")), ShouldEqual, 2)
+			})
+		})
+	})
+}
@@ -2,29 +2,95 @@ package captcha
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// ErrMissingToken is returned when the captcha verification is enabled and no token is given.
+var ErrMissingToken = errors.New("no captcha token specified")
+
+// ErrVerificationFailed is returned when the captcha token is rejected.
+var ErrVerificationFailed = errors.New("captcha verification failed")
+
+// Verified denotes the outcome of a successful captcha verification.
+const Verified = "verified"
+
+// Result is the outcome of a successful captcha verification.
+type Result struct {
+	// Score is the likelihood the interaction is legitimate between 0 and 1, nil if not provided by the provider.
+	Score *float64
+}
+
 type Resolver interface {
-	CheckRecaptcha(context.Context, *string) error
+	// CheckRecaptcha verifies the given token, returning a nil result when the verification is disabled.
+	CheckRecaptcha(context.Context, *string) (*Result, error)
+	// Enabled tells whether the captcha verification is enabled.
+	Enabled() bool
+	// MinScore returns the minimum score a captcha token must have to be accepted.
+	MinScore() float64
+	// SetMinScore changes the minimum score a captcha token must have to be accepted, returning ErrScoreNotSupported if
+	// the provider gives no score.
+	SetMinScore(float64) error
 }
 
 type ResolverConfig struct {
+	Provider  string  `mapstructure:"captcha-provider"`
 	Secret    string  `mapstructure:"captcha-secret"`
 	VerifyURL string  `mapstructure:"captcha-verify-url"`
 	MinScore  float64 `mapstructure:"captcha-min-score"`
 	Enable    bool    `mapstructure:"captcha"`
+	// Action is the action the tokens must have been issued for, not checked if empty.
+	Action string `mapstructure:"captcha-action"`
+	// Hostnames are the hostnames of the sites the captcha may be solved on, not checked if empty.
+	Hostnames []string `mapstructure:"captcha-hostnames"`
+	// MaxAge is the maximum duration between the resolution of the captcha and its verification, not checked if 0.
+	MaxAge time.Duration `mapstructure:"captcha-max-age"`
+	Pow    PowConfig     `mapstructure:",squash"`
 }
 
-func NewCaptchaResolver(config ResolverConfig) Resolver {
+// NewCaptchaResolver returns a Resolver verifying the tokens with the configured provider, through its default
+// siteverify endpoint unless another one is configured. The proof of work provider verifies the tokens itself.
+func NewCaptchaResolver(config ResolverConfig) (Resolver, error) {
+	if config.Provider == ProviderProofOfWork {
+		pow, err := newProofOfWork(config.Pow, config.Enable)
+		if err != nil {
+			return nil, err
+		}
+		return pow, nil
+	}
+
+	provider, err := NewProvider(config.Provider)
+	if err != nil {
+		return nil, err
+	}
+
 	if config.Enable && config.Secret == "" {
 		log.Error().Msg("Required Captcha secret not set")
 	}
-	return resolver{
+
+	verifyURL := config.VerifyURL
+	if verifyURL == "" {
+		verifyURL = provider.VerifyURL()
+	}
+
+	hostnames := make(map[string]struct{}, len(config.Hostnames))
+	for _, hostname := range config.Hostnames {
+		hostnames[strings.ToLower(hostname)] = struct{}{}
+	}
+
+	return &resolver{
+		provider:      provider,
 		secret:        config.Secret,
-		siteVerifyURL: config.VerifyURL,
+		siteVerifyURL: verifyURL,
 		minScore:      config.MinScore,
 		enable:        config.Enable,
-	}
+		action:        config.Action,
+		hostnames:     hostnames,
+		maxAge:        config.MaxAge,
+		used:          newReplays(replayRetention),
+		now:           time.Now,
+	}, nil
 }
@@ -0,0 +1,273 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newSiteVerifyServer returns a local stand-in of a siteverify endpoint, answering the given body to the requests
+// bearing the expected secret and recording the last verified token.
+func newSiteVerifyServer(body string, token *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		*token = r.FormValue("response")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+// check verifies the token with the resolver, only returning the error.
+func check(resolver Resolver, token *string) error {
+	_, err := resolver.CheckRecaptcha(context.Background(), token)
+	return err
+}
+
+func TestProviders(t *testing.T) {
+	cases := []struct {
+		provider string
+		body     string
+		minScore float64
+		err      error
+	}{
+		{ProviderRecaptchaV3, `{"success":true,"score":0.9,"action":"send","hostname":"localhost"}`, 0.5, nil},
+		{ProviderRecaptchaV3, `{"success":true,"score":0.3,"action":"send","hostname":"localhost"}`, 0.5, ErrVerificationFailed},
+		{ProviderRecaptchaV3, `{"success":true,"hostname":"localhost"}`, 0.5, ErrVerificationFailed},
+		{ProviderRecaptchaV3, `{"success":false,"error-codes":["invalid-input-response"]}`, 0, ErrVerificationFailed},
+		{ProviderRecaptchaV2, `{"success":true,"challenge_ts":"2022-09-01T12:00:00Z","hostname":"localhost"}`, 0.5, nil},
+		{ProviderRecaptchaV2, `{"success":false,"error-codes":["timeout-or-duplicate"]}`, 0.5, ErrVerificationFailed},
+		{ProviderHCaptcha, `{"success":true,"challenge_ts":"2022-09-01T12:00:00Z","hostname":"localhost","credit":false}`, 0.5, nil},
+		{ProviderHCaptcha, `{"success":true,"hostname":"localhost","score":0.2}`, 0.5, nil},
+		{ProviderHCaptcha, `{"success":true,"hostname":"localhost","score":0.8}`, 0.5, ErrVerificationFailed},
+		{ProviderHCaptcha, `{"success":false,"error-codes":["invalid-or-already-seen-response"]}`, 0.5, ErrVerificationFailed},
+		{ProviderTurnstile, `{"success":true,"challenge_ts":"2022-09-01T12:00:00.000Z","hostname":"localhost","action":"send"}`, 0.5, nil},
+		{ProviderTurnstile, `{"success":false,"error-codes":["invalid-input-response"],"messages":[]}`, 0.5, ErrVerificationFailed},
+	}
+
+	for _, c := range cases {
+		Convey("Given a "+c.provider+" resolver and a siteverify endpoint answering "+c.body, t, func() {
+			var verified string
+			server := newSiteVerifyServer(c.body, &verified)
+			defer server.Close()
+
+			resolver, err := NewCaptchaResolver(ResolverConfig{
+				Provider:  c.provider,
+				Secret:    "secret",
+				VerifyURL: server.URL,
+				MinScore:  c.minScore,
+				Enable:    true,
+			})
+			So(err, ShouldBeNil)
+
+			Convey("When checking a token", func() {
+				token := "token"
+				result, err := resolver.CheckRecaptcha(context.Background(), &token)
+
+				Convey("Then the token should be submitted and the outcome reported", func() {
+					So(verified, ShouldEqual, "token")
+					if c.err == nil {
+						So(err, ShouldBeNil)
+						So(result, ShouldNotBeNil)
+					} else {
+						So(errors.Is(err, c.err), ShouldBeTrue)
+					}
+				})
+			})
+		})
+	}
+}
+
+func TestStrictVerification(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		body string
+		err  error
+	}{
+		{"a valid token", `{"success":true,"score":0.9,"action":"send","hostname":"Faucet.okp4.network",` +
+			`"challenge_ts":"2022-09-01T11:59:30Z"}`, nil},
+		{"an unexpected action", `{"success":true,"score":0.9,"action":"login","hostname":"faucet.okp4.network",` +
+			`"challenge_ts":"2022-09-01T11:59:30Z"}`, ErrVerificationFailed},
+		{"an unknown hostname", `{"success":true,"score":0.9,"action":"send","hostname":"evil.com",` +
+			`"challenge_ts":"2022-09-01T11:59:30Z"}`, ErrVerificationFailed},
+		{"a token too old", `{"success":true,"score":0.9,"action":"send","hostname":"faucet.okp4.network",` +
+			`"challenge_ts":"2022-09-01T11:57:00Z"}`, ErrVerificationFailed},
+		{"no challenge timestamp", `{"success":true,"score":0.9,"action":"send","hostname":"faucet.okp4.network"}`,
+			ErrVerificationFailed},
+	}
+
+	for _, c := range cases {
+		Convey("Given a strict resolver and a siteverify endpoint answering "+c.name, t, func() {
+			var verified string
+			server := newSiteVerifyServer(c.body, &verified)
+			defer server.Close()
+
+			verifier, err := NewCaptchaResolver(ResolverConfig{
+				Secret:    "secret",
+				VerifyURL: server.URL,
+				MinScore:  0.5,
+				Enable:    true,
+				Action:    "send",
+				Hostnames: []string{"faucet.okp4.network", "localhost"},
+				MaxAge:    2 * time.Minute,
+			})
+			So(err, ShouldBeNil)
+			verifier.(*resolver).now = func() time.Time { return now }
+
+			Convey("When checking a token", func() {
+				token := "token"
+				err := check(verifier, &token)
+
+				Convey("Then the outcome should be reported", func() {
+					if c.err == nil {
+						So(err, ShouldBeNil)
+					} else {
+						So(errors.Is(err, c.err), ShouldBeTrue)
+					}
+				})
+			})
+		})
+	}
+
+	Convey("Given a resolver and a siteverify endpoint accepting any token", t, func() {
+		var verified string
+		server := newSiteVerifyServer(`{"success":true,"score":0.9,"challenge_ts":"2022-09-01T11:59:30Z"}`, &verified)
+		defer server.Close()
+
+		verifier, err := NewCaptchaResolver(ResolverConfig{
+			Secret:    "secret",
+			VerifyURL: server.URL,
+			Enable:    true,
+			MaxAge:    2 * time.Minute,
+		})
+		So(err, ShouldBeNil)
+		verifier.(*resolver).now = func() time.Time { return now }
+
+		Convey("When checking a token twice", func() {
+			token := "token"
+			first := check(verifier, &token)
+			verified = ""
+			second := check(verifier, &token)
+
+			Convey("Then the replay should be rejected without contacting the endpoint", func() {
+				So(first, ShouldBeNil)
+				So(errors.Is(second, ErrVerificationFailed), ShouldBeTrue)
+				So(verified, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When checking another token", func() {
+			first, second := "first", "second"
+
+			Convey("Then both should be accepted", func() {
+				So(check(verifier, &first), ShouldBeNil)
+				So(check(verifier, &second), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a resolver and a siteverify endpoint failing before accepting the tokens", t, func() {
+		var verified string
+		body := `{"success":false,"error-codes":["internal-error"]}`
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			verified = r.FormValue("response")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}))
+		defer server.Close()
+
+		verifier, err := NewCaptchaResolver(ResolverConfig{Secret: "secret", VerifyURL: server.URL, Enable: true})
+		So(err, ShouldBeNil)
+
+		Convey("When checking a token whose verification fails, then checking it again", func() {
+			token := "token"
+			first := check(verifier, &token)
+			body = `{"success":true}`
+			verified = ""
+			second := check(verifier, &token)
+
+			Convey("Then the failed verification should not have consumed the token", func() {
+				So(errors.Is(first, ErrVerificationFailed), ShouldBeTrue)
+				So(second, ShouldBeNil)
+				So(verified, ShouldEqual, token)
+			})
+		})
+	})
+}
+
+func TestResolver(t *testing.T) {
+	Convey("Given a disabled resolver", t, func() {
+		resolver, err := NewCaptchaResolver(ResolverConfig{})
+		So(err, ShouldBeNil)
+
+		Convey("Then any request should pass", func() {
+			So(resolver.Enabled(), ShouldBeFalse)
+			result, err := resolver.CheckRecaptcha(context.Background(), nil)
+			So(result, ShouldBeNil)
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("Given an enabled resolver", t, func() {
+		resolver, err := NewCaptchaResolver(ResolverConfig{Secret: "secret", Enable: true, MinScore: 0.5})
+		So(err, ShouldBeNil)
+
+		Convey("Then a missing token should be rejected", func() {
+			So(check(resolver, nil), ShouldEqual, ErrMissingToken)
+		})
+
+		Convey("When changing the min score", func() {
+			So(resolver.SetMinScore(0.7), ShouldBeNil)
+
+			Convey("Then the new min score should apply", func() {
+				So(resolver.MinScore(), ShouldEqual, 0.7)
+			})
+		})
+	})
+
+	Convey("Given an unknown provider", t, func() {
+		_, err := NewCaptchaResolver(ResolverConfig{Provider: "unknown"})
+
+		Convey("Then the resolver should not be created", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given the known providers", t, func() {
+		Convey("Then each one should have its default siteverify endpoint", func() {
+			for name, url := range map[string]string{
+				ProviderRecaptchaV3: "https://www.google.com/recaptcha/api/siteverify",
+				ProviderRecaptchaV2: "https://www.google.com/recaptcha/api/siteverify",
+				ProviderHCaptcha:    "https://api.hcaptcha.com/siteverify",
+				ProviderTurnstile:   "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+			} {
+				provider, err := NewProvider(name)
+				So(err, ShouldBeNil)
+				So(provider.VerifyURL(), ShouldEqual, url)
+			}
+		})
+	})
+
+	for _, provider := range []string{ProviderRecaptchaV2, ProviderTurnstile} {
+		Convey("Given a resolver of the "+provider+" provider, which gives no score", t, func() {
+			resolver, err := NewCaptchaResolver(ResolverConfig{Provider: provider, Secret: "secret", Enable: true})
+			So(err, ShouldBeNil)
+
+			Convey("When changing the min score", func() {
+				err := resolver.SetMinScore(0.7)
+
+				Convey("Then it should fail", func() {
+					So(err, ShouldEqual, ErrScoreNotSupported)
+				})
+			})
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package captcha
+
+import (
+	ctx "context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ProviderProofOfWork is the name of the self-hosted proof of work captcha, as given in the configuration.
+const ProviderProofOfWork = "pow"
+
+const (
+	// powChallengeTTL is the time given to solve a proof of work challenge.
+	powChallengeTTL = 5 * time.Minute
+	// powLoadWindow is the period over which the answered challenges are counted to measure the load.
+	powLoadWindow = time.Minute
+	// powMaxDifficulty bounds the difficulty so that a browser, computing a few million SHA-256 hashes per second,
+	// still solves a challenge within seconds, 2^24 hashes being needed on average.
+	powMaxDifficulty = 24
+)
+
+// ErrChallengeNotSupported is returned when a challenge is requested from a resolver not issuing its own challenges.
+var ErrChallengeNotSupported = errors.New("captcha challenges are not issued by the configured provider")
+
+// ErrScoreNotSupported is returned when setting the minimum score of a resolver whose provider gives no score.
+var ErrScoreNotSupported = errors.New("the configured captcha provider gives no score")
+
+// PowChallenge is a hashcash-style challenge: a nonce must be found such that the SHA-256 hash of the
+// "<challenge>:<nonce>" string starts with at least Difficulty zero bits, this string being the captcha token.
+type PowChallenge struct {
+	Challenge  string
+	Difficulty int
+	ExpiresAt  time.Time
+}
+
+// Challenger is implemented by the resolvers issuing their own challenges instead of relying on a third party.
+type Challenger interface {
+	// IssueChallenge returns a new challenge to solve to obtain a captcha token.
+	IssueChallenge() (*PowChallenge, error)
+}
+
+// PowConfig configures the self-hosted proof of work captcha.
+type PowConfig struct {
+	// Difficulty is the number of leading zero bits required when the faucet is idle.
+	Difficulty int `mapstructure:"captcha-pow-difficulty"`
+	// MaxDifficulty is the difficulty the load can raise it up to, the difficulty staying constant when not above it.
+	MaxDifficulty int `mapstructure:"captcha-pow-max-difficulty"`
+	// LoadStep is the number of challenges answered per minute adding one bit to the difficulty.
+	LoadStep int `mapstructure:"captcha-pow-load-step"`
+}
+
+// proofOfWork verifies the solutions of the challenges it issued, the challenges being signed so that they do not
+// need to be stored until they are answered.
+type proofOfWork struct {
+	key    []byte
+	config PowConfig
+	enable bool
+	mu     sync.Mutex
+	// answered are the times at which challenges have been answered during the load window, in order.
+	answered []time.Time
+	used     *replays
+	now      func() time.Time
+}
+
+func newProofOfWork(config PowConfig, enable bool) (*proofOfWork, error) {
+	if config.Difficulty <= 0 || config.Difficulty > powMaxDifficulty {
+		return nil, fmt.Errorf("proof of work difficulty must be between 1 and %d", powMaxDifficulty)
+	}
+	if config.MaxDifficulty > powMaxDifficulty {
+		return nil, fmt.Errorf("proof of work max difficulty must not exceed %d", powMaxDifficulty)
+	}
+
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return &proofOfWork{
+		key:    key,
+		config: config,
+		enable: enable,
+		used:   newReplays(powChallengeTTL),
+		now:    time.Now,
+	}, nil
+}
+
+func (p *proofOfWork) Enabled() bool {
+	return p.enable
+}
+
+// MinScore always returns 0, a solved proof of work having no score.
+func (p *proofOfWork) MinScore() float64 {
+	return 0
+}
+
+// SetMinScore returns ErrScoreNotSupported, a solved proof of work having no score.
+func (p *proofOfWork) SetMinScore(float64) error {
+	return ErrScoreNotSupported
+}
+
+func (p *proofOfWork) IssueChallenge() (*PowChallenge, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	now := p.now()
+	difficulty := p.difficulty(now)
+	p.mu.Unlock()
+
+	expiresAt := now.Add(powChallengeTTL).Truncate(time.Second)
+	payload := fmt.Sprintf("%d.%d.%s", difficulty, expiresAt.Unix(), hex.EncodeToString(random))
+
+	return &PowChallenge{
+		Challenge:  payload + "." + p.sign(payload),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// difficulty returns the difficulty of the next challenge, increased by the number of challenges recently answered
+// when a max difficulty is configured. Unlike the issued challenges, the answered ones cannot be inflated without doing
+// the work. The lock must be held.
+func (p *proofOfWork) difficulty(now time.Time) int {
+	since := now.Add(-powLoadWindow)
+	i := 0
+	for i < len(p.answered) && !p.answered[i].After(since) {
+		i++
+	}
+	p.answered = p.answered[i:]
+
+	if p.config.MaxDifficulty <= p.config.Difficulty || p.config.LoadStep <= 0 {
+		return p.config.Difficulty
+	}
+
+	difficulty := p.config.Difficulty + len(p.answered)/p.config.LoadStep
+	if difficulty > p.config.MaxDifficulty {
+		return p.config.MaxDifficulty
+	}
+	return difficulty
+}
+
+func (p *proofOfWork) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *proofOfWork) CheckRecaptcha(_ ctx.Context, response *string) (*Result, error) {
+	if !p.enable {
+		return nil, nil
+	}
+
+	if response == nil {
+		log.Debug().Msg("No captcha token specified")
+		return nil, ErrMissingToken
+	}
+
+	if err := p.verify(*response); err != nil {
+		log.Debug().Err(err).Msg("Proof of work verification failed")
+		return nil, err
+	}
+	return &Result{}, nil
+}
+
+func (p *proofOfWork) verify(token string) error {
+	sep := strings.LastIndex(token, ":")
+	if sep < 0 || sep == len(token)-1 {
+		return fmt.Errorf("%w: malformed token", ErrVerificationFailed)
+	}
+	challenge := token[:sep]
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return fmt.Errorf("%w: malformed challenge", ErrVerificationFailed)
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(p.sign(payload))) {
+		return fmt.Errorf("%w: challenge not issued by this faucet", ErrVerificationFailed)
+	}
+
+	difficulty, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: malformed challenge", ErrVerificationFailed)
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed challenge", ErrVerificationFailed)
+	}
+	expiresAt := time.Unix(expires, 0)
+
+	now := p.now()
+	if now.After(expiresAt) {
+		return fmt.Errorf("%w: challenge expired", ErrVerificationFailed)
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	if leadingZeroBits(hash[:]) < difficulty {
+		return fmt.Errorf("%w: insufficient work", ErrVerificationFailed)
+	}
+
+	if !p.used.record(challenge, now, expiresAt) {
+		return fmt.Errorf("%w: challenge already answered", ErrVerificationFailed)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.answered = append(p.answered, now)
+
+	return nil
+}
+
+func leadingZeroBits(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,157 @@
+package captcha
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// solve brute forces a nonce answering the given challenge.
+func solve(challenge *PowChallenge) string {
+	for nonce := 0; ; nonce++ {
+		token := challenge.Challenge + ":" + strconv.Itoa(nonce)
+		hash := sha256.Sum256([]byte(token))
+		if leadingZeroBits(hash[:]) >= challenge.Difficulty {
+			return token
+		}
+	}
+}
+
+// fail returns a token answering the challenge with an insufficient work.
+func fail(challenge *PowChallenge) string {
+	for nonce := 0; ; nonce++ {
+		token := challenge.Challenge + ":" + strconv.Itoa(nonce)
+		hash := sha256.Sum256([]byte(token))
+		if leadingZeroBits(hash[:]) < challenge.Difficulty {
+			return token
+		}
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+	Convey("Given a proof of work resolver", t, func() {
+		resolver, err := NewCaptchaResolver(ResolverConfig{
+			Provider: ProviderProofOfWork,
+			Enable:   true,
+			Pow:      PowConfig{Difficulty: 8},
+		})
+		So(err, ShouldBeNil)
+		pow := resolver.(*proofOfWork)
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		pow.now = func() time.Time { return now }
+
+		Convey("When issuing a challenge", func() {
+			challenge, err := resolver.(Challenger).IssueChallenge()
+			So(err, ShouldBeNil)
+			So(challenge.Difficulty, ShouldEqual, 8)
+			So(challenge.ExpiresAt, ShouldEqual, now.Add(powChallengeTTL))
+
+			Convey("Then its solution should be accepted only once", func() {
+				token := solve(challenge)
+				So(check(resolver, &token), ShouldBeNil)
+				So(errors.Is(check(resolver, &token), ErrVerificationFailed), ShouldBeTrue)
+			})
+
+			Convey("Then an insufficient work should be rejected", func() {
+				token := fail(challenge)
+				So(errors.Is(check(resolver, &token), ErrVerificationFailed), ShouldBeTrue)
+			})
+
+			Convey("Then an expired solution should be rejected", func() {
+				token := solve(challenge)
+				now = now.Add(powChallengeTTL + time.Second)
+				So(errors.Is(check(resolver, &token), ErrVerificationFailed), ShouldBeTrue)
+			})
+
+			Convey("Then a solution of a tampered challenge should be rejected", func() {
+				challenge.Difficulty = 1
+				challenge.Challenge = "1" + challenge.Challenge[1:]
+				token := solve(challenge)
+				So(errors.Is(check(resolver, &token), ErrVerificationFailed), ShouldBeTrue)
+			})
+		})
+
+		Convey("When checking a malformed token", func() {
+			for _, token := range []string{"", "challenge", "challenge:", "a.b.c:1", "a.b.c.d:1"} {
+				token := token
+				So(errors.Is(check(resolver, &token), ErrVerificationFailed), ShouldBeTrue)
+			}
+		})
+
+		Convey("When setting the min score", func() {
+			err := resolver.SetMinScore(0.5)
+
+			Convey("Then it should fail as a proof of work has no score", func() {
+				So(err, ShouldEqual, ErrScoreNotSupported)
+			})
+		})
+
+		Convey("When checking no token", func() {
+			Convey("Then it should be rejected", func() {
+				So(check(resolver, nil), ShouldEqual, ErrMissingToken)
+			})
+		})
+	})
+
+	Convey("Given a proof of work resolver whose difficulty increases under load", t, func() {
+		resolver, err := NewCaptchaResolver(ResolverConfig{
+			Provider: ProviderProofOfWork,
+			Enable:   true,
+			Pow:      PowConfig{Difficulty: 8, MaxDifficulty: 10, LoadStep: 2},
+		})
+		So(err, ShouldBeNil)
+		pow := resolver.(*proofOfWork)
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		pow.now = func() time.Time { return now }
+
+		Convey("When issuing challenges in a row", func() {
+			for i := 0; i < 3; i++ {
+				_, err := pow.IssueChallenge()
+				So(err, ShouldBeNil)
+			}
+
+			Convey("Then the difficulty should not increase until they are answered", func() {
+				challenge, err := pow.IssueChallenge()
+				So(err, ShouldBeNil)
+				So(challenge.Difficulty, ShouldEqual, 8)
+			})
+		})
+
+		Convey("When answering challenges in a row", func() {
+			var difficulties []int
+			for i := 0; i < 7; i++ {
+				challenge, err := pow.IssueChallenge()
+				So(err, ShouldBeNil)
+				difficulties = append(difficulties, challenge.Difficulty)
+				token := solve(challenge)
+				So(check(resolver, &token), ShouldBeNil)
+			}
+
+			Convey("Then the difficulty should increase up to the max", func() {
+				So(difficulties, ShouldResemble, []int{8, 8, 9, 9, 10, 10, 10})
+			})
+
+			Convey("Then the difficulty should get back to normal once the load is gone", func() {
+				now = now.Add(powLoadWindow + time.Second)
+				challenge, err := pow.IssueChallenge()
+				So(err, ShouldBeNil)
+				So(challenge.Difficulty, ShouldEqual, 8)
+			})
+		})
+	})
+
+	Convey("Given an invalid difficulty", t, func() {
+		for _, config := range []PowConfig{{Difficulty: 0}, {Difficulty: 25}, {Difficulty: 8, MaxDifficulty: 25}} {
+			_, err := NewCaptchaResolver(ResolverConfig{Provider: ProviderProofOfWork, Pow: config})
+
+			Convey(fmt.Sprintf("Then the resolver should not be created with %+v", config), func() {
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package captcha
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Provider names, as given in the configuration.
+const (
+	ProviderRecaptchaV2 = "recaptcha-v2"
+	ProviderRecaptchaV3 = "recaptcha-v3"
+	ProviderHCaptcha    = "hcaptcha"
+	ProviderTurnstile   = "turnstile"
+)
+
+// Verification is the outcome of a captcha token verification, as returned by the siteverify endpoint of a provider.
+type Verification struct {
+	// Success tells whether the token is valid.
+	Success bool
+	// Score is the likelihood the interaction is legitimate between 0 and 1, nil if not provided.
+	Score *float64
+	// Action is the name of the action the token has been issued for, if provided.
+	Action string
+	// Hostname is the hostname of the site where the captcha has been solved.
+	Hostname string
+	// ChallengeTS is the time at which the captcha has been solved.
+	ChallengeTS time.Time
+	// ErrorCodes describe the reasons of a failed verification.
+	ErrorCodes []string
+}
+
+// Provider is a captcha service verifying the tokens through a siteverify endpoint.
+type Provider interface {
+	// VerifyURL returns the default siteverify endpoint of the provider.
+	VerifyURL() string
+	// ParseResponse decodes the body of a siteverify response.
+	ParseResponse(body io.Reader) (*Verification, error)
+	// Scored tells whether the provider may give a score, to which the minimum score applies.
+	Scored() bool
+}
+
+// NewProvider returns the provider with the given name, reCAPTCHA v3 being the default one.
+func NewProvider(name string) (Provider, error) {
+	switch name {
+	case ProviderRecaptchaV3, "":
+		return recaptcha{scored: true}, nil
+	case ProviderRecaptchaV2:
+		return recaptcha{}, nil
+	case ProviderHCaptcha:
+		return hCaptcha{}, nil
+	case ProviderTurnstile:
+		return turnstile{}, nil
+	default:
+		return nil, fmt.Errorf("unknown captcha provider %q", name)
+	}
+}
+
+// recaptcha is the Google reCAPTCHA service, only v3 providing a score.
+type recaptcha struct {
+	scored bool
+}
+
+type recaptchaResponse struct {
+	Success     bool      `json:"success"`
+	Score       *float64  `json:"score"`
+	Action      string    `json:"action"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Hostname    string    `json:"hostname"`
+	ErrorCodes  []string  `json:"error-codes"`
+}
+
+func (p recaptcha) VerifyURL() string {
+	return "https://www.google.com/recaptcha/api/siteverify"
+}
+
+func (p recaptcha) Scored() bool {
+	return p.scored
+}
+
+func (p recaptcha) ParseResponse(body io.Reader) (*Verification, error) {
+	var resp recaptchaResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	verification := &Verification{
+		Success:     resp.Success,
+		Action:      resp.Action,
+		Hostname:    resp.Hostname,
+		ChallengeTS: resp.ChallengeTS,
+		ErrorCodes:  resp.ErrorCodes,
+	}
+	if p.scored {
+		// A v3 token is expected to be scored, a missing score denoting a v2 token given to a v3 site.
+		score := 0.0
+		if resp.Score != nil {
+			score = *resp.Score
+		}
+		verification.Score = &score
+	}
+
+	return verification, nil
+}
+
+// hCaptcha is the hCaptcha service, only the enterprise plans providing a risk score where 1 denotes a bot.
+type hCaptcha struct{}
+
+type hCaptchaResponse struct {
+	Success     bool      `json:"success"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Hostname    string    `json:"hostname"`
+	Credit      bool      `json:"credit"`
+	ErrorCodes  []string  `json:"error-codes"`
+	Score       *float64  `json:"score"`
+}
+
+func (p hCaptcha) VerifyURL() string {
+	return "https://api.hcaptcha.com/siteverify"
+}
+
+// Scored returns true, the score being given by the enterprise plans.
+func (p hCaptcha) Scored() bool {
+	return true
+}
+
+func (p hCaptcha) ParseResponse(body io.Reader) (*Verification, error) {
+	var resp hCaptchaResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	verification := &Verification{
+		Success:     resp.Success,
+		Hostname:    resp.Hostname,
+		ChallengeTS: resp.ChallengeTS,
+		ErrorCodes:  resp.ErrorCodes,
+	}
+	if resp.Score != nil {
+		score := 1 - *resp.Score
+		verification.Score = &score
+	}
+
+	return verification, nil
+}
+
+// turnstile is the Cloudflare Turnstile service, which doesn't provide any score.
+type turnstile struct{}
+
+type turnstileResponse struct {
+	Success     bool      `json:"success"`
+	ChallengeTS time.Time `json:"challenge_ts"`
+	Hostname    string    `json:"hostname"`
+	ErrorCodes  []string  `json:"error-codes"`
+	Action      string    `json:"action"`
+	CData       string    `json:"cdata"`
+}
+
+func (p turnstile) VerifyURL() string {
+	return "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+}
+
+func (p turnstile) Scored() bool {
+	return false
+}
+
+func (p turnstile) ParseResponse(body io.Reader) (*Verification, error) {
+	var resp turnstileResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	return &Verification{
+		Success:     resp.Success,
+		Action:      resp.Action,
+		Hostname:    resp.Hostname,
+		ChallengeTS: resp.ChallengeTS,
+		ErrorCodes:  resp.ErrorCodes,
+	}, nil
+}
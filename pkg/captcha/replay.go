@@ -0,0 +1,53 @@
+package captcha
+
+import (
+	"sync"
+	"time"
+)
+
+// replays remembers the answered tokens until they expire, so that each token is only accepted once. The expired
+// tokens are swept lazily, at most once per sweep period, so that recording a token does not go through all of them.
+type replays struct {
+	mu        sync.Mutex
+	expiries  map[string]time.Time
+	period    time.Duration
+	nextSweep time.Time
+}
+
+func newReplays(period time.Duration) *replays {
+	return &replays{
+		expiries: make(map[string]time.Time),
+		period:   period,
+	}
+}
+
+// seen tells whether the token has been recorded and has not expired yet.
+func (r *replays) seen(token string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expiry, ok := r.expiries[token]
+	return ok && !now.After(expiry)
+}
+
+// record remembers the token until the given expiry, returning false if it has already been recorded and has not
+// expired yet.
+func (r *replays) record(token string, now, expiry time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.After(r.nextSweep) {
+		for used, usedExpiry := range r.expiries {
+			if now.After(usedExpiry) {
+				delete(r.expiries, used)
+			}
+		}
+		r.nextSweep = now.Add(r.period)
+	}
+
+	if usedExpiry, ok := r.expiries[token]; ok && !now.After(usedExpiry) {
+		return false
+	}
+	r.expiries[token] = expiry
+	return true
+}
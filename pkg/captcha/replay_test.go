@@ -0,0 +1,32 @@
+package captcha
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReplays(t *testing.T) {
+	Convey("Given a replay cache swept every minute", t, func() {
+		replays := newReplays(time.Minute)
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+
+		Convey("When recording a token", func() {
+			So(replays.record("token", now, now.Add(time.Minute)), ShouldBeTrue)
+
+			Convey("Then it should be seen and not recorded again until it expires", func() {
+				So(replays.seen("token", now), ShouldBeTrue)
+				So(replays.record("token", now, now.Add(time.Minute)), ShouldBeFalse)
+				So(replays.seen("other", now), ShouldBeFalse)
+			})
+
+			Convey("Then it should be forgotten once expired", func() {
+				later := now.Add(2 * time.Minute)
+				So(replays.seen("token", later), ShouldBeFalse)
+				So(replays.record("other", later, later.Add(time.Minute)), ShouldBeTrue)
+				So(replays.expiries, ShouldNotContainKey, "token")
+			})
+		})
+	})
+}
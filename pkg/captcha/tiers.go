@@ -0,0 +1,56 @@
+package captcha
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// Tier associates a minimum captcha score to the amount granted to the requests reaching it.
+type Tier struct {
+	MinScore float64
+	Amount   types.Coins
+}
+
+// Tiers grant amounts depending on the captcha score, sorted by increasing minimum score.
+type Tiers []Tier
+
+// NewTiers returns the tiers granting the given amounts of the denom by minimum score, as given on the command line
+// (e.g. 0.5=100000).
+func NewTiers(amounts map[string]int64, denom string) (Tiers, error) {
+	tiers := make(Tiers, 0, len(amounts))
+	for minScore, amount := range amounts {
+		score, err := strconv.ParseFloat(minScore, 64)
+		if err != nil || score < 0 || score > 1 {
+			return nil, fmt.Errorf("invalid tier score %q, must be between 0 and 1", minScore)
+		}
+		if amount <= 0 {
+			return nil, fmt.Errorf("invalid tier amount %d, must be positive", amount)
+		}
+		tiers = append(tiers, Tier{MinScore: score, Amount: types.NewCoins(types.NewInt64Coin(denom, amount))})
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MinScore < tiers[j].MinScore
+	})
+	return tiers, nil
+}
+
+// Amount returns the amount of the highest tier the score reaches, or of the lowest tier if none, nil if there are no
+// tiers.
+func (t Tiers) Amount(score float64) types.Coins {
+	if len(t) == 0 {
+		return nil
+	}
+
+	amount := t[0].Amount
+	for _, tier := range t[1:] {
+		if score < tier.MinScore {
+			break
+		}
+		amount = tier.Amount
+	}
+	return amount
+}
@@ -0,0 +1,45 @@
+package captcha
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTiers(t *testing.T) {
+	Convey("Given tiers granting amounts by minimum score", t, func() {
+		tiers, err := NewTiers(map[string]int64{"0.9": 1000, "0.3": 100, "0.6": 500}, "uknow")
+		So(err, ShouldBeNil)
+
+		Convey("Then they should be sorted by minimum score", func() {
+			So(len(tiers), ShouldEqual, 3)
+			So(tiers[0].MinScore, ShouldEqual, 0.3)
+			So(tiers[2].MinScore, ShouldEqual, 0.9)
+		})
+
+		Convey("Then each score should be granted the amount of the highest tier it reaches", func() {
+			for score, amount := range map[float64]int64{0.1: 100, 0.3: 100, 0.59: 100, 0.6: 500, 0.89: 500, 1: 1000} {
+				So(tiers.Amount(score), ShouldResemble, types.NewCoins(types.NewInt64Coin("uknow", amount)))
+			}
+		})
+	})
+
+	Convey("Given no tiers", t, func() {
+		tiers, err := NewTiers(nil, "uknow")
+		So(err, ShouldBeNil)
+
+		Convey("Then no amount should be granted", func() {
+			So(tiers.Amount(0.5), ShouldBeNil)
+		})
+	})
+
+	Convey("Given invalid tiers", t, func() {
+		Convey("Then they should be refused", func() {
+			for _, amounts := range []map[string]int64{{"high": 100}, {"1.5": 100}, {"0.5": 0}} {
+				_, err := NewTiers(amounts, "uknow")
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
@@ -2,76 +2,152 @@ package captcha
 
 import (
 	ctx "context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
-type siteVerifyResponse struct {
-	Success     bool      `json:"success"`
-	Score       float64   `json:"score"`
-	Action      string    `json:"action"`
-	ChallengeTS time.Time `json:"challenge_ts"`
-	Hostname    string    `json:"hostname"`
-	ErrorCodes  []string  `json:"error-codes"`
-}
-
 type resolver struct {
+	provider      Provider
 	secret        string
 	siteVerifyURL string
+	mu            sync.RWMutex
 	minScore      float64
 	enable        bool
+	action        string
+	hostnames     map[string]struct{}
+	maxAge        time.Duration
+	used          *replays
+	now           func() time.Time
+}
+
+// replayRetention is the duration during which a verified token is remembered when no maximum token age is
+// configured, the tokens of the providers expiring after a few minutes.
+const replayRetention = 10 * time.Minute
+
+func (c *resolver) Enabled() bool {
+	return c.enable
+}
+
+func (c *resolver) MinScore() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.minScore
+}
+
+func (c *resolver) SetMinScore(score float64) error {
+	if !c.provider.Scored() {
+		return ErrScoreNotSupported
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.minScore = score
+	return nil
 }
 
-func (c resolver) CheckRecaptcha(ctx ctx.Context, response *string) error {
+func (c *resolver) CheckRecaptcha(ctx ctx.Context, response *string) (*Result, error) {
 	if !c.enable {
-		return nil
+		return nil, nil
 	}
 
 	if response == nil {
 		log.Debug().Msg("No captcha token specified")
-		return fmt.Errorf("no captcha token specified")
+		return nil, ErrMissingToken
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.siteVerifyURL, nil)
+	if c.used.seen(*response, c.now()) {
+		log.Debug().Msg("Captcha verification failed: token already used")
+		return nil, fmt.Errorf("%w: token already used", ErrVerificationFailed)
+	}
+
+	form := url.Values{}
+	form.Set("secret", c.secret)
+	form.Set("response", *response)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.siteVerifyURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		log.Error().Err(err).Msgf("Error while creating Captcha verification request: %s", err.Error())
-		return fmt.Errorf("error while creating Captcha verification request: %w", err)
+		return nil, fmt.Errorf("error while creating Captcha verification request: %w", err)
 	}
-
-	q := req.URL.Query()
-	q.Add("secret", c.secret)
-	q.Add("response", *response)
-	req.URL.RawQuery = q.Encode()
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error while requesting Captcha verification: %s", err.Error())
-		return fmt.Errorf("error while requesting Captcha verification: %w", err)
+		return nil, fmt.Errorf("error while requesting Captcha verification: %w", err)
 	}
 	defer resp.Body.Close()
 
-	var body siteVerifyResponse
-	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+	verification, err := c.provider.ParseResponse(resp.Body)
+	if err != nil {
 		log.Error().Err(err).Msgf("Error while decoding Captcha verification response: %s", err.Error())
-		return fmt.Errorf("error while decoding Captcha verification response: %w", err)
+		return nil, fmt.Errorf("error while decoding Captcha verification response: %w", err)
 	}
 
 	// If success false, Captcha verification KO.
-	if !body.Success {
-		log.Debug().Msg("Captcha verification failed")
-		return errors.New("captcha verification failed")
+	if !verification.Success {
+		log.Debug().Strs("errorCodes", verification.ErrorCodes).Msg("Captcha verification failed")
+		return nil, ErrVerificationFailed
 	}
 
 	// If score is too low, verification KO.
-	if body.Score < c.minScore {
-		log.Debug().Float64("score", body.Score).Msg("Captcha verification failed: score is too low")
-		return errors.New("captcha verification failed: score is too low")
+	if verification.Score != nil && *verification.Score < c.MinScore() {
+		log.Debug().Float64("score", *verification.Score).Msg("Captcha verification failed: score is too low")
+		return nil, fmt.Errorf("%w: score is too low", ErrVerificationFailed)
+	}
+
+	if err := c.checkContext(verification); err != nil {
+		return nil, err
+	}
+
+	if !c.consume(*response) {
+		log.Debug().Msg("Captcha verification failed: token already used")
+		return nil, fmt.Errorf("%w: token already used", ErrVerificationFailed)
+	}
+
+	return &Result{Score: verification.Score}, nil
+}
+
+// checkContext ensures the token has been issued for the expected action, on an allowed site and recently enough.
+func (c *resolver) checkContext(verification *Verification) error {
+	if c.action != "" && verification.Action != c.action {
+		log.Debug().Str("action", verification.Action).Msg("Captcha verification failed: unexpected action")
+		return fmt.Errorf("%w: unexpected action", ErrVerificationFailed)
+	}
+
+	if len(c.hostnames) > 0 {
+		if _, ok := c.hostnames[strings.ToLower(verification.Hostname)]; !ok {
+			log.Debug().Str("hostname", verification.Hostname).Msg("Captcha verification failed: hostname not allowed")
+			return fmt.Errorf("%w: hostname not allowed", ErrVerificationFailed)
+		}
+	}
+
+	if c.maxAge > 0 && (verification.ChallengeTS.IsZero() || c.now().Sub(verification.ChallengeTS) > c.maxAge) {
+		log.Debug().Time("challengeTS", verification.ChallengeTS).Msg("Captcha verification failed: token too old")
+		return fmt.Errorf("%w: token too old", ErrVerificationFailed)
 	}
 
 	return nil
 }
+
+// consume records the verified token as used, returning false if it already was, e.g. by a concurrent verification.
+// The tokens are remembered for the maximum token age, after which they are rejected anyway.
+func (c *resolver) consume(token string) bool {
+	now := c.now()
+	return c.used.record(token, now, now.Add(c.retention()))
+}
+
+// retention returns the duration during which a verified token is remembered.
+func (c *resolver) retention() time.Duration {
+	if c.maxAge > 0 {
+		return c.maxAge
+	}
+	return replayRetention
+}
@@ -8,9 +8,12 @@ import (
 	"github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	auth "github.com/cosmos/cosmos-sdk/x/auth/types"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 type GrpcClient struct {
@@ -58,6 +61,30 @@ func (client *GrpcClient) Receive(ctx actor.Context) {
 		ctx.Respond(&message.BroadcastTxResponse{
 			TxResponse: resp,
 		})
+
+	case *message.GetTx:
+		goCTX, cancelFunc := context.WithDeadline(context.Background(), msg.Deadline)
+		defer cancelFunc()
+
+		resp, err := client.GetTx(goCTX, msg.Hash)
+		if err != nil {
+			panic(err)
+		}
+		ctx.Respond(&message.GetTxResponse{
+			TxResponse: resp,
+		})
+
+	case *message.GetBalances:
+		goCTX, cancelFunc := context.WithDeadline(context.Background(), msg.Deadline)
+		defer cancelFunc()
+
+		balances, err := client.GetBalances(goCTX, msg.Address)
+		if err != nil {
+			panic(err)
+		}
+		ctx.Respond(&message.GetBalancesResponse{
+			Balances: balances,
+		})
 	}
 }
 
@@ -91,3 +118,28 @@ func (client *GrpcClient) BroadcastTx(context context.Context, txBytes []byte) (
 
 	return grpcRes.TxResponse, nil
 }
+
+// GetTx returns the transaction with the given hash, or nil if not found (i.e. not yet included in a block).
+func (client *GrpcClient) GetTx(context context.Context, hash string) (*types.TxResponse, error) {
+	txClient := tx.NewServiceClient(client.grpcConn)
+	grpcRes, err := txClient.GetTx(context, &tx.GetTxRequest{Hash: hash})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return grpcRes.TxResponse, nil
+}
+
+// GetBalances returns all the balances of the given address.
+func (client *GrpcClient) GetBalances(context context.Context, address string) (types.Coins, error) {
+	bankClient := bank.NewQueryClient(client.grpcConn)
+	query, err := bankClient.AllBalances(context, &bank.QueryAllBalancesRequest{Address: address})
+	if err != nil {
+		return nil, err
+	}
+
+	return query.Balances, nil
+}
@@ -2,10 +2,13 @@ package cosmos
 
 import (
 	"fmt"
+	"okp4/cosmos-faucet/pkg/actor/event"
 	"okp4/cosmos-faucet/pkg/actor/message"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/asynkron/protoactor-go/scheduler"
 	sdk "github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/crypto/hd"
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
@@ -26,6 +29,9 @@ type TxHandler struct {
 	signMode          signing.SignMode
 	cosmosClientProps *actor.Props
 	cosmosClient      *actor.PID
+	confirmInterval   time.Duration
+	confirmAttempts   int
+	eventStream       *eventstream.EventStream
 }
 
 func NewTxHandler(opts ...Option) *TxHandler {
@@ -74,7 +80,23 @@ func WithCosmosClientProps(props *actor.Props) Option {
 	}
 }
 
-// nolint: funlen
+// WithConfirmation enables the confirmation of the submitted transactions, checking every interval whether they have
+// been included in a block, up to the given number of attempts.
+func WithConfirmation(interval time.Duration, attempts int) Option {
+	return func(handler *TxHandler) {
+		handler.confirmInterval = interval
+		handler.confirmAttempts = attempts
+	}
+}
+
+// WithEventStream sets the event stream on which the transactions lifecycle events are published.
+func WithEventStream(stream *eventstream.EventStream) Option {
+	return func(handler *TxHandler) {
+		handler.eventStream = stream
+	}
+}
+
+// nolint: funlen,gocyclo,cyclop
 func (handler *TxHandler) Receive(ctx actor.Context) {
 	switch msg := ctx.Message().(type) {
 	case *actor.Started:
@@ -83,12 +105,13 @@ func (handler *TxHandler) Receive(ctx actor.Context) {
 	case *message.MakeTx:
 		if time.Now().After(msg.Deadline) {
 			log.Warn().Msg("😞 Deadline exceeded, ignore transaction.")
+			handler.notifyFailure(ctx, msg.RequestIDs, fmt.Errorf("deadline exceeded"))
 			break
 		}
 
 		unsignedTx, err := handler.BuildUnsignedTx(msg.Msgs, msg.Memo, msg.GasLimit, msg.FeeAmount)
 		if err != nil {
-			log.Panic().Err(err).Msg("❌ Could not build transaction.")
+			handler.fail(ctx, msg.RequestIDs, err, "❌ Could not build transaction.")
 		}
 
 		accountResp, err := ctx.RequestFuture(
@@ -97,7 +120,7 @@ func (handler *TxHandler) Receive(ctx actor.Context) {
 			time.Until(msg.Deadline),
 		).Result()
 		if err != nil {
-			log.Panic().Err(err).Msg("❌ Could not get account information.")
+			handler.fail(ctx, msg.RequestIDs, err, "❌ Could not get account information.")
 		}
 
 		var account *auth.BaseAccount
@@ -105,7 +128,7 @@ func (handler *TxHandler) Receive(ctx actor.Context) {
 		case *message.GetAccountResponse:
 			account = resp.Account
 		default:
-			log.Panic().Err(fmt.Errorf("wrong response message")).Msg("❌ Could not get account information.")
+			handler.fail(ctx, msg.RequestIDs, fmt.Errorf("wrong response message"), "❌ Could not get account information.")
 		}
 
 		signedTx, err := handler.SignTx(
@@ -116,12 +139,12 @@ func (handler *TxHandler) Receive(ctx actor.Context) {
 			},
 		)
 		if err != nil {
-			log.Panic().Err(err).Msg("❌ Could not sign transaction.")
+			handler.fail(ctx, msg.RequestIDs, err, "❌ Could not sign transaction.")
 		}
 
 		tx, err := handler.EncodeTx(signedTx)
 		if err != nil {
-			log.Panic().Err(err).Msg("❌ Could not encode transaction.")
+			handler.fail(ctx, msg.RequestIDs, err, "❌ Could not encode transaction.")
 		}
 
 		txResp, err := ctx.RequestFuture(
@@ -130,12 +153,21 @@ func (handler *TxHandler) Receive(ctx actor.Context) {
 			time.Until(msg.Deadline),
 		).Result()
 		if err != nil {
-			log.Panic().Err(err).Msg("❌ Could not broadcast transaction.")
+			handler.fail(ctx, msg.RequestIDs, err, "❌ Could not broadcast transaction.")
 		}
 
 		switch resp := txResp.(type) {
 		case *message.BroadcastTxResponse:
-			ctx.Send(msg.TxSubscriber, txResp)
+			ctx.Send(msg.TxSubscriber, &message.BroadcastTxResponse{
+				TxResponse: resp.TxResponse,
+				RequestIDs: msg.RequestIDs,
+			})
+			handler.publish(&event.TxBroadcast{
+				TxHash:     resp.TxResponse.TxHash,
+				Code:       resp.TxResponse.Code,
+				RequestIDs: msg.RequestIDs,
+				Time:       time.Now(),
+			})
 			if resp.TxResponse.Code != 0 {
 				log.Warn().
 					Int("messageCount", len(msg.Msgs)).
@@ -147,13 +179,83 @@ func (handler *TxHandler) Receive(ctx actor.Context) {
 					Str("txHash", resp.TxResponse.TxHash).
 					Uint32("txCode", resp.TxResponse.Code).
 					Msg("🚀 Successfully submit transaction")
+				handler.scheduleCheck(ctx, &message.CheckTx{Hash: resp.TxResponse.TxHash, RequestIDs: msg.RequestIDs})
 			}
 		default:
-			log.Panic().Err(fmt.Errorf("wrong response message")).Msg("❌ Could not broadcast transaction.")
+			handler.fail(ctx, msg.RequestIDs, fmt.Errorf("wrong response message"), "❌ Could not broadcast transaction.")
 		}
+
+	case *message.CheckTx:
+		handler.checkTx(ctx, msg)
 	}
 }
 
+// fail notifies the parent that the transaction including the given requests failed, before crashing.
+func (handler *TxHandler) fail(ctx actor.Context, requestIDs []string, err error, reason string) {
+	handler.notifyFailure(ctx, requestIDs, err)
+	log.Panic().Err(err).Msg(reason)
+}
+
+// notifyFailure notifies the parent and the event stream that the transaction including the given requests failed.
+func (handler *TxHandler) notifyFailure(ctx actor.Context, requestIDs []string, err error) {
+	ctx.Send(ctx.Parent(), &message.TxFailed{Error: err, RequestIDs: requestIDs})
+	handler.publish(&event.TxFailed{Error: err.Error(), RequestIDs: requestIDs, Time: time.Now()})
+}
+
+func (handler *TxHandler) publish(evt interface{}) {
+	if handler.eventStream != nil {
+		handler.eventStream.Publish(evt)
+	}
+}
+
+// scheduleCheck schedules the given check of a submitted transaction, if the confirmation is enabled.
+func (handler *TxHandler) scheduleCheck(ctx actor.Context, check *message.CheckTx) {
+	if handler.confirmInterval <= 0 {
+		return
+	}
+
+	scheduler.NewTimerScheduler(ctx.ActorSystem().Root).SendOnce(handler.confirmInterval, ctx.Self(), check)
+}
+
+// checkTx looks for the submitted transaction in the blockchain, notifying the parent once it is included in a block or
+// once all the attempts have been made.
+func (handler *TxHandler) checkTx(ctx actor.Context, msg *message.CheckTx) {
+	deadline := time.Now().Add(handler.confirmInterval)
+	txResp, err := ctx.RequestFuture(
+		handler.cosmosClient,
+		&message.GetTx{Deadline: deadline, Hash: msg.Hash},
+		time.Until(deadline),
+	).Result()
+	if err != nil {
+		log.Warn().Err(err).Str("txHash", msg.Hash).Msg("😥 Could not check transaction.")
+	}
+
+	if resp, ok := txResp.(*message.GetTxResponse); ok && resp.TxResponse != nil {
+		log.Info().
+			Str("txHash", msg.Hash).
+			Int64("height", resp.TxResponse.Height).
+			Uint32("txCode", resp.TxResponse.Code).
+			Msg("✅ Transaction included in block")
+		ctx.Send(ctx.Parent(), &message.TxConfirmed{TxResponse: resp.TxResponse, RequestIDs: msg.RequestIDs})
+		handler.publish(&event.TxConfirmed{
+			TxHash:     msg.Hash,
+			Code:       resp.TxResponse.Code,
+			Height:     resp.TxResponse.Height,
+			RequestIDs: msg.RequestIDs,
+			Time:       time.Now(),
+		})
+		return
+	}
+
+	if msg.Attempt+1 >= handler.confirmAttempts {
+		log.Warn().Str("txHash", msg.Hash).Msg("😞 Transaction not included in block in time.")
+		handler.notifyFailure(ctx, msg.RequestIDs, fmt.Errorf("transaction %s not included in block in time", msg.Hash))
+		return
+	}
+
+	handler.scheduleCheck(ctx, &message.CheckTx{Hash: msg.Hash, RequestIDs: msg.RequestIDs, Attempt: msg.Attempt + 1})
+}
+
 func (handler *TxHandler) BuildUnsignedTx(
 	msgs []types.Msg,
 	memo string,
@@ -2,12 +2,14 @@ package cosmos
 
 import (
 	"fmt"
+	"okp4/cosmos-faucet/pkg/actor/event"
 	"okp4/cosmos-faucet/pkg/actor/message"
 	"okp4/cosmos-faucet/test/mock"
 	"testing"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
 	"github.com/cosmos/cosmos-sdk/simapp"
 	"github.com/cosmos/cosmos-sdk/types"
 	signing2 "github.com/cosmos/cosmos-sdk/types/tx/signing"
@@ -229,20 +231,34 @@ func TestMakeTx(t *testing.T) {
 		)
 		txHandler.cosmosClient = &actor.PID{Id: "client"}
 
+		parent := &actor.PID{Id: "faucet"}
+
 		Convey("And a MakeTx message with an exceeded deadline", func() {
 			msg := &message.MakeTx{
-				Deadline: time.Now().Add(-time.Second),
+				Deadline:   time.Now().Add(-time.Second),
+				RequestIDs: []string{"request"},
 			}
 
 			Convey("When receiving the message", func() {
+				var failure interface{}
 				mockedContext := &mock.ActorContext{}
 				mockedContext.On("Message").Return(msg)
+				mockedContext.On("Parent").Return(parent)
+				mockedContext.On("Send", parent, AnythingOfType("*message.TxFailed")).
+					Run(func(args Arguments) {
+						failure = args.Get(1)
+					})
 				txHandler.Receive(mockedContext)
 
 				Convey("Then it should not make the transaction", func() {
 					mockedContext.AssertCalled(t, "Message")
 					mockedContext.AssertNotCalled(t, "Spawn", Anything)
-					mockedContext.AssertNotCalled(t, "Send", Anything)
+					mockedContext.AssertNotCalled(t, "RequestFuture", Anything, Anything, Anything)
+				})
+
+				Convey("And it should notify the parent of the failure", func() {
+					mockedContext.AssertCalled(t, "Send", parent, AnythingOfType("*message.TxFailed"))
+					So(failure.(*message.TxFailed).RequestIDs, ShouldResemble, msg.RequestIDs)
 				})
 			})
 		})
@@ -309,9 +325,12 @@ func TestMakeTx(t *testing.T) {
 
 				Convey("When receiving the message", func() {
 					mockedContext.On("Message").Return(msg)
+					mockedContext.On("Parent").Return(parent)
+					mockedContext.On("Send", parent, AnythingOfType("*message.TxFailed"))
 
-					Convey("Then it should crash", func() {
+					Convey("Then it should notify the parent and crash", func() {
 						So(func() { txHandler.Receive(mockedContext) }, ShouldPanic)
+						mockedContext.AssertCalled(t, "Send", parent, AnythingOfType("*message.TxFailed"))
 						mockedContext.AssertCalled(t, "Message")
 						mockedContext.AssertCalled(t, "RequestFuture", txHandler.cosmosClient, AnythingOfType("*message.GetAccount"), Anything)
 						mockedContext.AssertCalled(t, "RequestFuture", txHandler.cosmosClient, AnythingOfType("*message.BroadcastTx"), Anything)
@@ -370,3 +389,72 @@ func TestMakeTx(t *testing.T) {
 		})
 	})
 }
+
+func TestCheckTx(t *testing.T) {
+	Convey("Given a tx handler actor with confirmation enabled", t, func() {
+		stream := eventstream.NewEventStream()
+		var published []interface{}
+		stream.Subscribe(func(evt interface{}) {
+			published = append(published, evt)
+		})
+		txHandler := NewTxHandler(WithConfirmation(time.Second, 3), WithEventStream(stream))
+		txHandler.cosmosClient = &actor.PID{Id: "client"}
+		parent := &actor.PID{Id: "faucet"}
+
+		Convey("And a cosmos client finding the transaction", func() {
+			var notification interface{}
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Parent").Return(parent)
+			mockedContext.On("RequestFuture", txHandler.cosmosClient, AnythingOfType("*message.GetTx"), Anything).
+				Return(mock.MakeFuture(&message.GetTxResponse{TxResponse: &types.TxResponse{TxHash: "hash", Height: 42}}, nil))
+			mockedContext.On("Send", parent, Anything).
+				Run(func(args Arguments) {
+					notification = args.Get(1)
+				})
+
+			Convey("When receiving a CheckTx message", func() {
+				mockedContext.On("Message").Return(&message.CheckTx{Hash: "hash", RequestIDs: []string{"request"}})
+				txHandler.Receive(mockedContext)
+
+				Convey("Then it should notify the parent of the confirmation", func() {
+					So(notification, ShouldHaveSameTypeAs, &message.TxConfirmed{})
+					So(notification.(*message.TxConfirmed).TxResponse.Height, ShouldEqual, 42)
+					So(notification.(*message.TxConfirmed).RequestIDs, ShouldResemble, []string{"request"})
+				})
+
+				Convey("And it should publish the confirmation", func() {
+					So(len(published), ShouldEqual, 1)
+					So(published[0], ShouldHaveSameTypeAs, &event.TxConfirmed{})
+					So(published[0].(*event.TxConfirmed).Height, ShouldEqual, 42)
+				})
+			})
+		})
+
+		Convey("And a cosmos client not finding the transaction", func() {
+			var notification interface{}
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Parent").Return(parent)
+			mockedContext.On("RequestFuture", txHandler.cosmosClient, AnythingOfType("*message.GetTx"), Anything).
+				Return(mock.MakeFuture(&message.GetTxResponse{}, nil))
+			mockedContext.On("Send", parent, Anything).
+				Run(func(args Arguments) {
+					notification = args.Get(1)
+				})
+
+			Convey("When receiving the last CheckTx attempt", func() {
+				mockedContext.On("Message").Return(&message.CheckTx{Hash: "hash", RequestIDs: []string{"request"}, Attempt: 2})
+				txHandler.Receive(mockedContext)
+
+				Convey("Then it should notify the parent of the failure", func() {
+					So(notification, ShouldHaveSameTypeAs, &message.TxFailed{})
+					So(notification.(*message.TxFailed).RequestIDs, ShouldResemble, []string{"request"})
+				})
+
+				Convey("And it should publish the failure", func() {
+					So(len(published), ShouldEqual, 1)
+					So(published[0], ShouldHaveSameTypeAs, &event.TxFailed{})
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,156 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/guard"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// CommandFaucet is the name of the slash command requesting funds.
+	CommandFaucet = "faucet"
+	// optionAddress is the name of the option of the faucet command giving the recipient address.
+	optionAddress = "address"
+	// replyTimeout is the maximum duration to respond to an interaction.
+	replyTimeout = 10 * time.Second
+)
+
+// Bot serves the fund requests made through the faucet slash command, checking them with the guards before sending
+// them to the faucet and replying with the hash of the transaction including them.
+type Bot struct {
+	gateway Gateway
+	context *actor.RootContext
+	faucet  *actor.PID
+	guards  *guard.Chain
+	prefix  string
+}
+
+// Option configures the Bot.
+type Option func(*Bot)
+
+// NewBot returns a bot sending the accepted fund requests to the given faucet actor.
+func NewBot(gateway Gateway, context *actor.RootContext, faucet *actor.PID, opts ...Option) *Bot {
+	bot := &Bot{
+		gateway: gateway,
+		context: context,
+		faucet:  faucet,
+		guards:  guard.NewChain(),
+	}
+	for _, opt := range opts {
+		opt(bot)
+	}
+	return bot
+}
+
+// WithGuards sets the guards checking the fund requests, all the requests being accepted if not set.
+func WithGuards(guards *guard.Chain) Option {
+	return func(bot *Bot) {
+		bot.guards = guards
+	}
+}
+
+// WithAddressPrefix sets the bech32 prefix the recipient addresses must have.
+func WithAddressPrefix(prefix string) Option {
+	return func(bot *Bot) {
+		bot.prefix = prefix
+	}
+}
+
+// Run serves the faucet command until the context is done.
+func (b *Bot) Run(ctx context.Context) error {
+	return b.gateway.Run(ctx, []Command{{
+		Name:        CommandFaucet,
+		Description: "Request tokens from the faucet",
+		Options: []CommandOption{{
+			Name:        optionAddress,
+			Description: "Address to send the tokens to",
+			Required:    true,
+		}},
+	}}, b.handle)
+}
+
+func (b *Bot) handle(interaction *Interaction) {
+	if interaction.Command != CommandFaucet {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+	defer cancel()
+
+	if err := b.gateway.Defer(ctx, interaction); err != nil {
+		log.Err(err).Str("user", interaction.User.ID).Msg("❌ Could not acknowledge faucet command")
+		return
+	}
+
+	address := interaction.Options[optionAddress]
+	msg, err := b.newRequestFunds(ctx, interaction)
+	if err != nil {
+		log.Err(err).Str("toAddress", address).Str("user", interaction.User.ID).Msg("❌ Could not serve faucet command")
+		b.reply(interaction, fmt.Sprintf("❌ Could not send tokens to `%s`: %s.", address, err))
+		return
+	}
+
+	msg.TxSubscriber = b.context.Spawn(actor.PropsFromFunc(func(c actor.Context) {
+		switch resp := c.Message().(type) {
+		case *message.BroadcastTxResponse:
+			if resp.TxResponse.Code != 0 {
+				b.reply(interaction, fmt.Sprintf("❌ Could not send tokens to `%s`: transaction `%s` failed.",
+					address, resp.TxResponse.TxHash))
+			} else {
+				b.reply(interaction, fmt.Sprintf("✅ Tokens sent to `%s` in transaction `%s`.",
+					address, resp.TxResponse.TxHash))
+			}
+			c.Stop(c.Self())
+		case *message.TxFailed:
+			b.reply(interaction, fmt.Sprintf("❌ Could not send tokens to `%s`, please retry later.", address))
+			c.Stop(c.Self())
+		}
+	}))
+	b.context.Send(b.faucet, msg)
+}
+
+// newRequestFunds submits the fund request of the interaction to the guards, returning the fund request to send to the
+// faucet if accepted.
+func (b *Bot) newRequestFunds(ctx context.Context, interaction *Interaction) (*message.RequestFunds, error) {
+	addr, err := types.GetFromBech32(interaction.Options[optionAddress], b.prefix)
+	if err != nil {
+		return nil, errors.New("invalid address")
+	}
+
+	req := &guard.Request{
+		ID:       uuid.NewString(),
+		Address:  addr,
+		Identity: interaction.User.Identity(),
+	}
+	if err := b.guards.Check(ctx, req); err != nil {
+		var rejection *guard.Rejection
+		if errors.As(err, &rejection) {
+			return nil, rejection.Err
+		}
+		return nil, err
+	}
+
+	return &message.RequestFunds{
+		ID:       req.ID,
+		Address:  addr,
+		Amount:   req.Amount,
+		Identity: req.Identity.Key(),
+	}, nil
+}
+
+func (b *Bot) reply(interaction *Interaction, content string) {
+	ctx, cancel := context.WithTimeout(context.Background(), replyTimeout)
+	defer cancel()
+
+	if err := b.gateway.Reply(ctx, interaction, content); err != nil {
+		log.Err(err).Str("user", interaction.User.ID).Msg("❌ Could not reply to faucet command")
+	}
+}
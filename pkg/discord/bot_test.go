@@ -0,0 +1,219 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/faucet"
+	"okp4/cosmos-faucet/pkg/guard"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// stubGateway is a Gateway recording the responses to the interactions it is given to handle.
+type stubGateway struct {
+	mu       sync.Mutex
+	commands []Command
+	deferred []string
+	replies  chan string
+}
+
+func newStubGateway() *stubGateway {
+	return &stubGateway{replies: make(chan string, 10)}
+}
+
+func (g *stubGateway) Run(_ context.Context, commands []Command, _ func(*Interaction)) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.commands = commands
+	return nil
+}
+
+func (g *stubGateway) Defer(_ context.Context, interaction *Interaction) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deferred = append(g.deferred, interaction.ID)
+	return nil
+}
+
+func (g *stubGateway) Reply(_ context.Context, _ *Interaction, content string) error {
+	g.replies <- content
+	return nil
+}
+
+func (g *stubGateway) reply() string {
+	select {
+	case content := <-g.replies:
+		return content
+	case <-time.After(time.Second):
+		return ""
+	}
+}
+
+func TestBot(t *testing.T) {
+	Convey("Given a bot sending the fund requests to a faucet", t, func() {
+		types.GetConfig().SetBech32PrefixForAccount("okp4", "okp4pub")
+		addr := types.AccAddress("recipient-address-01")
+		address, err := types.Bech32ifyAddressBytes("okp4", addr)
+		So(err, ShouldBeNil)
+
+		requests := make(chan *message.RequestFunds, 10)
+		txCode := uint32(0)
+		actorCTX := actor.NewActorSystem().Root
+		faucetPID := actorCTX.Spawn(actor.PropsFromFunc(func(c actor.Context) {
+			if msg, ok := c.Message().(*message.RequestFunds); ok {
+				requests <- msg
+				c.Send(msg.TxSubscriber, &message.BroadcastTxResponse{
+					TxResponse: &types.TxResponse{TxHash: "ABCDEF", Code: txCode},
+					RequestIDs: []string{msg.ID},
+				})
+			}
+		}))
+		defer actorCTX.Stop(faucetPID)
+
+		gateway := newStubGateway()
+		denied := errors.New("denied")
+		bot := NewBot(gateway, actorCTX, faucetPID,
+			WithAddressPrefix("okp4"),
+			WithGuards(guard.NewChain(guard.Func("test", func(_ context.Context, req *guard.Request) error {
+				if req.Identity.ID == "1" {
+					return denied
+				}
+				return nil
+			}))),
+		)
+		interaction := &Interaction{
+			ID:      "42",
+			Token:   "token",
+			Command: CommandFaucet,
+			Options: map[string]string{optionAddress: address},
+			User:    User{ID: "175928847299117063", Username: "alice"},
+		}
+
+		Convey("When running", func() {
+			So(bot.Run(context.Background()), ShouldBeNil)
+
+			Convey("Then the faucet command should be registered", func() {
+				So(gateway.commands, ShouldHaveLength, 1)
+				So(gateway.commands[0].Name, ShouldEqual, CommandFaucet)
+				So(gateway.commands[0].Options[0].Required, ShouldBeTrue)
+			})
+		})
+
+		Convey("When a user invokes the faucet command", func() {
+			bot.handle(interaction)
+
+			Convey("Then the fund request should be sent to the faucet on behalf of the user", func() {
+				var req *message.RequestFunds
+				select {
+				case req = <-requests:
+				case <-time.After(time.Second):
+				}
+				So(req, ShouldNotBeNil)
+				So(req.Address, ShouldResemble, addr)
+				So(req.Identity, ShouldEqual, "discord:175928847299117063")
+			})
+
+			Convey("Then the user should be replied the transaction hash", func() {
+				So(gateway.deferred, ShouldResemble, []string{"42"})
+				So(gateway.reply(), ShouldContainSubstring, "`ABCDEF`")
+			})
+		})
+
+		Convey("When the transaction fails", func() {
+			txCode = 5
+			bot.handle(interaction)
+
+			Convey("Then the user should be told", func() {
+				So(gateway.reply(), ShouldStartWith, "❌")
+			})
+		})
+
+		Convey("When the guards reject the request", func() {
+			interaction.User.ID = "1"
+			bot.handle(interaction)
+
+			Convey("Then the user should be told why without request being sent", func() {
+				So(gateway.reply(), ShouldContainSubstring, "denied")
+				So(requests, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the address is invalid", func() {
+			interaction.Options[optionAddress] = "cosmos1invalid"
+			bot.handle(interaction)
+
+			Convey("Then the user should be told", func() {
+				So(gateway.reply(), ShouldContainSubstring, "invalid address")
+				So(requests, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When another command is invoked", func() {
+			interaction.Command = "other"
+			bot.handle(interaction)
+
+			Convey("Then it should be ignored", func() {
+				So(gateway.deferred, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestBotTxFailure(t *testing.T) {
+	Convey("Given a bot sending the fund requests to a faucet whose transactions cannot be made", t, func() {
+		types.GetConfig().SetBech32PrefixForAccount("okp4", "okp4pub")
+		address, err := types.Bech32ifyAddressBytes("okp4", types.AccAddress("recipient-address-01"))
+		So(err, ShouldBeNil)
+
+		actorCTX := actor.NewActorSystem().Root
+		faucetPID := actorCTX.Spawn(actor.PropsFromProducer(func() actor.Actor {
+			return faucet.NewFaucet(
+				faucet.WithAddress(types.AccAddress("faucet-address-00001")),
+				faucet.WithAmount(types.NewCoins(types.NewInt64Coin("uknow", 1000))),
+				faucet.WithTxHandlerProps(actor.PropsFromFunc(func(c actor.Context) {
+					if msg, ok := c.Message().(*message.MakeTx); ok {
+						c.Send(c.Parent(), &message.TxFailed{Error: errors.New("deadline exceeded"), RequestIDs: msg.RequestIDs})
+					}
+				})),
+			)
+		}))
+		defer actorCTX.Stop(faucetPID)
+
+		gateway := newStubGateway()
+		bot := NewBot(gateway, actorCTX, faucetPID, WithAddressPrefix("okp4"))
+
+		Convey("When a user invokes the faucet command and the transaction is triggered", func() {
+			bot.handle(&Interaction{
+				ID:      "42",
+				Token:   "token",
+				Command: CommandFaucet,
+				Options: map[string]string{optionAddress: address},
+				User:    User{ID: "175928847299117063", Username: "alice"},
+			})
+			actorCTX.Send(faucetPID, &message.TriggerTx{Deadline: time.Now().Add(time.Minute)})
+
+			Convey("Then the user should be told to retry", func() {
+				So(gateway.reply(), ShouldContainSubstring, "please retry later")
+			})
+		})
+	})
+}
+
+func TestUserIdentity(t *testing.T) {
+	Convey("Given a Discord user", t, func() {
+		user := User{ID: "175928847299117063", Username: "alice"}
+
+		Convey("Then its identity should be created at the time given by its identifier", func() {
+			id := user.Identity()
+			So(id.Key(), ShouldEqual, "discord:175928847299117063")
+			So(id.Login, ShouldEqual, "alice")
+			So(id.CreatedAt, ShouldEqual, time.Date(2016, 4, 30, 11, 18, 25, 796000000, time.UTC))
+		})
+	})
+}
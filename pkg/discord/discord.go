@@ -0,0 +1,66 @@
+package discord
+
+import (
+	"context"
+	"okp4/cosmos-faucet/pkg/identity"
+	"strconv"
+	"time"
+)
+
+// IdentityProvider is the name of the identity provider of the Discord users, as recorded along with their requests.
+const IdentityProvider = "discord"
+
+// discordEpoch is the first second of 2015, from which the timestamps of the Discord identifiers are counted.
+const discordEpoch = 1420070400000
+
+// User is the Discord user invoking a command.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// Identity returns the identity of the user, its account creation time being given by its identifier.
+func (u User) Identity() *identity.Identity {
+	id := &identity.Identity{Provider: IdentityProvider, ID: u.ID, Login: u.Username}
+	if snowflake, err := strconv.ParseUint(u.ID, 10, 64); err == nil {
+		id.CreatedAt = time.UnixMilli(int64(snowflake>>22) + discordEpoch).UTC()
+	}
+	return id
+}
+
+// Interaction is the invocation of a slash command by a user.
+type Interaction struct {
+	// ID and Token identify the interaction when responding to it.
+	ID    string
+	Token string
+	// Command is the name of the invoked command.
+	Command string
+	// Options holds the values given to the command options, by option name.
+	Options map[string]string
+	// User is the user invoking the command.
+	User User
+}
+
+// Command describes a slash command registered at Discord.
+type Command struct {
+	Name        string
+	Description string
+	Options     []CommandOption
+}
+
+// CommandOption describes a string option of a slash command.
+type CommandOption struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Gateway connects to Discord to receive the slash command invocations and to respond to them.
+type Gateway interface {
+	// Run registers the given commands and calls handle for each of their invocations until the context is done.
+	Run(ctx context.Context, commands []Command, handle func(*Interaction)) error
+	// Defer acknowledges the interaction, its response being given later through Reply.
+	Defer(ctx context.Context, interaction *Interaction) error
+	// Reply gives the response to a deferred interaction.
+	Reply(ctx context.Context, interaction *Interaction, content string) error
+}
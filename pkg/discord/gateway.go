@@ -0,0 +1,346 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultAPIURL     = "https://discord.com/api/v10"
+	defaultGatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+	// reconnectDelay is the delay before reconnecting to the gateway once disconnected.
+	reconnectDelay = 5 * time.Second
+)
+
+// Gateway opcodes, see https://discord.com/developers/docs/topics/opcodes-and-status-codes#gateway.
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+)
+
+const (
+	// interactionApplicationCommand is the type of the interactions invoking a slash command.
+	interactionApplicationCommand = 2
+	// callbackDeferredMessage is the type of the interaction responses acknowledging an interaction to reply later.
+	callbackDeferredMessage = 5
+	// commandChatInput is the type of the slash commands.
+	commandChatInput = 1
+	// optionString is the type of the string command options.
+	optionString = 3
+)
+
+// ErrReconnect is returned when the gateway asks to reconnect.
+var ErrReconnect = errors.New("gateway requested a reconnection")
+
+type payload struct {
+	Op       int             `json:"op"`
+	Data     json.RawMessage `json:"d,omitempty"`
+	Sequence *int64          `json:"s,omitempty"`
+	Type     string          `json:"t,omitempty"`
+}
+
+type hello struct {
+	HeartbeatInterval int64 `json:"heartbeat_interval"`
+}
+
+type identify struct {
+	Token      string            `json:"token"`
+	Intents    int               `json:"intents"`
+	Properties map[string]string `json:"properties"`
+}
+
+type interactionEvent struct {
+	ID    string `json:"id"`
+	Type  int    `json:"type"`
+	Token string `json:"token"`
+	Data  struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+	Member *struct {
+		User User `json:"user"`
+	} `json:"member"`
+	User *User `json:"user"`
+}
+
+type commandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+type command struct {
+	Type        int             `json:"type"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []commandOption `json:"options,omitempty"`
+}
+
+// Client is the Gateway connecting to Discord as a bot, receiving the interactions through the websocket gateway and
+// responding to them through the REST api.
+type Client struct {
+	token         string
+	applicationID string
+	guildID       string
+	apiURL        string
+	gatewayURL    string
+	httpClient    *http.Client
+}
+
+// ClientOption configures the Client.
+type ClientOption func(*Client)
+
+// NewClient returns a client authenticating with the given bot token, on behalf of the given application.
+func NewClient(token, applicationID string, opts ...ClientOption) *Client {
+	client := &Client{
+		token:         token,
+		applicationID: applicationID,
+		apiURL:        defaultAPIURL,
+		gatewayURL:    defaultGatewayURL,
+		httpClient:    http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// WithGuild registers the commands in the given guild only, where they are available immediately, instead of
+// globally.
+func WithGuild(guildID string) ClientOption {
+	return func(client *Client) {
+		client.guildID = guildID
+	}
+}
+
+// WithURLs overrides the URLs of the REST api and of the websocket gateway, if not empty.
+func WithURLs(apiURL, gatewayURL string) ClientOption {
+	return func(client *Client) {
+		if apiURL != "" {
+			client.apiURL = strings.TrimSuffix(apiURL, "/")
+		}
+		if gatewayURL != "" {
+			client.gatewayURL = gatewayURL
+		}
+	}
+}
+
+// Run registers the commands, then stays connected to the gateway, reconnecting when disconnected, until the context
+// is done.
+func (c *Client) Run(ctx context.Context, commands []Command, handle func(*Interaction)) error {
+	if err := c.register(ctx, commands); err != nil {
+		return fmt.Errorf("could not register commands: %w", err)
+	}
+
+	for {
+		err := c.connect(ctx, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Warn().Err(err).Msg("😥 Disconnected from Discord gateway, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (c *Client) Defer(ctx context.Context, interaction *Interaction) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/interactions/%s/%s/callback", interaction.ID, interaction.Token),
+		map[string]int{"type": callbackDeferredMessage})
+}
+
+func (c *Client) Reply(ctx context.Context, interaction *Interaction, content string) error {
+	return c.do(ctx, http.MethodPatch, fmt.Sprintf("/webhooks/%s/%s/messages/@original", c.applicationID, interaction.Token),
+		map[string]string{"content": content})
+}
+
+func (c *Client) register(ctx context.Context, commands []Command) error {
+	body := make([]command, 0, len(commands))
+	for _, cmd := range commands {
+		options := make([]commandOption, 0, len(cmd.Options))
+		for _, option := range cmd.Options {
+			options = append(options, commandOption{
+				Type:        optionString,
+				Name:        option.Name,
+				Description: option.Description,
+				Required:    option.Required,
+			})
+		}
+		body = append(body, command{Type: commandChatInput, Name: cmd.Name, Description: cmd.Description, Options: options})
+	}
+
+	path := fmt.Sprintf("/applications/%s/commands", c.applicationID)
+	if c.guildID != "" {
+		path = fmt.Sprintf("/applications/%s/guilds/%s/commands", c.applicationID, c.guildID)
+	}
+	return c.do(ctx, http.MethodPut, path, body)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("discord api responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// connect identifies to the gateway and dispatches the received interactions until disconnected.
+func (c *Client) connect(ctx context.Context, handle func(*Interaction)) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.gatewayURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	var hi payload
+	if err := conn.ReadJSON(&hi); err != nil {
+		return err
+	}
+	var h hello
+	if err := json.Unmarshal(hi.Data, &h); hi.Op != opHello || err != nil || h.HeartbeatInterval <= 0 {
+		return errors.New("unexpected gateway greeting")
+	}
+
+	var writeMu sync.Mutex
+	send := func(p interface{}) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(p)
+	}
+
+	if err := send(map[string]interface{}{"op": opIdentify, "d": identify{
+		Token:      c.token,
+		Properties: map[string]string{"os": "linux", "browser": "cosmos-faucet", "device": "cosmos-faucet"},
+	}}); err != nil {
+		return err
+	}
+
+	var seqMu sync.Mutex
+	var seq *int64
+	go func() {
+		ticker := time.NewTicker(time.Duration(h.HeartbeatInterval) * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				seqMu.Lock()
+				heartbeat := map[string]interface{}{"op": opHeartbeat, "d": seq}
+				seqMu.Unlock()
+				if err := send(heartbeat); err != nil {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var p payload
+		if err := conn.ReadJSON(&p); err != nil {
+			return err
+		}
+		if p.Sequence != nil {
+			seqMu.Lock()
+			seq = p.Sequence
+			seqMu.Unlock()
+		}
+
+		switch p.Op {
+		case opDispatch:
+			if p.Type == "READY" {
+				log.Info().Msg("🤖 Connected to Discord gateway")
+			}
+			if p.Type == "INTERACTION_CREATE" {
+				if interaction := parseInteraction(p.Data); interaction != nil {
+					go handle(interaction)
+				}
+			}
+		case opHeartbeat:
+			seqMu.Lock()
+			heartbeat := map[string]interface{}{"op": opHeartbeat, "d": seq}
+			seqMu.Unlock()
+			if err := send(heartbeat); err != nil {
+				return err
+			}
+		case opReconnect, opInvalidSession:
+			return ErrReconnect
+		}
+	}
+}
+
+// parseInteraction returns the slash command invocation held by the given interaction event, nil if it is not one.
+func parseInteraction(data json.RawMessage) *Interaction {
+	var event interactionEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Type != interactionApplicationCommand {
+		return nil
+	}
+
+	interaction := &Interaction{
+		ID:      event.ID,
+		Token:   event.Token,
+		Command: event.Data.Name,
+		Options: make(map[string]string, len(event.Data.Options)),
+	}
+	for _, option := range event.Data.Options {
+		var value string
+		if err := json.Unmarshal(option.Value, &value); err == nil {
+			interaction.Options[option.Name] = value
+		}
+	}
+	switch {
+	case event.Member != nil:
+		interaction.User = event.Member.User
+	case event.User != nil:
+		interaction.User = *event.User
+	}
+	return interaction
+}
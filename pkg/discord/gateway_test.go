@@ -0,0 +1,180 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// apiCall is a request received by the stand-in REST api.
+type apiCall struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   string
+}
+
+// newDiscordServer returns a local stand-in of the Discord REST api and gateway, recording the api calls and the
+// identify payloads, and dispatching the given interaction once identified.
+func newDiscordServer(interaction string) (*httptest.Server, *sync.Mutex, *[]apiCall, chan identify) {
+	var mu sync.Mutex
+	var calls []apiCall
+	identified := make(chan identify, 1)
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gateway", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_ = conn.WriteJSON(map[string]interface{}{"op": opHello, "d": hello{HeartbeatInterval: 50}})
+		var p payload
+		if err := conn.ReadJSON(&p); err != nil || p.Op != opIdentify {
+			return
+		}
+		var id identify
+		_ = json.Unmarshal(p.Data, &id)
+		identified <- id
+
+		_ = conn.WriteJSON(map[string]interface{}{"op": opDispatch, "s": 1, "t": "READY", "d": map[string]string{}})
+		_ = conn.WriteJSON(map[string]interface{}{
+			"op": opDispatch, "s": 2, "t": "INTERACTION_CREATE", "d": json.RawMessage(interaction),
+		})
+		for {
+			if err := conn.ReadJSON(&p); err != nil {
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		calls = append(calls, apiCall{
+			Method: r.Method,
+			Path:   strings.TrimPrefix(r.URL.Path, "/api"),
+			Auth:   r.Header.Get("Authorization"),
+			Body:   string(body),
+		})
+		mu.Unlock()
+		if strings.Contains(r.URL.Path, "unknown") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return httptest.NewServer(mux), &mu, &calls, identified
+}
+
+func TestClient(t *testing.T) {
+	Convey("Given a client connected to a local Discord stand-in", t, func() {
+		server, mu, calls, identified := newDiscordServer(`{
+			"id": "42", "type": 2, "token": "interaction-token",
+			"data": {"name": "faucet", "options": [{"name": "address", "type": 3, "value": "okp41abc"}]},
+			"member": {"user": {"id": "175928847299117063", "username": "alice"}}
+		}`)
+		defer server.Close()
+
+		client := NewClient("bot-token", "app",
+			WithGuild("guild"),
+			WithURLs(server.URL+"/api", "ws"+strings.TrimPrefix(server.URL, "http")+"/gateway"),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		interactions := make(chan *Interaction, 1)
+		done := make(chan error, 1)
+		go func() {
+			done <- client.Run(ctx, []Command{{
+				Name:        "faucet",
+				Description: "Request tokens",
+				Options:     []CommandOption{{Name: "address", Description: "Recipient", Required: true}},
+			}}, func(interaction *Interaction) { interactions <- interaction })
+		}()
+
+		Convey("When running", func() {
+			var id identify
+			select {
+			case id = <-identified:
+			case <-time.After(time.Second):
+			}
+			var interaction *Interaction
+			select {
+			case interaction = <-interactions:
+			case <-time.After(time.Second):
+			}
+
+			Convey("Then the commands should be registered in the guild and the bot identified", func() {
+				mu.Lock()
+				defer mu.Unlock()
+				So(*calls, ShouldNotBeEmpty)
+				So((*calls)[0].Method, ShouldEqual, http.MethodPut)
+				So((*calls)[0].Path, ShouldEqual, "/applications/app/guilds/guild/commands")
+				So((*calls)[0].Auth, ShouldEqual, "Bot bot-token")
+				So((*calls)[0].Body, ShouldContainSubstring, `"name":"address"`)
+				So(id.Token, ShouldEqual, "bot-token")
+			})
+
+			Convey("Then the interactions should be dispatched", func() {
+				So(interaction, ShouldNotBeNil)
+				So(*interaction, ShouldResemble, Interaction{
+					ID:      "42",
+					Token:   "interaction-token",
+					Command: "faucet",
+					Options: map[string]string{"address": "okp41abc"},
+					User:    User{ID: "175928847299117063", Username: "alice"},
+				})
+			})
+
+			Convey("When responding to an interaction", func() {
+				So(client.Defer(ctx, interaction), ShouldBeNil)
+				So(client.Reply(ctx, interaction, "done"), ShouldBeNil)
+
+				Convey("Then it should be acknowledged then its response edited", func() {
+					mu.Lock()
+					defer mu.Unlock()
+					So(*calls, ShouldHaveLength, 3)
+					So((*calls)[1].Path, ShouldEqual, "/interactions/42/interaction-token/callback")
+					So((*calls)[1].Body, ShouldEqual, `{"type":5}`)
+					So((*calls)[2].Method, ShouldEqual, http.MethodPatch)
+					So((*calls)[2].Path, ShouldEqual, "/webhooks/app/interaction-token/messages/@original")
+					So((*calls)[2].Body, ShouldEqual, `{"content":"done"}`)
+				})
+			})
+
+			Convey("When the context is done", func() {
+				cancel()
+
+				Convey("Then it should stop", func() {
+					var err error
+					select {
+					case err = <-done:
+					case <-time.After(time.Second):
+						err = context.DeadlineExceeded
+					}
+					So(err, ShouldBeNil)
+				})
+			})
+		})
+
+		Convey("When the api rejects a call", func() {
+			err := client.Reply(ctx, &Interaction{Token: "unknown"}, "done")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,297 @@
+package eligibility
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrCooldown is returned when the address has already received funds during the cooldown period.
+var ErrCooldown = errors.New("address has recently received funds")
+
+// ErrBalanceTooHigh is returned when the address balance is above the maximum allowed to request funds.
+var ErrBalanceTooHigh = errors.New("address balance is too high")
+
+// ErrPaused is returned when the faucet is paused.
+var ErrPaused = errors.New("faucet is paused")
+
+// ErrBudgetExhausted is returned when the faucet already distributed its whole budget over the budget period.
+var ErrBudgetExhausted = errors.New("faucet budget is exhausted")
+
+// Reason tells why an address cannot currently request funds.
+type Reason string
+
+const (
+	// ReasonDenylisted denotes an address matching an entry of the denylist.
+	ReasonDenylisted Reason = "denylisted"
+	// ReasonNotAllowlisted denotes an address not matching any entry of a non empty allowlist.
+	ReasonNotAllowlisted Reason = "not_allowlisted"
+	// ReasonCooldown denotes an address having received funds during the cooldown period.
+	ReasonCooldown Reason = "cooldown"
+	// ReasonBalanceTooHigh denotes an address whose balance is above the configured maximum.
+	ReasonBalanceTooHigh Reason = "balance_too_high"
+	// ReasonPaused denotes a paused faucet.
+	ReasonPaused Reason = "paused"
+	// ReasonBudgetExhausted denotes a faucet having distributed its whole budget over the budget period.
+	ReasonBudgetExhausted Reason = "budget_exhausted"
+)
+
+// Decision is the outcome of an eligibility check, an empty reason meaning the address is eligible.
+type Decision struct {
+	Reason Reason
+	// RetryAfter is the duration after which the address may be eligible again, if known.
+	RetryAfter time.Duration
+}
+
+// Eligible returns true if the address can request funds.
+func (d Decision) Eligible() bool {
+	return d.Reason == ""
+}
+
+// Err returns the error corresponding to the decision, or nil if the address is eligible.
+func (d Decision) Err() error {
+	if d.Eligible() {
+		return nil
+	}
+
+	return &IneligibleError{Decision: d}
+}
+
+// IneligibleError is the error of a decision refusing an address, wrapping the error corresponding to its reason.
+type IneligibleError struct {
+	Decision Decision
+}
+
+func (e *IneligibleError) Error() string {
+	if e.Decision.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry in %s", e.Unwrap(), e.Decision.RetryAfter.Round(time.Second))
+	}
+	return e.Unwrap().Error()
+}
+
+func (e *IneligibleError) Unwrap() error {
+	switch e.Decision.Reason {
+	case ReasonDenylisted:
+		return access.ErrDenied
+	case ReasonNotAllowlisted:
+		return access.ErrNotAllowed
+	case ReasonCooldown:
+		return ErrCooldown
+	case ReasonBalanceTooHigh:
+		return ErrBalanceTooHigh
+	case ReasonPaused:
+		return ErrPaused
+	case ReasonBudgetExhausted:
+		return ErrBudgetExhausted
+	default:
+		return fmt.Errorf("address is not eligible: %s", e.Decision.Reason)
+	}
+}
+
+// BalanceQuerier gives the balances of an address.
+type BalanceQuerier interface {
+	GetBalances(ctx context.Context, address string) (types.Coins, error)
+}
+
+// Checker decides whether an address can currently request funds. It is the single decision logic shared by all the
+// ways to request funds, and to tell beforehand if a request would be accepted.
+type Checker struct {
+	accessList   *access.List
+	store        ledger.Store
+	cooldown     time.Duration
+	balances     BalanceQuerier
+	maxBalance   types.Coins
+	budget       types.Coins
+	budgetPeriod time.Duration
+	paused       func() bool
+	now          func() time.Time
+}
+
+func NewChecker(opts ...Option) *Checker {
+	checker := &Checker{now: time.Now}
+	for _, opt := range opts {
+		opt(checker)
+	}
+
+	return checker
+}
+
+type Option func(checker *Checker)
+
+// WithAccessList sets the allow/deny lists the addresses are checked against.
+func WithAccessList(list *access.List) Option {
+	return func(checker *Checker) {
+		checker.accessList = list
+	}
+}
+
+// WithStore sets the ledger in which the past distributions are looked up to apply the cooldown and the budget.
+func WithStore(store ledger.Store) Option {
+	return func(checker *Checker) {
+		checker.store = store
+	}
+}
+
+// WithCooldown sets the minimum duration between two fund requests of a same address, disabled if 0.
+func WithCooldown(cooldown time.Duration) Option {
+	return func(checker *Checker) {
+		checker.cooldown = cooldown
+	}
+}
+
+// WithMaxBalance refuses the addresses already owning at least one of the given amounts.
+func WithMaxBalance(querier BalanceQuerier, maxBalance types.Coins) Option {
+	return func(checker *Checker) {
+		checker.balances = querier
+		checker.maxBalance = maxBalance
+	}
+}
+
+// WithBudget limits the amounts the faucet can distribute over a sliding period, refusing any request which would exceed
+// one of the given amounts.
+func WithBudget(budget types.Coins, period time.Duration) Option {
+	return func(checker *Checker) {
+		checker.budget = budget
+		checker.budgetPeriod = period
+	}
+}
+
+// WithPaused sets the function telling whether the faucet is paused.
+func WithPaused(paused func() bool) Option {
+	return func(checker *Checker) {
+		checker.paused = paused
+	}
+}
+
+// Check returns the eligibility decision of the given address, an error being returned only if the decision could not
+// be made.
+func (c *Checker) Check(ctx context.Context, address string) (Decision, error) {
+	return c.CheckRequest(ctx, address, nil)
+}
+
+// CheckRequest returns the eligibility decision of a request of the given amount to the given address, an empty amount
+// only requiring the budget not to be already exhausted.
+func (c *Checker) CheckRequest(ctx context.Context, address string, amount types.Coins) (Decision, error) {
+	if c.paused != nil && c.paused() {
+		return Decision{Reason: ReasonPaused}, nil
+	}
+
+	if c.accessList != nil {
+		switch err := c.accessList.Check(address); {
+		case errors.Is(err, access.ErrDenied):
+			return Decision{Reason: ReasonDenylisted}, nil
+		case errors.Is(err, access.ErrNotAllowed):
+			return Decision{Reason: ReasonNotAllowlisted}, nil
+		case err != nil:
+			return Decision{}, err
+		}
+	}
+
+	decision, err := c.CheckRecords(address, amount)
+	if err != nil || !decision.Eligible() {
+		return decision, err
+	}
+
+	return c.checkBalance(ctx, address)
+}
+
+// CheckRecords returns the eligibility decision of a request of the given amount to the given address from the
+// distributions recorded in the ledger only, that is the budget and the cooldown.
+func (c *Checker) CheckRecords(address string, amount types.Coins) (Decision, error) {
+	decision, err := c.checkBudget(amount)
+	if err != nil || !decision.Eligible() {
+		return decision, err
+	}
+
+	return c.checkCooldown(address)
+}
+
+func (c *Checker) checkCooldown(address string) (Decision, error) {
+	if c.store == nil || c.cooldown <= 0 {
+		return Decision{}, nil
+	}
+
+	since := c.now().Add(-c.cooldown)
+	var last *ledger.Record
+	err := c.walk(ledger.Query{Address: address}, since, func(record ledger.Record) bool {
+		last = &record
+		return false
+	})
+	if err != nil || last == nil {
+		return Decision{}, err
+	}
+
+	return Decision{
+		Reason:     ReasonCooldown,
+		RetryAfter: last.CreatedAt.Sub(since),
+	}, nil
+}
+
+// checkBudget refuses the requests of the given amount once the budget is exhausted or if they would exceed it.
+func (c *Checker) checkBudget(amount types.Coins) (Decision, error) {
+	if c.store == nil || c.budget.IsZero() || c.budgetPeriod <= 0 {
+		return Decision{}, nil
+	}
+
+	since := c.now().Add(-c.budgetPeriod)
+	spent := types.NewCoins()
+	var oldest time.Time
+	err := c.walk(ledger.Query{}, since, func(record ledger.Record) bool {
+		spent = spent.Add(record.Amount...)
+		oldest = record.CreatedAt
+		return true
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+
+	for _, coin := range c.budget {
+		denomSpent := spent.AmountOf(coin.Denom)
+		if denomSpent.GTE(coin.Amount) || denomSpent.Add(amount.AmountOf(coin.Denom)).GT(coin.Amount) {
+			return Decision{
+				Reason:     ReasonBudgetExhausted,
+				RetryAfter: oldest.Sub(since),
+			}, nil
+		}
+	}
+
+	return Decision{}, nil
+}
+
+func (c *Checker) checkBalance(ctx context.Context, address string) (Decision, error) {
+	if c.balances == nil || c.maxBalance.IsZero() {
+		return Decision{}, nil
+	}
+
+	balances, err := c.balances.GetBalances(ctx, address)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	for _, coin := range c.maxBalance {
+		if balances.AmountOf(coin.Denom).GTE(coin.Amount) {
+			return Decision{Reason: ReasonBalanceTooHigh}, nil
+		}
+	}
+
+	return Decision{}, nil
+}
+
+// walk calls fn for each non failed record matching the query created after since, from the most recent to the oldest,
+// until fn returns false.
+func (c *Checker) walk(query ledger.Query, since time.Time, fn func(record ledger.Record) bool) error {
+	return ledger.Walk(c.store, query, func(record ledger.Record) bool {
+		if !record.CreatedAt.After(since) {
+			return false
+		}
+		if record.Status == ledger.StatusFailed {
+			return true
+		}
+		return fn(record)
+	})
+}
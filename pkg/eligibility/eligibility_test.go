@@ -0,0 +1,173 @@
+package eligibility
+
+import (
+	"context"
+	"errors"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	addr1 = "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27"
+	addr2 = "okp41pmkq300lrngpkeprygfrtag0xpgp9z92c7eskm"
+)
+
+var (
+	now    = time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	amount = types.NewCoins(types.NewInt64Coin("uknow", 100))
+)
+
+type balances map[string]types.Coins
+
+func (b balances) GetBalances(_ context.Context, address string) (types.Coins, error) {
+	if coins, ok := b[address]; ok {
+		return coins, nil
+	}
+	return nil, errors.New("unknown address")
+}
+
+func newStore(records ...ledger.Record) ledger.Store {
+	store := ledger.NewMemoryStore()
+	for _, record := range records {
+		So(store.Put(record), ShouldBeNil)
+	}
+	return store
+}
+
+func TestCheck(t *testing.T) {
+	Convey("Given a checker without any rule", t, func() {
+		checker := NewChecker()
+
+		Convey("Then any address should be eligible", func() {
+			decision, err := checker.Check(context.Background(), addr1)
+			So(err, ShouldBeNil)
+			So(decision.Eligible(), ShouldBeTrue)
+			So(decision.Err(), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a paused faucet", t, func() {
+		checker := NewChecker(WithPaused(func() bool { return true }))
+
+		Convey("Then no address should be eligible", func() {
+			decision, err := checker.Check(context.Background(), addr1)
+			So(err, ShouldBeNil)
+			So(decision.Reason, ShouldEqual, ReasonPaused)
+			So(errors.Is(decision.Err(), ErrPaused), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a checker with access lists", t, func() {
+		checker := NewChecker(WithAccessList(access.NewList(access.Lists{Allow: []string{"okp41rhd*"}, Deny: []string{addr1}})))
+
+		Convey("Then the access lists should be enforced", func() {
+			decision, err := checker.Check(context.Background(), addr1)
+			So(err, ShouldBeNil)
+			So(decision.Reason, ShouldEqual, ReasonDenylisted)
+			So(errors.Is(decision.Err(), access.ErrDenied), ShouldBeTrue)
+
+			decision, err = checker.Check(context.Background(), addr2)
+			So(err, ShouldBeNil)
+			So(decision.Reason, ShouldEqual, ReasonNotAllowlisted)
+		})
+	})
+
+	Convey("Given a checker with a cooldown", t, func() {
+		store := newStore(
+			ledger.Record{ID: "1", Address: addr1, Amount: amount, Status: ledger.StatusSubmitted, CreatedAt: now.Add(-20 * time.Minute)},
+			ledger.Record{ID: "2", Address: addr2, Amount: amount, Status: ledger.StatusSubmitted, CreatedAt: now.Add(-2 * time.Hour)},
+			ledger.Record{ID: "3", Address: addr2, Amount: amount, Status: ledger.StatusFailed, CreatedAt: now.Add(-time.Minute)},
+		)
+		checker := NewChecker(WithStore(store), WithCooldown(time.Hour))
+		checker.now = func() time.Time { return now }
+
+		Convey("Then an address recently funded should be in cooldown with the remaining time", func() {
+			decision, err := checker.Check(context.Background(), addr1)
+			So(err, ShouldBeNil)
+			So(decision.Reason, ShouldEqual, ReasonCooldown)
+			So(decision.RetryAfter, ShouldEqual, 40*time.Minute)
+			So(errors.Is(decision.Err(), ErrCooldown), ShouldBeTrue)
+			So(decision.Err().Error(), ShouldEqual, "address has recently received funds, retry in 40m0s")
+		})
+
+		Convey("Then an address funded before the cooldown or having failed requests should be eligible", func() {
+			decision, err := checker.Check(context.Background(), addr2)
+			So(err, ShouldBeNil)
+			So(decision.Eligible(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a checker with a max balance", t, func() {
+		checker := NewChecker(WithMaxBalance(
+			balances{
+				addr1: types.NewCoins(types.NewInt64Coin("uknow", 1000)),
+				addr2: types.NewCoins(types.NewInt64Coin("uknow", 999), types.NewInt64Coin("other", 5000)),
+			},
+			types.NewCoins(types.NewInt64Coin("uknow", 1000)),
+		))
+
+		Convey("Then only the addresses below the max balance should be eligible", func() {
+			decision, err := checker.Check(context.Background(), addr1)
+			So(err, ShouldBeNil)
+			So(decision.Reason, ShouldEqual, ReasonBalanceTooHigh)
+
+			decision, err = checker.Check(context.Background(), addr2)
+			So(err, ShouldBeNil)
+			So(decision.Eligible(), ShouldBeTrue)
+		})
+
+		Convey("Then an error should be returned if the balance cannot be retrieved", func() {
+			_, err := checker.Check(context.Background(), "okp41unknown")
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a checker with a budget", t, func() {
+		store := newStore(
+			ledger.Record{ID: "1", Address: addr1, Amount: amount, Status: ledger.StatusConfirmed, CreatedAt: now.Add(-30 * time.Hour)},
+			ledger.Record{ID: "2", Address: addr1, Amount: amount, Status: ledger.StatusConfirmed, CreatedAt: now.Add(-20 * time.Hour)},
+			ledger.Record{ID: "3", Address: addr2, Amount: amount, Status: ledger.StatusQueued, CreatedAt: now.Add(-time.Hour)},
+			ledger.Record{ID: "4", Address: addr2, Amount: amount, Status: ledger.StatusFailed, CreatedAt: now.Add(-time.Minute)},
+		)
+
+		Convey("When the budget is reached over the period", func() {
+			checker := NewChecker(WithStore(store), WithBudget(types.NewCoins(types.NewInt64Coin("uknow", 200)), 24*time.Hour))
+			checker.now = func() time.Time { return now }
+
+			Convey("Then no address should be eligible until the oldest distribution leaves the period", func() {
+				decision, err := checker.Check(context.Background(), addr1)
+				So(err, ShouldBeNil)
+				So(decision.Reason, ShouldEqual, ReasonBudgetExhausted)
+				So(decision.RetryAfter, ShouldEqual, 4*time.Hour)
+			})
+		})
+
+		Convey("When the budget is not reached over the period", func() {
+			checker := NewChecker(WithStore(store), WithBudget(types.NewCoins(types.NewInt64Coin("uknow", 300)), 24*time.Hour))
+			checker.now = func() time.Time { return now }
+
+			Convey("Then the addresses should be eligible", func() {
+				decision, err := checker.Check(context.Background(), addr1)
+				So(err, ShouldBeNil)
+				So(decision.Eligible(), ShouldBeTrue)
+			})
+
+			Convey("Then a request fitting in the remaining budget should be eligible", func() {
+				decision, err := checker.CheckRequest(context.Background(), addr1, amount)
+				So(err, ShouldBeNil)
+				So(decision.Eligible(), ShouldBeTrue)
+			})
+
+			Convey("Then a request exceeding the remaining budget should not be eligible", func() {
+				decision, err := checker.CheckRequest(context.Background(), addr1, amount.Add(amount...))
+				So(err, ShouldBeNil)
+				So(decision.Reason, ShouldEqual, ReasonBudgetExhausted)
+			})
+		})
+	})
+}
@@ -1,26 +1,74 @@
 package faucet
 
 import (
+	"errors"
+	"okp4/cosmos-faucet/pkg/actor/event"
 	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/queue"
+	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
 	"github.com/asynkron/protoactor-go/router"
+	"github.com/asynkron/protoactor-go/scheduler"
 	"github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+// refreshTimeout is the maximum duration to wait for the node when refreshing the faucet balances.
+const refreshTimeout = 5 * time.Second
+
+const (
+	// defaultMaxAttempts is the default number of failed transactions after which a queued fund request is dropped.
+	defaultMaxAttempts = 3
+	// defaultMaxAge is the default age after which a queued fund request is no longer replayed.
+	defaultMaxAge = 24 * time.Hour
+)
+
+// tick is the message the faucet sends to itself to trigger a transaction at the end of each batch window.
+type tick struct{}
+
+// refresh is the message the faucet sends to itself to refresh its balances and the node connectivity.
+type refresh struct{}
+
 type Faucet struct {
-	address        types.AccAddress
-	amount         types.Coins
-	txHandlerProps *actor.Props
-	txHandler      *actor.PID
-	msgs           []types.Msg
-	txSubscribers  []*actor.PID
+	address           types.AccAddress
+	amount            types.Coins
+	txHandlerProps    *actor.Props
+	txHandler         *actor.PID
+	cosmosClientProps *actor.Props
+	cosmosClient      *actor.PID
+	store             ledger.Store
+	queue             queue.Queue
+	maxAttempts       int
+	maxAge            time.Duration
+	batchWindow       time.Duration
+	trigger           func() *message.TriggerTx
+	refreshInterval   time.Duration
+	cancels           []scheduler.CancelFunc
+	msgs              []types.Msg
+	requestIDs        []string
+	txSubscribers     []*actor.PID
+	subscriberOf      map[string]*actor.PID
+	inflight          map[string]*actor.PID
+	paused            bool
+	nextTrigger       time.Time
+	lastTxHash        string
+	lastTxHeight      int64
+	nodeReachable     bool
+	nodeCheckedAt     time.Time
+	balances          types.Coins
+	eventStream       *eventstream.EventStream
 }
 
 func NewFaucet(opts ...Option) *Faucet {
-	faucet := &Faucet{}
+	faucet := &Faucet{
+		maxAttempts: defaultMaxAttempts,
+		maxAge:      defaultMaxAge,
+	}
 	for _, opt := range opts {
 		opt(faucet)
 	}
@@ -48,35 +96,367 @@ func WithTxHandlerProps(props *actor.Props) Option {
 	}
 }
 
+// WithStore sets the ledger in which the fund requests and their outcome are recorded.
+func WithStore(store ledger.Store) Option {
+	return func(faucet *Faucet) {
+		faucet.store = store
+	}
+}
+
+// WithQueue sets the write-ahead queue in which the fund requests are kept until successfully submitted, the pending
+// ones being replayed when the faucet starts.
+func WithQueue(q queue.Queue) Option {
+	return func(faucet *Faucet) {
+		faucet.queue = q
+	}
+}
+
+// WithReplayLimits bounds the retries of the queued fund requests: a request is dropped from the queue once included
+// in the given number of failed transactions, and is no longer replayed once older than the given age, each limit
+// being disabled if 0.
+func WithReplayLimits(maxAttempts int, maxAge time.Duration) Option {
+	return func(faucet *Faucet) {
+		faucet.maxAttempts = maxAttempts
+		faucet.maxAge = maxAge
+	}
+}
+
+// WithBatchWindow makes the faucet trigger a transaction at the end of each window, the trigger function providing the
+// transaction parameters.
+func WithBatchWindow(window time.Duration, trigger func() *message.TriggerTx) Option {
+	return func(faucet *Faucet) {
+		faucet.batchWindow = window
+		faucet.trigger = trigger
+	}
+}
+
+// WithCosmosClientProps sets the client used to check the node connectivity and the faucet balances every interval.
+func WithCosmosClientProps(props *actor.Props, interval time.Duration) Option {
+	return func(faucet *Faucet) {
+		faucet.cosmosClientProps = props
+		faucet.refreshInterval = interval
+	}
+}
+
+// WithEventStream sets the event stream on which the fund requests lifecycle events are published.
+func WithEventStream(stream *eventstream.EventStream) Option {
+	return func(faucet *Faucet) {
+		faucet.eventStream = stream
+	}
+}
+
+// nolint: funlen,gocyclo,cyclop
 func (faucet *Faucet) Receive(ctx actor.Context) {
 	switch msg := ctx.Message().(type) {
 	case *actor.Started:
 		faucet.txHandler = ctx.Spawn(faucet.txHandlerProps)
+		if faucet.cosmosClientProps != nil {
+			faucet.cosmosClient = ctx.Spawn(faucet.cosmosClientProps)
+		}
+		faucet.replay()
+		faucet.schedule(ctx)
+
+	case *actor.Stopping:
+		for _, cancel := range faucet.cancels {
+			cancel()
+		}
 
 	case *message.RequestFunds:
-		faucet.msgs = append(faucet.msgs, faucet.MakeSendMsg(msg.Address))
+		id := msg.ID
+		if id == "" {
+			id = uuid.NewString()
+		}
+
+		amount := msg.Amount
+		if amount.Empty() {
+			amount = faucet.amount
+		}
+
+		now := faucet.createdAt(id)
+		faucet.enqueue(queue.Entry{
+			ID:          id,
+			Address:     msg.Address,
+			Amount:      amount,
+			RequesterIP: msg.RequesterIP,
+			Captcha:     msg.Captcha,
+			CreatedAt:   now,
+		})
+		faucet.msgs = append(faucet.msgs, banktypes.NewMsgSend(faucet.address, msg.Address, amount))
+		faucet.requestIDs = append(faucet.requestIDs, id)
 		if msg.TxSubscriber != nil {
 			faucet.txSubscribers = append(faucet.txSubscribers, msg.TxSubscriber)
+			if faucet.subscriberOf == nil {
+				faucet.subscriberOf = map[string]*actor.PID{}
+			}
+			faucet.subscriberOf[id] = msg.TxSubscriber
 		}
-		log.Info().Str("address", msg.Address.String()).Msg("✍️  Register fund request")
+		faucet.record(ledger.Record{
+			ID:              id,
+			Address:         msg.Address.String(),
+			Amount:          amount,
+			RequesterIP:     msg.RequesterIP,
+			RequesterSubnet: msg.RequesterSubnet,
+			Captcha:         msg.Captcha,
+			APIKey:          msg.APIKey,
+			Identity:        msg.Identity,
+			Status:          ledger.StatusQueued,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+		faucet.publish(&event.RequestAccepted{
+			RequestID: id,
+			Address:   msg.Address.String(),
+			Amount:    amount,
+			Time:      now,
+		})
+		log.Info().Str("address", msg.Address.String()).Str("requestID", id).Msg("✍️  Register fund request")
 
 	case *message.TriggerTx:
-		if len(faucet.msgs) == 0 {
-			log.Info().Msg("😥 Ignore transaction trigger, no message to submit")
-			break
+		faucet.triggerTx(ctx, msg)
+
+	case *tick:
+		faucet.nextTrigger = time.Now().Add(faucet.batchWindow)
+		faucet.triggerTx(ctx, faucet.trigger())
+
+	case *refresh:
+		faucet.refresh(ctx)
+
+	case *message.Pause:
+		if !faucet.paused {
+			faucet.paused = true
+			faucet.publish(&event.FaucetPaused{Time: time.Now()})
+		}
+		log.Info().Msg("⏸️  Faucet paused")
+		ctx.Respond(faucet.status())
+
+	case *message.Resume:
+		if faucet.paused {
+			faucet.paused = false
+			faucet.publish(&event.FaucetResumed{Time: time.Now()})
+		}
+		log.Info().Msg("▶️  Faucet resumed")
+		ctx.Respond(faucet.status())
+
+	case *message.SetAmount:
+		faucet.amount = msg.Amount
+		log.Info().Str("amount", msg.Amount.String()).Msg("💰 Faucet amount changed")
+		ctx.Respond(faucet.status())
+
+	case *message.Flush:
+		if faucet.trigger != nil {
+			faucet.triggerTx(ctx, faucet.trigger())
+		}
+		ctx.Respond(faucet.status())
+
+	case *message.RemoveRequest:
+		ctx.Respond(&message.RemoveRequestResponse{Removed: faucet.remove(ctx, msg.ID)})
+
+	case *message.GetStatus:
+		ctx.Respond(faucet.status())
+
+	case *message.BroadcastTxResponse:
+		// The subscribers received the response through the broadcast group.
+		for _, id := range msg.RequestIDs {
+			delete(faucet.inflight, id)
+		}
+		faucet.update(msg.RequestIDs, func(record *ledger.Record) {
+			record.Status = ledger.StatusSubmitted
+			if msg.TxResponse.Code != 0 {
+				record.Status = ledger.StatusFailed
+				record.Error = msg.TxResponse.RawLog
+			}
+			record.TxHash = msg.TxResponse.TxHash
+			record.Code = msg.TxResponse.Code
+			record.Height = msg.TxResponse.Height
+		})
+		if msg.TxResponse.Code == 0 {
+			faucet.lastTxHash = msg.TxResponse.TxHash
+			faucet.dequeue(msg.RequestIDs)
+		} else {
+			faucet.countAttempt(msg.RequestIDs)
 		}
 
-		log.Info().Time("deadline", msg.Deadline).Msg("🔥 Trigger new transaction")
-		ctx.Send(faucet.txHandler, &message.MakeTx{
-			Deadline:     msg.Deadline,
-			TxSubscriber: ctx.Spawn(router.NewBroadcastGroup(faucet.txSubscribers...)),
-			Msgs:         faucet.msgs,
-			Memo:         msg.Memo,
-			GasLimit:     msg.GasLimit,
-			FeeAmount:    msg.FeeAmount,
+	case *message.TxConfirmed:
+		faucet.lastTxHeight = msg.TxResponse.Height
+		faucet.update(msg.RequestIDs, func(record *ledger.Record) {
+			record.Status = ledger.StatusConfirmed
+			if msg.TxResponse.Code != 0 {
+				record.Status = ledger.StatusFailed
+				record.Error = msg.TxResponse.RawLog
+			}
+			record.Code = msg.TxResponse.Code
+			record.Height = msg.TxResponse.Height
 		})
-		faucet.msgs = faucet.msgs[:0]
-		faucet.txSubscribers = faucet.txSubscribers[:0]
+
+	case *message.TxFailed:
+		faucet.update(msg.RequestIDs, func(record *ledger.Record) {
+			record.Status = ledger.StatusFailed
+			record.Error = msg.Error.Error()
+		})
+		faucet.countAttempt(msg.RequestIDs)
+		faucet.notifyFailure(ctx, msg)
+
+	case *message.GetRequest:
+		resp := &message.GetRequestResponse{}
+		if faucet.store != nil {
+			if record, err := faucet.store.Get(msg.ID); err == nil {
+				resp.Record = record
+			}
+		}
+		ctx.Respond(resp)
+	}
+}
+
+func (faucet *Faucet) triggerTx(ctx actor.Context, msg *message.TriggerTx) {
+	if faucet.paused {
+		log.Info().Msg("😥 Ignore transaction trigger, faucet is paused")
+		return
+	}
+	if len(faucet.msgs) == 0 {
+		log.Info().Msg("😥 Ignore transaction trigger, no message to submit")
+		return
+	}
+
+	log.Info().Time("deadline", msg.Deadline).Msg("🔥 Trigger new transaction")
+	faucet.publish(&event.BatchTriggered{RequestIDs: faucet.requestIDs, Time: time.Now()})
+	subscribers := append([]*actor.PID{ctx.Self()}, faucet.txSubscribers...)
+	ctx.Send(faucet.txHandler, &message.MakeTx{
+		Deadline:     msg.Deadline,
+		TxSubscriber: ctx.Spawn(router.NewBroadcastGroup(subscribers...)),
+		Msgs:         faucet.msgs,
+		RequestIDs:   faucet.requestIDs,
+		Memo:         msg.Memo,
+		GasLimit:     msg.GasLimit,
+		FeeAmount:    msg.FeeAmount,
+	})
+	for id, subscriber := range faucet.subscriberOf {
+		if faucet.inflight == nil {
+			faucet.inflight = map[string]*actor.PID{}
+		}
+		faucet.inflight[id] = subscriber
+	}
+	faucet.msgs = faucet.msgs[:0]
+	faucet.requestIDs = nil
+	faucet.txSubscribers = faucet.txSubscribers[:0]
+	faucet.subscriberOf = nil
+}
+
+// notifyFailure forwards the failure of a transaction to the subscribers of the fund requests it includes, which are
+// waiting for its response.
+func (faucet *Faucet) notifyFailure(ctx actor.Context, failure *message.TxFailed) {
+	requestIDs := map[*actor.PID][]string{}
+	var subscribers []*actor.PID
+	for _, id := range failure.RequestIDs {
+		subscriber, ok := faucet.inflight[id]
+		if !ok {
+			continue
+		}
+		delete(faucet.inflight, id)
+		if _, ok := requestIDs[subscriber]; !ok {
+			subscribers = append(subscribers, subscriber)
+		}
+		requestIDs[subscriber] = append(requestIDs[subscriber], id)
+	}
+
+	for _, subscriber := range subscribers {
+		ctx.Send(subscriber, &message.TxFailed{Error: failure.Error, RequestIDs: requestIDs[subscriber]})
+	}
+}
+
+func (faucet *Faucet) status() *message.GetStatusResponse {
+	return &message.GetStatusResponse{
+		Paused:        faucet.paused,
+		QueueLength:   len(faucet.msgs),
+		NextTrigger:   faucet.nextTrigger,
+		LastTxHash:    faucet.lastTxHash,
+		LastTxHeight:  faucet.lastTxHeight,
+		NodeReachable: faucet.nodeReachable,
+		NodeCheckedAt: faucet.nodeCheckedAt,
+		Balances:      faucet.balances,
+		Amount:        faucet.amount,
+	}
+}
+
+// remove withdraws the given fund request from the pending ones, returning false if not pending.
+func (faucet *Faucet) remove(ctx actor.Context, id string) bool {
+	index := -1
+	for i, requestID := range faucet.requestIDs {
+		if requestID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false
+	}
+
+	faucet.msgs = append(faucet.msgs[:index], faucet.msgs[index+1:]...)
+	faucet.requestIDs = append(faucet.requestIDs[:index], faucet.requestIDs[index+1:]...)
+	err := errors.New("request removed by an administrator")
+	if subscriber, ok := faucet.subscriberOf[id]; ok {
+		for i, pid := range faucet.txSubscribers {
+			if pid == subscriber {
+				faucet.txSubscribers = append(faucet.txSubscribers[:i], faucet.txSubscribers[i+1:]...)
+				break
+			}
+		}
+		delete(faucet.subscriberOf, id)
+		ctx.Send(subscriber, &message.TxFailed{Error: err, RequestIDs: []string{id}})
+	}
+	faucet.dequeue([]string{id})
+	faucet.update([]string{id}, func(record *ledger.Record) {
+		record.Status = ledger.StatusFailed
+		record.Error = err.Error()
+	})
+	log.Info().Str("requestID", id).Msg("🗑️  Remove pending fund request")
+
+	return true
+}
+
+// schedule starts the periodic transaction triggers and node checks, if configured.
+func (faucet *Faucet) schedule(ctx actor.Context) {
+	if faucet.batchWindow > 0 && faucet.trigger != nil {
+		faucet.nextTrigger = time.Now().Add(faucet.batchWindow)
+		faucet.cancels = append(faucet.cancels,
+			scheduler.NewTimerScheduler(ctx.ActorSystem().Root).
+				SendRepeatedly(faucet.batchWindow, faucet.batchWindow, ctx.Self(), &tick{}))
+	}
+
+	if faucet.cosmosClient != nil {
+		ctx.Send(ctx.Self(), &refresh{})
+		if faucet.refreshInterval > 0 {
+			faucet.cancels = append(faucet.cancels,
+				scheduler.NewTimerScheduler(ctx.ActorSystem().Root).
+					SendRepeatedly(faucet.refreshInterval, faucet.refreshInterval, ctx.Self(), &refresh{}))
+		}
+	}
+}
+
+// refresh retrieves the faucet balances from the node, without blocking the processing of other messages.
+func (faucet *Faucet) refresh(ctx actor.Context) {
+	future := ctx.RequestFuture(
+		faucet.cosmosClient,
+		&message.GetBalances{Deadline: time.Now().Add(refreshTimeout), Address: faucet.address.String()},
+		refreshTimeout,
+	)
+	ctx.ReenterAfter(future, func(res interface{}, err error) {
+		faucet.nodeCheckedAt = time.Now()
+		resp, ok := res.(*message.GetBalancesResponse)
+		if err != nil || !ok {
+			log.Warn().Err(err).Msg("😥 Could not refresh faucet balances, node is unreachable")
+			faucet.nodeReachable = false
+			return
+		}
+
+		faucet.nodeReachable = true
+		faucet.balances = resp.Balances
+		faucet.publish(&event.BalancesRefreshed{Balances: resp.Balances, Time: faucet.nodeCheckedAt})
+	})
+}
+
+func (faucet *Faucet) publish(evt interface{}) {
+	if faucet.eventStream != nil {
+		faucet.eventStream.Publish(evt)
 	}
 }
 
@@ -87,3 +467,160 @@ func (faucet *Faucet) MakeSendMsg(addr types.AccAddress) types.Msg {
 		faucet.amount,
 	)
 }
+
+// replay registers the fund requests pending in the queue, e.g. not successfully submitted before the faucet stopped.
+// The records of those whose transaction failed are queued again, as they are sent anew.
+func (faucet *Faucet) replay() {
+	if faucet.queue == nil {
+		return
+	}
+
+	pending, err := faucet.queue.Pending()
+	if err != nil {
+		log.Warn().Err(err).Msg("😥 Could not retrieve pending fund requests")
+		return
+	}
+
+	var expired, replayed []string
+	for _, entry := range pending {
+		if faucet.exhausted(entry) {
+			expired = append(expired, entry.ID)
+			continue
+		}
+
+		replayed = append(replayed, entry.ID)
+		amount := entry.Amount
+		if amount.Empty() {
+			amount = faucet.amount
+		}
+		faucet.msgs = append(faucet.msgs, banktypes.NewMsgSend(faucet.address, entry.Address, amount))
+		faucet.requestIDs = append(faucet.requestIDs, entry.ID)
+	}
+	if len(replayed) > 0 {
+		faucet.update(replayed, func(record *ledger.Record) {
+			if record.Status == ledger.StatusFailed {
+				record.Status = ledger.StatusQueued
+				record.Error = ""
+			}
+		})
+		log.Info().Int("count", len(replayed)).Msg("🔁 Replay pending fund requests")
+	}
+	if len(expired) > 0 {
+		faucet.dequeue(expired)
+		faucet.update(expired, func(record *ledger.Record) {
+			if record.Status != ledger.StatusFailed {
+				record.Status = ledger.StatusFailed
+				record.Error = "request expired before being submitted"
+			}
+		})
+		log.Info().Int("count", len(expired)).Msg("🗑️  Drop expired fund requests from queue")
+	}
+}
+
+// exhausted tells whether the given queued fund request has reached the replay limits.
+func (faucet *Faucet) exhausted(entry queue.Entry) bool {
+	if faucet.maxAttempts > 0 && entry.Attempts >= faucet.maxAttempts {
+		return true
+	}
+
+	return faucet.maxAge > 0 && time.Since(entry.CreatedAt) > faucet.maxAge
+}
+
+// countAttempt records a failed transaction for the queued fund requests with the given IDs, dropping from the queue
+// the ones having reached the maximum number of attempts.
+func (faucet *Faucet) countAttempt(ids []string) {
+	if faucet.queue == nil {
+		return
+	}
+
+	pending, err := faucet.queue.Pending()
+	if err != nil {
+		log.Warn().Err(err).Msg("😥 Could not retrieve pending fund requests")
+		return
+	}
+
+	failed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		failed[id] = true
+	}
+	var dropped []string
+	for _, entry := range pending {
+		if !failed[entry.ID] {
+			continue
+		}
+
+		entry.Attempts++
+		if faucet.maxAttempts > 0 && entry.Attempts >= faucet.maxAttempts {
+			dropped = append(dropped, entry.ID)
+			continue
+		}
+		if err := faucet.queue.Update(entry); err != nil {
+			log.Warn().Err(err).Str("requestID", entry.ID).Msg("😥 Could not update fund request in queue")
+		}
+	}
+	if len(dropped) > 0 {
+		faucet.dequeue(dropped)
+		log.Info().Strs("requestIDs", dropped).Msg("🗑️  Drop fund requests from queue after too many attempts")
+	}
+}
+
+func (faucet *Faucet) enqueue(entry queue.Entry) {
+	if faucet.queue == nil {
+		return
+	}
+
+	if err := faucet.queue.Push(entry); err != nil {
+		log.Warn().Err(err).Str("requestID", entry.ID).Msg("😥 Could not persist fund request in queue")
+	}
+}
+
+func (faucet *Faucet) dequeue(ids []string) {
+	if faucet.queue == nil {
+		return
+	}
+
+	if err := faucet.queue.Done(ids...); err != nil {
+		log.Warn().Err(err).Strs("requestIDs", ids).Msg("😥 Could not remove submitted fund requests from queue")
+	}
+}
+
+func (faucet *Faucet) record(record ledger.Record) {
+	if faucet.store == nil {
+		return
+	}
+
+	if err := faucet.store.Put(record); err != nil {
+		log.Warn().Err(err).Str("requestID", record.ID).Msg("😥 Could not record fund request")
+	}
+}
+
+// createdAt returns the creation time of the fund request with the given ID, which is the one of its record if the
+// guards reserved it in the ledger, so the record keeps its place once updated.
+func (faucet *Faucet) createdAt(id string) time.Time {
+	if faucet.store != nil {
+		if record, err := faucet.store.Get(id); err == nil {
+			return record.CreatedAt
+		}
+	}
+
+	return time.Now()
+}
+
+// update applies the given change to the records of the fund requests with the given IDs.
+func (faucet *Faucet) update(ids []string, change func(record *ledger.Record)) {
+	if faucet.store == nil {
+		return
+	}
+
+	for _, id := range ids {
+		record, err := faucet.store.Get(id)
+		if err != nil {
+			log.Warn().Err(err).Str("requestID", id).Msg("😥 Could not retrieve fund request record")
+			continue
+		}
+
+		change(record)
+		record.UpdatedAt = time.Now()
+		faucet.record(*record)
+	}
+}
@@ -1,12 +1,18 @@
 package faucet
 
 import (
+	"errors"
+	"okp4/cosmos-faucet/pkg/actor/event"
 	"okp4/cosmos-faucet/pkg/actor/message"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/queue"
 	"okp4/cosmos-faucet/test/mock"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/asynkron/protoactor-go/eventstream"
 	"github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	. "github.com/smartystreets/goconvey/convey"
@@ -21,10 +27,12 @@ var (
 
 func TestOptions(t *testing.T) {
 	Convey("Given a set of options", t, func() {
+		store := ledger.NewMemoryStore()
 		opts := []Option{
 			WithAddress(fromAddr),
 			WithAmount(amount),
 			WithTxHandlerProps(&actor.Props{}),
+			WithStore(store),
 		}
 
 		Convey("When creating the faucet with the options", func() {
@@ -35,7 +43,9 @@ func TestOptions(t *testing.T) {
 				So(faucet.amount, ShouldResemble, amount)
 				So(faucet.txHandlerProps, ShouldResemble, &actor.Props{})
 				So(faucet.txHandler, ShouldBeNil)
+				So(faucet.store, ShouldEqual, store)
 				So(faucet.msgs, ShouldBeNil)
+				So(faucet.requestIDs, ShouldBeNil)
 				So(faucet.txSubscribers, ShouldBeNil)
 			})
 		})
@@ -137,6 +147,7 @@ func TestTriggerTxWithMsgs(t *testing.T) {
 		}
 		faucet := &Faucet{
 			msgs:          txMsgs,
+			requestIDs:    []string{"1", "2"},
 			txSubscribers: []*actor.PID{{}, {}},
 			txHandler:     &actor.PID{Id: "txHandler"},
 		}
@@ -151,6 +162,7 @@ func TestTriggerTxWithMsgs(t *testing.T) {
 			}
 			mockedContext := &mock.ActorContext{}
 			mockedContext.On("Message").Return(&triggerMsg)
+			mockedContext.On("Self").Return(&actor.PID{Id: "faucet"})
 			mockedContext.On("Spawn", Anything).Return(&actor.PID{Id: "subscriber"})
 			mockedContext.On("Send", Anything, Anything).Run(func(args Arguments) {
 				messageSent = args.Get(1)
@@ -162,8 +174,10 @@ func TestTriggerTxWithMsgs(t *testing.T) {
 				mockedContext.AssertCalled(t, "Spawn", Anything)
 				mockedContext.AssertCalled(t, "Send", &actor.PID{Id: "txHandler"}, Anything)
 				So(len(faucet.msgs), ShouldEqual, 0)
+				So(len(faucet.requestIDs), ShouldEqual, 0)
 				So(len(faucet.txSubscribers), ShouldEqual, 0)
 				So(messageSent, ShouldHaveSameTypeAs, &message.MakeTx{})
+				So(messageSent.(*message.MakeTx).RequestIDs, ShouldResemble, []string{"1", "2"})
 				So(messageSent.(*message.MakeTx).Deadline, ShouldResemble, triggerMsg.Deadline)
 				So(messageSent.(*message.MakeTx).TxSubscriber.Id, ShouldEqual, "subscriber")
 				So(messageSent.(*message.MakeTx).Msgs, ShouldResemble, txMsgs)
@@ -174,3 +188,683 @@ func TestTriggerTxWithMsgs(t *testing.T) {
 		})
 	})
 }
+
+func TestTxFailure(t *testing.T) {
+	Convey("Given a faucet actor whose transaction including requests with subscribers has been triggered", t, func() {
+		faucet := NewFaucet(WithAddress(fromAddr), WithAmount(amount))
+		faucet.txHandler = &actor.PID{Id: "txHandler"}
+		first, second := &actor.PID{Id: "first"}, &actor.PID{Id: "second"}
+		for _, req := range []*message.RequestFunds{
+			{ID: "1", Address: toAddr, TxSubscriber: first},
+			{ID: "2", Address: toAddr, TxSubscriber: second},
+			{ID: "3", Address: toAddr},
+		} {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(req)
+			faucet.Receive(mockedContext)
+		}
+		mockedContext := &mock.ActorContext{}
+		mockedContext.On("Message").Return(&message.TriggerTx{Deadline: time.Now()})
+		mockedContext.On("Self").Return(&actor.PID{Id: "faucet"})
+		mockedContext.On("Spawn", Anything).Return(&actor.PID{Id: "group"})
+		mockedContext.On("Send", Anything, Anything).Return()
+		faucet.Receive(mockedContext)
+
+		Convey("When receiving a TxFailed message", func() {
+			failure := &message.TxFailed{Error: errors.New("deadline exceeded"), RequestIDs: []string{"1", "2", "3"}}
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(failure)
+			mockedContext.On("Send", Anything, Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then the failure should be forwarded to the subscribers of the requests", func() {
+				mockedContext.AssertNumberOfCalls(t, "Send", 2)
+				for id, subscriber := range map[string]*actor.PID{"1": first, "2": second} {
+					id := id
+					mockedContext.AssertCalled(t, "Send", subscriber, MatchedBy(func(msg *message.TxFailed) bool {
+						return msg.Error == failure.Error && len(msg.RequestIDs) == 1 && msg.RequestIDs[0] == id
+					}))
+				}
+			})
+		})
+
+		Convey("When receiving the transaction response", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.BroadcastTxResponse{
+				TxResponse: &types.TxResponse{TxHash: "hash"},
+				RequestIDs: []string{"1", "2", "3"},
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("And a later failure of the transaction confirmation", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.TxFailed{
+					Error:      errors.New("not included in block"),
+					RequestIDs: []string{"1", "2", "3"},
+				})
+				faucet.Receive(mockedContext)
+
+				Convey("Then the subscribers, already notified, should not be sent the failure", func() {
+					mockedContext.AssertNotCalled(t, "Send", Anything, Anything)
+				})
+			})
+		})
+	})
+}
+
+func TestSubscribeTx(t *testing.T) {
+	Convey("Given a transaction subscriber", t, func() {
+		root := actor.NewActorSystem().Root
+		pid, done := SubscribeTx(root)
+
+		outcome := func() error {
+			select {
+			case err := <-done:
+				return err
+			case <-time.After(time.Second):
+				return errors.New("no outcome")
+			}
+		}
+
+		Convey("When the transaction fails", func() {
+			failure := errors.New("deadline exceeded")
+			root.Send(pid, &message.TxFailed{Error: failure, RequestIDs: []string{"1"}})
+
+			Convey("Then the failure should be received", func() {
+				So(outcome(), ShouldEqual, failure)
+			})
+		})
+
+		Convey("When the transaction is broadcast", func() {
+			root.Send(pid, &message.BroadcastTxResponse{TxResponse: &types.TxResponse{TxHash: "hash"}})
+
+			Convey("Then its success should be received", func() {
+				So(outcome(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the transaction is broadcast with a non 0 code", func() {
+			root.Send(pid, &message.BroadcastTxResponse{TxResponse: &types.TxResponse{Code: 5, RawLog: "insufficient funds"}})
+
+			Convey("Then its failure should be received", func() {
+				err := outcome()
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "insufficient funds")
+			})
+		})
+	})
+}
+
+func TestRequestFundsRecording(t *testing.T) {
+	Convey("Given a faucet actor with a ledger", t, func() {
+		store := ledger.NewMemoryStore()
+		faucet := NewFaucet(WithAddress(fromAddr), WithAmount(amount), WithStore(store))
+
+		Convey("When receiving a RequestFunds message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{
+				ID:              "request",
+				Address:         toAddr,
+				RequesterIP:     "127.0.0.1",
+				RequesterSubnet: "127.0.0.0/24",
+				Captcha:         "verified",
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should be recorded", func() {
+				So(faucet.requestIDs, ShouldResemble, []string{"request"})
+
+				record, err := store.Get("request")
+				So(err, ShouldBeNil)
+				So(record.Address, ShouldEqual, toAddr.String())
+				So(record.Amount, ShouldResemble, amount)
+				So(record.RequesterIP, ShouldEqual, "127.0.0.1")
+				So(record.RequesterSubnet, ShouldEqual, "127.0.0.0/24")
+				So(record.Captcha, ShouldEqual, "verified")
+				So(record.Status, ShouldEqual, ledger.StatusQueued)
+				So(record.TxHash, ShouldBeEmpty)
+				So(record.CreatedAt, ShouldNotBeZeroValue)
+			})
+
+			Convey("And receiving the response of the transaction including it", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.BroadcastTxResponse{
+					TxResponse: &types.TxResponse{TxHash: "hash", Code: 5, Height: 42},
+					RequestIDs: []string{"request"},
+				})
+				faucet.Receive(mockedContext)
+
+				Convey("Then the record should contain the transaction outcome", func() {
+					record, err := store.Get("request")
+					So(err, ShouldBeNil)
+					So(record.Status, ShouldEqual, ledger.StatusFailed)
+					So(record.TxHash, ShouldEqual, "hash")
+					So(record.Code, ShouldEqual, 5)
+					So(record.Height, ShouldEqual, 42)
+				})
+			})
+		})
+
+		Convey("When receiving a RequestFunds message with a custom amount", func() {
+			custom := types.NewCoins(types.NewInt64Coin("uknow", 42))
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{
+				ID:      "request",
+				Address: toAddr,
+				Amount:  custom,
+				APIKey:  "ci",
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the custom amount should be sent and recorded", func() {
+				So(faucet.msgs, ShouldResemble, []types.Msg{banktypes.NewMsgSend(fromAddr, toAddr, custom)})
+
+				record, err := store.Get("request")
+				So(err, ShouldBeNil)
+				So(record.Amount, ShouldResemble, custom)
+				So(record.APIKey, ShouldEqual, "ci")
+			})
+		})
+
+		Convey("When receiving a RequestFunds message without ID", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{Address: toAddr})
+			faucet.Receive(mockedContext)
+
+			Convey("Then an ID should be generated", func() {
+				So(len(faucet.requestIDs), ShouldEqual, 1)
+				So(faucet.requestIDs[0], ShouldNotBeEmpty)
+
+				_, err := store.Get(faucet.requestIDs[0])
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestRequestTracking(t *testing.T) {
+	Convey("Given a faucet actor with a submitted request recorded", t, func() {
+		store := ledger.NewMemoryStore()
+		So(store.Put(ledger.Record{ID: "request", Address: toAddr.String(), Status: ledger.StatusSubmitted}), ShouldBeNil)
+		faucet := NewFaucet(WithAddress(fromAddr), WithAmount(amount), WithStore(store))
+
+		Convey("When receiving a TxConfirmed message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.TxConfirmed{
+				TxResponse: &types.TxResponse{TxHash: "hash", Height: 42},
+				RequestIDs: []string{"request"},
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should be confirmed", func() {
+				record, err := store.Get("request")
+				So(err, ShouldBeNil)
+				So(record.Status, ShouldEqual, ledger.StatusConfirmed)
+				So(record.Height, ShouldEqual, 42)
+			})
+		})
+
+		Convey("When receiving a TxFailed message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.TxFailed{
+				Error:      errors.New("not found"),
+				RequestIDs: []string{"request"},
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should be failed with the error", func() {
+				record, err := store.Get("request")
+				So(err, ShouldBeNil)
+				So(record.Status, ShouldEqual, ledger.StatusFailed)
+				So(record.Error, ShouldEqual, "not found")
+			})
+		})
+
+		Convey("When receiving a GetRequest message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.GetRequest{ID: "request"})
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then it should respond with the request record", func() {
+				mockedContext.AssertCalled(t, "Respond", MatchedBy(func(resp *message.GetRequestResponse) bool {
+					return resp.Record != nil && resp.Record.ID == "request"
+				}))
+			})
+		})
+
+		Convey("When receiving a GetRequest message for an unknown request", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.GetRequest{ID: "unknown"})
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then it should respond without record", func() {
+				mockedContext.AssertCalled(t, "Respond", &message.GetRequestResponse{})
+			})
+		})
+	})
+}
+
+func TestStatus(t *testing.T) {
+	Convey("Given a faucet actor with a pending request", t, func() {
+		faucet := NewFaucet(WithAddress(fromAddr), WithAmount(amount))
+		faucet.msgs = []types.Msg{faucet.MakeSendMsg(toAddr)}
+		faucet.requestIDs = []string{"request"}
+
+		Convey("When receiving a Pause message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.Pause{})
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then it should respond with the paused status", func() {
+				mockedContext.AssertCalled(t, "Respond", &message.GetStatusResponse{Paused: true, QueueLength: 1, Amount: amount})
+			})
+
+			Convey("And receiving a TriggerTx message", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.TriggerTx{})
+				faucet.Receive(mockedContext)
+
+				Convey("Then no transaction should be made", func() {
+					mockedContext.AssertNotCalled(t, "Send", Anything, Anything)
+					So(len(faucet.msgs), ShouldEqual, 1)
+				})
+			})
+
+			Convey("And receiving a GetStatus message", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.GetStatus{})
+				mockedContext.On("Respond", Anything).Return()
+				faucet.Receive(mockedContext)
+
+				Convey("Then it should respond the faucet is paused", func() {
+					mockedContext.AssertCalled(t, "Respond", &message.GetStatusResponse{Paused: true, QueueLength: 1, Amount: amount})
+				})
+			})
+
+			Convey("And receiving a Resume message", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.Resume{})
+				mockedContext.On("Respond", Anything).Return()
+				faucet.Receive(mockedContext)
+
+				Convey("Then the faucet should not be paused", func() {
+					So(faucet.paused, ShouldBeFalse)
+				})
+			})
+		})
+
+		Convey("When receiving the responses of a transaction", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.BroadcastTxResponse{
+				TxResponse: &types.TxResponse{TxHash: "hash"},
+				RequestIDs: []string{"request"},
+			}).Once()
+			mockedContext.On("Message").Return(&message.TxConfirmed{
+				TxResponse: &types.TxResponse{TxHash: "hash", Height: 42},
+				RequestIDs: []string{"request"},
+			}).Once()
+			mockedContext.On("Message").Return(&message.GetStatus{})
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+			faucet.Receive(mockedContext)
+			faucet.Receive(mockedContext)
+
+			Convey("Then the status should contain the last transaction", func() {
+				mockedContext.AssertCalled(t, "Respond", &message.GetStatusResponse{
+					QueueLength:  1,
+					LastTxHash:   "hash",
+					LastTxHeight: 42,
+					Amount:       amount,
+				})
+			})
+		})
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	Convey("Given a faucet actor with a cosmos client", t, func() {
+		faucet := NewFaucet(WithAddress(fromAddr), WithCosmosClientProps(&actor.Props{}, 0))
+		faucet.cosmosClient = &actor.PID{Id: "client"}
+
+		Convey("When receiving a refresh message", func() {
+			var continuation func(interface{}, error)
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&refresh{})
+			mockedContext.On("RequestFuture", faucet.cosmosClient, AnythingOfType("*message.GetBalances"), Anything).
+				Return(&actor.Future{})
+			mockedContext.On("ReenterAfter", Anything, Anything).
+				Run(func(args Arguments) {
+					continuation = args.Get(1).(func(interface{}, error))
+				}).
+				Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then it should request the faucet balances", func() {
+				mockedContext.AssertCalled(t, "RequestFuture", faucet.cosmosClient, MatchedBy(func(msg *message.GetBalances) bool {
+					return msg.Address == fromAddr.String()
+				}), Anything)
+				So(continuation, ShouldNotBeNil)
+			})
+
+			Convey("And the node responding", func() {
+				continuation(&message.GetBalancesResponse{Balances: amount}, nil)
+
+				Convey("Then the node should be reachable with the balances updated", func() {
+					So(faucet.nodeReachable, ShouldBeTrue)
+					So(faucet.balances, ShouldResemble, amount)
+					So(faucet.nodeCheckedAt, ShouldNotBeZeroValue)
+				})
+
+				Convey("And the node failing to respond", func() {
+					continuation(nil, errors.New("timeout"))
+
+					Convey("Then the node should be unreachable", func() {
+						So(faucet.nodeReachable, ShouldBeFalse)
+					})
+				})
+			})
+		})
+	})
+}
+
+func TestEvents(t *testing.T) {
+	Convey("Given a faucet actor with an event stream", t, func() {
+		stream := eventstream.NewEventStream()
+		var published []interface{}
+		stream.Subscribe(func(evt interface{}) {
+			published = append(published, evt)
+		})
+		faucet := NewFaucet(WithAddress(fromAddr), WithAmount(amount), WithEventStream(stream))
+
+		Convey("When receiving a RequestFunds message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{ID: "request", Address: toAddr})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the accepted request should be published", func() {
+				So(len(published), ShouldEqual, 1)
+				So(published[0], ShouldHaveSameTypeAs, &event.RequestAccepted{})
+				So(published[0].(*event.RequestAccepted).RequestID, ShouldEqual, "request")
+				So(published[0].(*event.RequestAccepted).Address, ShouldEqual, toAddr.String())
+				So(published[0].(*event.RequestAccepted).Amount, ShouldResemble, amount)
+			})
+
+			Convey("And receiving a TriggerTx message", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.TriggerTx{})
+				mockedContext.On("Self").Return(&actor.PID{})
+				mockedContext.On("Spawn", Anything).Return(&actor.PID{})
+				mockedContext.On("Send", Anything, Anything).Return()
+				faucet.Receive(mockedContext)
+
+				Convey("Then the triggered batch should be published", func() {
+					So(len(published), ShouldEqual, 2)
+					So(published[1], ShouldHaveSameTypeAs, &event.BatchTriggered{})
+					So(published[1].(*event.BatchTriggered).RequestIDs, ShouldResemble, []string{"request"})
+				})
+			})
+		})
+
+		Convey("When receiving Pause messages then a Resume one", func() {
+			for _, msg := range []interface{}{&message.Pause{}, &message.Pause{}, &message.Resume{}} {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(msg)
+				mockedContext.On("Respond", Anything).Return()
+				faucet.Receive(mockedContext)
+			}
+
+			Convey("Then only the changes of state should be published", func() {
+				So(len(published), ShouldEqual, 2)
+				So(published[0], ShouldHaveSameTypeAs, &event.FaucetPaused{})
+				So(published[1], ShouldHaveSameTypeAs, &event.FaucetResumed{})
+			})
+		})
+	})
+}
+
+func TestQueue(t *testing.T) {
+	Convey("Given a faucet actor with a queue containing pending requests", t, func() {
+		q, err := queue.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		So(err, ShouldBeNil)
+		defer func() { _ = q.Close() }()
+		So(q.Push(queue.Entry{ID: "pending", Address: toAddr, CreatedAt: time.Now()}), ShouldBeNil)
+
+		faucet := NewFaucet(
+			WithAddress(fromAddr),
+			WithAmount(amount),
+			WithTxHandlerProps(actor.PropsFromFunc(func(c actor.Context) {})),
+			WithQueue(q),
+			WithReplayLimits(2, time.Hour),
+		)
+
+		Convey("When receiving a Started message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&actor.Started{})
+			mockedContext.On("Spawn", Anything).Return(&actor.PID{})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the pending requests should be replayed", func() {
+				So(faucet.requestIDs, ShouldResemble, []string{"pending"})
+				So(faucet.msgs, ShouldResemble, []types.Msg{banktypes.NewMsgSend(fromAddr, toAddr, amount)})
+			})
+		})
+
+		Convey("When starting with requests having reached the replay limits", func() {
+			So(q.Push(queue.Entry{ID: "old", Address: toAddr, CreatedAt: time.Now().Add(-2 * time.Hour)}), ShouldBeNil)
+			So(q.Push(queue.Entry{ID: "retried", Address: toAddr, CreatedAt: time.Now(), Attempts: 2}), ShouldBeNil)
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&actor.Started{})
+			mockedContext.On("Spawn", Anything).Return(&actor.PID{})
+			faucet.Receive(mockedContext)
+
+			Convey("Then they should be dropped instead of replayed", func() {
+				So(faucet.requestIDs, ShouldResemble, []string{"pending"})
+				pending, err := q.Pending()
+				So(err, ShouldBeNil)
+				So(len(pending), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When receiving a RequestFunds message", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{ID: "request", Address: toAddr})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should be pushed to the queue with the resolved amount", func() {
+				pending, err := q.Pending()
+				So(err, ShouldBeNil)
+				So(len(pending), ShouldEqual, 2)
+				So(pending[1].ID, ShouldEqual, "request")
+				So(pending[1].Address, ShouldResemble, []byte(toAddr))
+				So(pending[1].Amount, ShouldResemble, amount)
+			})
+		})
+
+		Convey("When receiving a failed transaction response", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.BroadcastTxResponse{
+				TxResponse: &types.TxResponse{Code: 5},
+				RequestIDs: []string{"pending"},
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should remain in the queue with its attempt counted", func() {
+				pending, err := q.Pending()
+				So(err, ShouldBeNil)
+				So(len(pending), ShouldEqual, 1)
+				So(pending[0].Attempts, ShouldEqual, 1)
+			})
+
+			Convey("And the transaction failing again", func() {
+				mockedContext := &mock.ActorContext{}
+				mockedContext.On("Message").Return(&message.TxFailed{
+					Error:      errors.New("deadline exceeded"),
+					RequestIDs: []string{"pending"},
+				})
+				faucet.Receive(mockedContext)
+
+				Convey("Then the request should be dropped from the queue", func() {
+					pending, err := q.Pending()
+					So(err, ShouldBeNil)
+					So(pending, ShouldBeEmpty)
+				})
+			})
+		})
+
+		Convey("When receiving a successful transaction response", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.BroadcastTxResponse{
+				TxResponse: &types.TxResponse{Code: 0},
+				RequestIDs: []string{"pending"},
+			})
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should be removed from the queue", func() {
+				pending, err := q.Pending()
+				So(err, ShouldBeNil)
+				So(pending, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a faucet actor with a queue and a ledger", t, func() {
+		q, err := queue.NewBoltQueue(filepath.Join(t.TempDir(), "queue.db"))
+		So(err, ShouldBeNil)
+		defer func() { _ = q.Close() }()
+		store := ledger.NewMemoryStore()
+		options := []Option{
+			WithAddress(fromAddr),
+			WithAmount(amount),
+			WithTxHandlerProps(actor.PropsFromFunc(func(c actor.Context) {})),
+			WithQueue(q),
+			WithStore(store),
+			WithReplayLimits(3, time.Hour),
+		}
+		faucet := NewFaucet(options...)
+
+		Convey("When a transaction fails before the faucet restarts", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{ID: "request", Address: toAddr})
+			faucet.Receive(mockedContext)
+
+			mockedContext = &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.TxFailed{
+				Error:      errors.New("deadline exceeded"),
+				RequestIDs: []string{"request"},
+			})
+			faucet.Receive(mockedContext)
+
+			failed, err := store.Get("request")
+			So(err, ShouldBeNil)
+			So(failed.Status, ShouldEqual, ledger.StatusFailed)
+
+			restarted := NewFaucet(options...)
+			mockedContext = &mock.ActorContext{}
+			mockedContext.On("Message").Return(&actor.Started{})
+			mockedContext.On("Spawn", Anything).Return(&actor.PID{})
+			restarted.Receive(mockedContext)
+
+			Convey("Then the request should be replayed with its record queued again", func() {
+				So(restarted.requestIDs, ShouldResemble, []string{"request"})
+				record, err := store.Get("request")
+				So(err, ShouldBeNil)
+				So(record.Status, ShouldEqual, ledger.StatusQueued)
+				So(record.Error, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestAdministration(t *testing.T) {
+	Convey("Given a faucet actor with pending requests", t, func() {
+		store := ledger.NewMemoryStore()
+		faucet := NewFaucet(
+			WithAddress(fromAddr),
+			WithAmount(amount),
+			WithStore(store),
+			WithBatchWindow(time.Minute, func() *message.TriggerTx { return &message.TriggerTx{Memo: "flush"} }),
+		)
+		faucet.txHandler = &actor.PID{Id: "txHandler"}
+		subscriber := &actor.PID{Id: "subscriber"}
+		for _, id := range []string{"first", "second"} {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RequestFunds{ID: id, Address: toAddr, TxSubscriber: subscriber})
+			faucet.Receive(mockedContext)
+		}
+
+		Convey("When receiving a SetAmount message", func() {
+			newAmount := types.NewCoins(types.NewInt64Coin("uknow", 42))
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.SetAmount{Amount: newAmount})
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then the next requests should be sent the new amount", func() {
+				mockedContext.AssertCalled(t, "Respond", MatchedBy(func(resp *message.GetStatusResponse) bool {
+					return resp.Amount.IsEqual(newAmount)
+				}))
+				So(faucet.MakeSendMsg(toAddr), ShouldResemble, banktypes.NewMsgSend(fromAddr, toAddr, newAmount))
+			})
+		})
+
+		Convey("When receiving a Flush message", func() {
+			var messageSent interface{}
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.Flush{})
+			mockedContext.On("Self").Return(&actor.PID{Id: "faucet"})
+			mockedContext.On("Spawn", Anything).Return(&actor.PID{Id: "group"})
+			mockedContext.On("Send", Anything, Anything).Run(func(args Arguments) {
+				messageSent = args.Get(1)
+			}).Return()
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then a transaction should be made right away", func() {
+				So(messageSent, ShouldHaveSameTypeAs, &message.MakeTx{})
+				So(messageSent.(*message.MakeTx).Memo, ShouldEqual, "flush")
+				So(messageSent.(*message.MakeTx).RequestIDs, ShouldResemble, []string{"first", "second"})
+				mockedContext.AssertCalled(t, "Respond", MatchedBy(func(resp *message.GetStatusResponse) bool {
+					return resp.QueueLength == 0
+				}))
+			})
+		})
+
+		Convey("When receiving a RemoveRequest message for a pending request", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RemoveRequest{ID: "first"})
+			mockedContext.On("Send", Anything, Anything).Return()
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then the request should be removed and its subscriber notified", func() {
+				mockedContext.AssertCalled(t, "Respond", &message.RemoveRequestResponse{Removed: true})
+				mockedContext.AssertCalled(t, "Send", subscriber, MatchedBy(func(msg *message.TxFailed) bool {
+					return len(msg.RequestIDs) == 1 && msg.RequestIDs[0] == "first"
+				}))
+				So(faucet.requestIDs, ShouldResemble, []string{"second"})
+				So(len(faucet.msgs), ShouldEqual, 1)
+				So(len(faucet.txSubscribers), ShouldEqual, 1)
+
+				record, err := store.Get("first")
+				So(err, ShouldBeNil)
+				So(record.Status, ShouldEqual, ledger.StatusFailed)
+				So(record.Error, ShouldNotBeEmpty)
+			})
+		})
+
+		Convey("When receiving a RemoveRequest message for an unknown request", func() {
+			mockedContext := &mock.ActorContext{}
+			mockedContext.On("Message").Return(&message.RemoveRequest{ID: "unknown"})
+			mockedContext.On("Respond", Anything).Return()
+			faucet.Receive(mockedContext)
+
+			Convey("Then nothing should be removed", func() {
+				mockedContext.AssertCalled(t, "Respond", &message.RemoveRequestResponse{})
+				So(len(faucet.requestIDs), ShouldEqual, 2)
+			})
+		})
+	})
+}
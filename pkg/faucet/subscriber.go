@@ -0,0 +1,31 @@
+package faucet
+
+import (
+	"fmt"
+	"okp4/cosmos-faucet/pkg/actor/message"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// SubscribeTx spawns a subscriber to the transaction of a fund request, to give as its TxSubscriber. The returned
+// channel receives the outcome of the transaction once known: nil once it is broadcast with a 0 code, or the error
+// it failed with.
+func SubscribeTx(spawner actor.SpawnerContext) (*actor.PID, <-chan error) {
+	done := make(chan error, 1)
+	pid := spawner.Spawn(actor.PropsFromFunc(func(c actor.Context) {
+		switch msg := c.Message().(type) {
+		case *message.BroadcastTxResponse:
+			if msg.TxResponse != nil && msg.TxResponse.Code != 0 {
+				done <- fmt.Errorf("transaction failed with code %d: %s", msg.TxResponse.Code, msg.TxResponse.RawLog)
+			} else {
+				done <- nil
+			}
+			c.Stop(c.Self())
+		case *message.TxFailed:
+			done <- msg.Error
+			c.Stop(c.Self())
+		}
+	}))
+
+	return pid, done
+}
@@ -0,0 +1,141 @@
+package grant
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var grantsBucket = []byte("grants")
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore returns a Store persisting grants in the embedded bolt database file at the given path, creating it if
+// needed.
+func NewBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(grantsBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Put(grant Grant) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, grant)
+	})
+}
+
+func (s *boltStore) Add(grant Grant) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(grantsBucket).ForEach(func(_, v []byte) error {
+			var other Grant
+			if err := json.Unmarshal(v, &other); err != nil {
+				return err
+			}
+			if other.Address == grant.Address && other.Status == StatusPending {
+				return ErrPending
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return put(tx, grant)
+	})
+}
+
+func (s *boltStore) Get(id string) (*Grant, error) {
+	var grant *Grant
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		grant, err = get(tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+func (s *boltStore) Update(id string, fn func(grant *Grant) error) (*Grant, error) {
+	var grant *Grant
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		if grant, err = get(tx, id); err != nil {
+			return err
+		}
+		if err := fn(grant); err != nil {
+			return err
+		}
+		return put(tx, *grant)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
+
+func (s *boltStore) List(status Status) ([]Grant, error) {
+	var grants []Grant
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(grantsBucket).ForEach(func(_, v []byte) error {
+			var grant Grant
+			if err := json.Unmarshal(v, &grant); err != nil {
+				return err
+			}
+			if status == "" || grant.Status == status {
+				grants = append(grants, grant)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByCreation(grants)
+
+	return grants, nil
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func put(tx *bolt.Tx, grant Grant) error {
+	bz, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(grantsBucket).Put([]byte(grant.ID), bz)
+}
+
+func get(tx *bolt.Tx, id string) (*Grant, error) {
+	bz := tx.Bucket(grantsBucket).Get([]byte(id))
+	if bz == nil {
+		return nil, ErrNotFound
+	}
+
+	grant := &Grant{}
+	if err := json.Unmarshal(bz, grant); err != nil {
+		return nil, err
+	}
+
+	return grant, nil
+}
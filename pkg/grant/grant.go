@@ -0,0 +1,126 @@
+package grant
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrNotFound is returned when no grant matches the requested identifier.
+var ErrNotFound = errors.New("grant not found")
+
+// ErrNotPending is returned when deciding on a grant already approved or rejected.
+var ErrNotPending = errors.New("grant is not pending approval")
+
+// ErrPending is returned when requesting a grant for an address which already has a grant pending approval.
+var ErrPending = errors.New("address already has a grant pending approval")
+
+// Status represents the approval status of a grant.
+type Status string
+
+const (
+	// StatusPending denotes a grant waiting to be reviewed.
+	StatusPending Status = "pending"
+	// StatusApproved denotes a grant approved, whose funds have been requested to the faucet.
+	StatusApproved Status = "approved"
+	// StatusRejected denotes a grant rejected by a reviewer.
+	StatusRejected Status = "rejected"
+)
+
+// Grant represents a request of an amount larger than the standard one, which must be approved before being sent.
+type Grant struct {
+	// ID uniquely identifies the grant.
+	ID string `json:"id"`
+
+	// Address on which the funds are requested.
+	Address string `json:"address"`
+
+	// Amount of requested funds.
+	Amount types.Coins `json:"amount"`
+
+	// Justification given by the requester.
+	Justification string `json:"justification"`
+
+	// RequesterIP is the IP address of the requester, if known.
+	RequesterIP string `json:"requesterIp,omitempty"`
+
+	// Status is the approval status of the grant.
+	Status Status `json:"status"`
+
+	// Reason given by the reviewer when rejecting the grant, if any.
+	Reason string `json:"reason,omitempty"`
+
+	// RequestID is the identifier of the fund request made once the grant is approved.
+	RequestID string `json:"requestId,omitempty"`
+
+	// CreatedAt is the time at which the grant has been requested.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// DecidedAt is the time at which the grant has been approved or rejected.
+	DecidedAt *time.Time `json:"decidedAt,omitempty"`
+}
+
+// Store is a persistent storage of the grants.
+type Store interface {
+	// Put inserts the grant, or replaces it if a grant with the same ID already exists.
+	Put(grant Grant) error
+
+	// Add atomically inserts the new grant, unless its address already has a grant pending approval in which case
+	// ErrPending is returned.
+	Add(grant Grant) error
+
+	// Get returns the grant with the given ID, or ErrNotFound.
+	Get(id string) (*Grant, error)
+
+	// Update atomically applies the given change to the grant with the given ID, returning the updated grant. Nothing
+	// is changed if fn returns an error.
+	Update(id string, fn func(grant *Grant) error) (*Grant, error)
+
+	// List returns the grants having the given status, or all of them if empty, from the oldest to the most recent.
+	List(status Status) ([]Grant, error)
+
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// sortByCreation sorts the given grants from the oldest to the most recent.
+func sortByCreation(grants []Grant) {
+	sort.Slice(grants, func(i, j int) bool {
+		if grants[i].CreatedAt.Equal(grants[j].CreatedAt) {
+			return grants[i].ID < grants[j].ID
+		}
+		return grants[i].CreatedAt.Before(grants[j].CreatedAt)
+	})
+}
+
+// Approve marks the pending grant with the given ID as approved, along with the identifier of the fund request made
+// to send its funds.
+func Approve(store Store, id, requestID string) (*Grant, error) {
+	return decide(store, id, func(grant *Grant) {
+		grant.Status = StatusApproved
+		grant.RequestID = requestID
+	})
+}
+
+// Reject marks the pending grant with the given ID as rejected for the given reason.
+func Reject(store Store, id, reason string) (*Grant, error) {
+	return decide(store, id, func(grant *Grant) {
+		grant.Status = StatusRejected
+		grant.Reason = reason
+	})
+}
+
+func decide(store Store, id string, change func(grant *Grant)) (*Grant, error) {
+	return store.Update(id, func(grant *Grant) error {
+		if grant.Status != StatusPending {
+			return ErrNotPending
+		}
+
+		now := time.Now().UTC()
+		change(grant)
+		grant.DecidedAt = &now
+		return nil
+	})
+}
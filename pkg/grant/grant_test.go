@@ -0,0 +1,178 @@
+package grant
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func() Store{
+		"memory": NewMemoryStore,
+		"bolt": func() Store {
+			store, err := NewBoltStore(filepath.Join(t.TempDir(), "grants.db"))
+			if err != nil {
+				panic(err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		Convey("Given a "+name+" store containing grants", t, func() {
+			store := newStore()
+			defer store.Close()
+
+			start := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+			for i := 0; i < 3; i++ {
+				So(store.Put(Grant{
+					ID:            fmt.Sprintf("grant-%d", i),
+					Address:       "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27",
+					Amount:        types.NewCoins(types.NewInt64Coin("uknow", 1000000000)),
+					Justification: "validator onboarding",
+					Status:        []Status{StatusPending, StatusRejected, StatusPending}[i],
+					CreatedAt:     start.Add(time.Duration(2-i) * time.Hour),
+				}), ShouldBeNil)
+			}
+
+			Convey("When getting an unknown grant", func() {
+				_, err := store.Get("unknown")
+
+				Convey("Then it should not be found", func() {
+					So(err, ShouldEqual, ErrNotFound)
+				})
+			})
+
+			Convey("When listing the pending grants", func() {
+				grants, err := store.List(StatusPending)
+
+				Convey("Then they should be returned from the oldest", func() {
+					So(err, ShouldBeNil)
+					So(len(grants), ShouldEqual, 2)
+					So(grants[0].ID, ShouldEqual, "grant-2")
+					So(grants[1].ID, ShouldEqual, "grant-0")
+				})
+			})
+
+			Convey("When listing all the grants", func() {
+				grants, err := store.List("")
+
+				Convey("Then all of them should be returned", func() {
+					So(err, ShouldBeNil)
+					So(len(grants), ShouldEqual, 3)
+				})
+			})
+
+			Convey("When updating a grant", func() {
+				updated, err := store.Update("grant-0", func(grant *Grant) error {
+					grant.Status = StatusApproved
+					grant.RequestID = "request"
+					return nil
+				})
+
+				Convey("Then the change should be stored", func() {
+					So(err, ShouldBeNil)
+					So(updated.Status, ShouldEqual, StatusApproved)
+
+					got, err := store.Get("grant-0")
+					So(err, ShouldBeNil)
+					So(got.Status, ShouldEqual, StatusApproved)
+					So(got.RequestID, ShouldEqual, "request")
+				})
+			})
+
+			Convey("When a change fails", func() {
+				_, err := store.Update("grant-0", func(grant *Grant) error {
+					grant.Status = StatusApproved
+					return ErrNotPending
+				})
+
+				Convey("Then the grant should be left untouched", func() {
+					So(errors.Is(err, ErrNotPending), ShouldBeTrue)
+
+					got, err := store.Get("grant-0")
+					So(err, ShouldBeNil)
+					So(got.Status, ShouldEqual, StatusPending)
+				})
+			})
+
+			Convey("When adding a grant for an address having a pending one", func() {
+				err := store.Add(Grant{
+					ID:      "grant-3",
+					Address: "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27",
+					Status:  StatusPending,
+				})
+
+				Convey("Then it should be refused", func() {
+					So(err, ShouldEqual, ErrPending)
+
+					_, err := store.Get("grant-3")
+					So(err, ShouldEqual, ErrNotFound)
+				})
+			})
+
+			Convey("When adding a grant for another address", func() {
+				err := store.Add(Grant{ID: "grant-3", Address: "okp41other", Status: StatusPending})
+
+				Convey("Then it should be stored", func() {
+					So(err, ShouldBeNil)
+
+					_, err := store.Get("grant-3")
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("When updating an unknown grant", func() {
+				_, err := store.Update("unknown", func(grant *Grant) error { return nil })
+
+				Convey("Then it should not be found", func() {
+					So(err, ShouldEqual, ErrNotFound)
+				})
+			})
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	Convey("Given a store with a pending grant", t, func() {
+		store := NewMemoryStore()
+		So(store.Put(Grant{ID: "grant", Status: StatusPending}), ShouldBeNil)
+
+		Convey("When approving it", func() {
+			grant, err := Approve(store, "grant", "request")
+
+			Convey("Then it should be approved along with its fund request", func() {
+				So(err, ShouldBeNil)
+				So(grant.Status, ShouldEqual, StatusApproved)
+				So(grant.RequestID, ShouldEqual, "request")
+				So(grant.DecidedAt, ShouldNotBeNil)
+			})
+
+			Convey("Then it should not be rejected anymore", func() {
+				_, err := Reject(store, "grant", "too late")
+				So(err, ShouldEqual, ErrNotPending)
+			})
+		})
+
+		Convey("When rejecting it", func() {
+			grant, err := Reject(store, "grant", "not a validator")
+
+			Convey("Then it should be rejected with the reason", func() {
+				So(err, ShouldBeNil)
+				So(grant.Status, ShouldEqual, StatusRejected)
+				So(grant.Reason, ShouldEqual, "not a validator")
+				So(grant.DecidedAt, ShouldNotBeNil)
+			})
+
+			Convey("Then it should not be approved anymore", func() {
+				_, err := Approve(store, "grant", "request")
+				So(err, ShouldEqual, ErrNotPending)
+			})
+		})
+	})
+}
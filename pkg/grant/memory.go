@@ -0,0 +1,81 @@
+package grant
+
+import "sync"
+
+type memoryStore struct {
+	mu     sync.RWMutex
+	grants map[string]Grant
+}
+
+// NewMemoryStore returns a Store keeping the grants in memory, which are lost once the process exits.
+func NewMemoryStore() Store {
+	return &memoryStore{grants: map[string]Grant{}}
+}
+
+func (s *memoryStore) Put(grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.grants[grant.ID] = grant
+	return nil
+}
+
+func (s *memoryStore) Add(grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.grants {
+		if other.Address == grant.Address && other.Status == StatusPending {
+			return ErrPending
+		}
+	}
+	s.grants[grant.ID] = grant
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (*Grant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	grant, ok := s.grants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &grant, nil
+}
+
+func (s *memoryStore) Update(id string, fn func(grant *Grant) error) (*Grant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grant, ok := s.grants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := fn(&grant); err != nil {
+		return nil, err
+	}
+	s.grants[id] = grant
+
+	return &grant, nil
+}
+
+func (s *memoryStore) List(status Status) ([]Grant, error) {
+	s.mu.RLock()
+	grants := make([]Grant, 0, len(s.grants))
+	for _, grant := range s.grants {
+		if status == "" || grant.Status == status {
+			grants = append(grants, grant)
+		}
+	}
+	s.mu.RUnlock()
+
+	sortByCreation(grants)
+
+	return grants, nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,218 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/identity"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
+)
+
+// Request is a fund request submitted to the guards, which may adjust the amount to send.
+type Request struct {
+	// ID identifies the fund request, under which it is reserved in the ledger once accepted.
+	ID string
+	// Address is the recipient of the funds.
+	Address types.AccAddress
+	// Amount is the amount to send, the faucet sending its configured amount if empty.
+	Amount types.Coins
+	// RequesterIP is the IP address of the requester, if known.
+	RequesterIP string
+	// RequesterSubnet is the /24 IPv4 or /64 IPv6 subnet of the requester IP address, if known.
+	RequesterSubnet string
+	// CaptchaToken is the captcha token given by the requester, if any.
+	CaptchaToken *string
+	// PubKey is the base64 encoded public key of the address, given to prove its ownership.
+	PubKey string
+	// Signature is the base64 encoded ADR-036 signature of the ownership challenge.
+	Signature string
+	// APIKey is the API key the requester authenticated with, if any.
+	APIKey *apikey.Key
+	// Identity is the identity of the requester at an identity provider, if logged in.
+	Identity *identity.Identity
+	// Captcha is the outcome of the captcha verification, empty if no captcha has been verified.
+	Captcha string
+}
+
+// Guard is an anti-abuse check of the fund requests.
+type Guard interface {
+	// Name identifies the guard in the configuration and in the rejections.
+	Name() string
+	// Check accepts the request, possibly after adjusting its amount, or rejects it by returning an error.
+	Check(ctx context.Context, req *Request) error
+}
+
+// Recorded is implemented by the guards whose decision depends on the fund requests recorded in the ledger.
+type Recorded interface {
+	// CheckRecords checks the request against the recorded fund requests only, which the chain does again along with
+	// the reservation of the request.
+	CheckRecords(ctx context.Context, req *Request) error
+}
+
+// Rejection is the error returned when a guard rejects a request.
+type Rejection struct {
+	// Guard is the name of the guard which rejected the request.
+	Guard string
+	// Code identifies the reason of the rejection, derived from the wrapped error if empty.
+	Code string
+	Err  error
+}
+
+// Reject returns the error of a rejection with the given code, for the guards whose errors are not known by the
+// frontends.
+func Reject(code string, err error) error {
+	return &Rejection{Code: code, Err: err}
+}
+
+func (r *Rejection) Error() string {
+	return r.Err.Error()
+}
+
+func (r *Rejection) Unwrap() error {
+	return r.Err
+}
+
+// Chain applies guards in order, a request being accepted only if all of them accept it.
+type Chain struct {
+	guards []Guard
+	// mu makes the last checks of the recorded guards and the reservation of the request a single step.
+	mu     sync.Mutex
+	store  ledger.Store
+	amount func() types.Coins
+}
+
+func NewChain(guards ...Guard) *Chain {
+	return &Chain{guards: guards}
+}
+
+// Select returns the chain of the guards with the given names, in the order of the names.
+func Select(names []string, guards ...Guard) (*Chain, error) {
+	byName := make(map[string]Guard, len(guards))
+	for _, guard := range guards {
+		byName[guard.Name()] = guard
+	}
+
+	selected := make([]Guard, 0, len(names))
+	for _, name := range names {
+		guard, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown guard %q", name)
+		}
+		selected = append(selected, guard)
+	}
+
+	return NewChain(selected...), nil
+}
+
+// Only returns the chain of the guards of this chain having one of the given names, in the order of this chain, which
+// does not reserve the requests it accepts.
+func (c *Chain) Only(names ...string) *Chain {
+	guards := make([]Guard, 0, len(names))
+	for _, guard := range c.guards {
+		for _, name := range names {
+			if guard.Name() == name {
+				guards = append(guards, guard)
+				break
+			}
+		}
+	}
+
+	return NewChain(guards...)
+}
+
+// Names returns the names of the guards of the chain, in order.
+func (c *Chain) Names() []string {
+	names := make([]string, 0, len(c.guards))
+	for _, guard := range c.guards {
+		names = append(names, guard.Name())
+	}
+	return names
+}
+
+// Reserve makes the chain record the accepted requests in the given ledger before returning, the requests not choosing
+// their amount being recorded with the given default one. The recorded guards check the request again along with its
+// reservation, so concurrent requests cannot all be accepted against the same records.
+func (c *Chain) Reserve(store ledger.Store, amount func() types.Coins) *Chain {
+	c.store = store
+	c.amount = amount
+	return c
+}
+
+// Check submits the request to each guard in turn, stopping at the first rejection which is returned as a Rejection.
+// If the chain reserves the requests, an accepted one is recorded in the ledger with its ID and its amount once
+// resolved.
+func (c *Chain) Check(ctx context.Context, req *Request) error {
+	for _, guard := range c.guards {
+		if err := guard.Check(ctx, req); err != nil {
+			return reject(guard, err)
+		}
+	}
+
+	if c.store == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if req.Amount.Empty() && c.amount != nil {
+		req.Amount = c.amount()
+	}
+	for _, guard := range c.guards {
+		recorded, ok := guard.(Recorded)
+		if !ok {
+			continue
+		}
+		if err := recorded.CheckRecords(ctx, req); err != nil {
+			return reject(guard, err)
+		}
+	}
+
+	return c.reserve(req)
+}
+
+// reserve records the accepted request in the ledger as queued, the faucet updating the record once it receives it.
+func (c *Chain) reserve(req *Request) error {
+	if req.ID == "" {
+		req.ID = uuid.NewString()
+	}
+
+	now := time.Now()
+	record := ledger.Record{
+		ID:              req.ID,
+		Address:         req.Address.String(),
+		Amount:          req.Amount,
+		RequesterIP:     req.RequesterIP,
+		RequesterSubnet: req.RequesterSubnet,
+		Captcha:         req.Captcha,
+		Status:          ledger.StatusQueued,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if req.APIKey != nil {
+		record.APIKey = req.APIKey.Name
+	}
+	if req.Identity != nil {
+		record.Identity = req.Identity.Key()
+	}
+
+	return c.store.Put(record)
+}
+
+// reject returns the error of the given guard as a Rejection.
+func reject(guard Guard, err error) error {
+	var rejection *Rejection
+	if errors.As(err, &rejection) {
+		if rejection.Guard == "" {
+			rejection.Guard = guard.Name()
+		}
+		return err
+	}
+	return &Rejection{Guard: guard.Name(), Err: err}
+}
@@ -0,0 +1,371 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/captcha"
+	"okp4/cosmos-faucet/pkg/identity"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/screening"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errRefused = errors.New("refused")
+
+// scoringResolver is a captcha resolver accepting any token with the given score.
+type scoringResolver struct {
+	score *float64
+}
+
+func (r scoringResolver) CheckRecaptcha(_ context.Context, token *string) (*captcha.Result, error) {
+	if token == nil {
+		return nil, captcha.ErrMissingToken
+	}
+	return &captcha.Result{Score: r.score}, nil
+}
+
+func (r scoringResolver) Enabled() bool {
+	return true
+}
+
+func (r scoringResolver) MinScore() float64 {
+	return 0
+}
+
+func (r scoringResolver) SetMinScore(float64) error {
+	return nil
+}
+
+func TestChain(t *testing.T) {
+	Convey("Given a chain of guards", t, func() {
+		var calls []string
+		accept := func(name string) Guard {
+			return Func(name, func(_ context.Context, _ *Request) error {
+				calls = append(calls, name)
+				return nil
+			})
+		}
+		halve := Func("halve", func(_ context.Context, req *Request) error {
+			calls = append(calls, "halve")
+			req.Amount = types.NewCoins(types.NewInt64Coin("uknow", req.Amount.AmountOf("uknow").Int64()/2))
+			return nil
+		})
+		refuse := Func("refuse", func(_ context.Context, _ *Request) error {
+			calls = append(calls, "refuse")
+			return errRefused
+		})
+		coded := Func("coded", func(_ context.Context, _ *Request) error {
+			calls = append(calls, "coded")
+			return Reject("SCREENED", errRefused)
+		})
+
+		req := &Request{Amount: types.NewCoins(types.NewInt64Coin("uknow", 100))}
+
+		Convey("When all the guards accept the request", func() {
+			err := NewChain(accept("first"), halve, accept("last")).Check(context.Background(), req)
+
+			Convey("Then it should be accepted with its adjusted amount", func() {
+				So(err, ShouldBeNil)
+				So(calls, ShouldResemble, []string{"first", "halve", "last"})
+				So(req.Amount.AmountOf("uknow").Int64(), ShouldEqual, 50)
+			})
+		})
+
+		Convey("When a guard rejects the request", func() {
+			err := NewChain(accept("first"), refuse, accept("last")).Check(context.Background(), req)
+
+			Convey("Then the next guards should not be applied", func() {
+				So(calls, ShouldResemble, []string{"first", "refuse"})
+			})
+
+			Convey("Then the rejection should tell the guard and wrap its error", func() {
+				var rejection *Rejection
+				So(errors.As(err, &rejection), ShouldBeTrue)
+				So(rejection.Guard, ShouldEqual, "refuse")
+				So(rejection.Code, ShouldBeEmpty)
+				So(errors.Is(err, errRefused), ShouldBeTrue)
+				So(err.Error(), ShouldEqual, errRefused.Error())
+			})
+		})
+
+		Convey("When a guard rejects the request with a code", func() {
+			err := NewChain(coded).Check(context.Background(), req)
+
+			Convey("Then the rejection should keep its code", func() {
+				var rejection *Rejection
+				So(errors.As(err, &rejection), ShouldBeTrue)
+				So(rejection.Guard, ShouldEqual, "coded")
+				So(rejection.Code, ShouldEqual, "SCREENED")
+			})
+		})
+
+		Convey("When selecting the guards by name", func() {
+			chain, err := Select([]string{"last", "refuse"}, accept("first"), refuse, accept("last"))
+
+			Convey("Then the chain should apply them in the given order", func() {
+				So(err, ShouldBeNil)
+				So(chain.Names(), ShouldResemble, []string{"last", "refuse"})
+			})
+		})
+
+		Convey("When keeping only some of the guards", func() {
+			chain := NewChain(accept("first"), refuse, accept("last")).Only("last", "first", "unknown")
+
+			Convey("Then the chain should apply them in its order", func() {
+				So(chain.Names(), ShouldResemble, []string{"first", "last"})
+			})
+		})
+
+		Convey("When selecting an unknown guard", func() {
+			_, err := Select([]string{"unknown"}, accept("first"))
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestReserve(t *testing.T) {
+	addr := types.AccAddress("recipient-address-01")
+	amount := types.NewCoins(types.NewInt64Coin("uknow", 100))
+
+	Convey("Given a chain reserving the accepted requests, allowing a single request per hour from an ip", t, func() {
+		store := ledger.NewMemoryStore()
+		chain := NewChain(IPLimit(store, IPLimits{PerIP: 1, Period: time.Hour})).
+			Reserve(store, func() types.Coins { return amount })
+
+		Convey("When many requests from the same ip are checked concurrently", func() {
+			var wg sync.WaitGroup
+			errs := make(chan error, 20)
+			for i := 0; i < cap(errs); i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs <- chain.Check(context.Background(), &Request{Address: addr, RequesterIP: "1.2.3.4"})
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			Convey("Then a single one should be accepted and reserved with the default amount", func() {
+				accepted := 0
+				for err := range errs {
+					if err == nil {
+						accepted++
+						continue
+					}
+					So(errors.Is(err, ErrIPLimited), ShouldBeTrue)
+				}
+				So(accepted, ShouldEqual, 1)
+
+				records, _, err := store.List(ledger.Query{First: 10})
+				So(err, ShouldBeNil)
+				So(records, ShouldHaveLength, 1)
+				So(records[0].ID, ShouldNotBeEmpty)
+				So(records[0].Status, ShouldEqual, ledger.StatusQueued)
+				So(records[0].Amount, ShouldResemble, amount)
+			})
+		})
+
+		Convey("When a request is rejected", func() {
+			So(store.Put(ledger.Record{ID: "1", RequesterIP: "1.2.3.4", CreatedAt: time.Now()}), ShouldBeNil)
+			err := chain.Check(context.Background(), &Request{ID: "2", Address: addr, RequesterIP: "1.2.3.4"})
+
+			Convey("Then it should not be reserved", func() {
+				So(err, ShouldNotBeNil)
+				_, err := store.Get("2")
+				So(err, ShouldEqual, ledger.ErrNotFound)
+			})
+		})
+	})
+}
+
+func TestGuards(t *testing.T) {
+	addr := types.AccAddress("recipient-address-01")
+
+	Convey("Given an access list guard denying an address", t, func() {
+		guard := AccessList(access.NewList(access.Lists{Deny: []string{addr.String()}}))
+
+		Convey("Then a request to this address should be rejected", func() {
+			So(errors.Is(guard.Check(context.Background(), &Request{Address: addr}), access.ErrDenied), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a captcha guard with score tiers", t, func() {
+		score := 0.8
+		tiers, err := captcha.NewTiers(map[string]int64{"0.5": 100, "0.9": 1000}, "uknow")
+		So(err, ShouldBeNil)
+		guard := Captcha(scoringResolver{score: &score}, tiers)
+
+		Convey("When checking a request", func() {
+			token := "token"
+			req := &Request{Address: addr, CaptchaToken: &token}
+			err := guard.Check(context.Background(), req)
+
+			Convey("Then the amount of the tier reached by the score should be granted", func() {
+				So(err, ShouldBeNil)
+				So(req.Captcha, ShouldEqual, captcha.Verified)
+				So(req.Amount, ShouldResemble, types.NewCoins(types.NewInt64Coin("uknow", 100)))
+			})
+		})
+
+		Convey("When checking a request for a given amount", func() {
+			token := "token"
+			req := &Request{Address: addr, CaptchaToken: &token, Amount: types.NewCoins(types.NewInt64Coin("uknow", 5000))}
+			err := guard.Check(context.Background(), req)
+
+			Convey("Then the requested amount should be kept", func() {
+				So(err, ShouldBeNil)
+				So(req.Captcha, ShouldEqual, captcha.Verified)
+				So(req.Amount, ShouldResemble, types.NewCoins(types.NewInt64Coin("uknow", 5000)))
+			})
+		})
+
+		Convey("When checking a request authenticated with an api key", func() {
+			req := &Request{Address: addr, APIKey: &apikey.Key{Name: "ci"}}
+			err := guard.Check(context.Background(), req)
+
+			Convey("Then the captcha should not be verified", func() {
+				So(err, ShouldBeNil)
+				So(req.Captcha, ShouldBeEmpty)
+				So(req.Amount, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given an api key guard", t, func() {
+		key := apikey.Key{Name: "ci", SecretHash: apikey.Hash("secret"), Assets: map[string]int64{"uknow": 1000}}
+		keyring, err := apikey.NewKeyring([]apikey.Key{key}, nil)
+		So(err, ShouldBeNil)
+		guard := APIKey(keyring)
+
+		Convey("Then a request without key should be accepted", func() {
+			So(guard.Check(context.Background(), &Request{Address: addr}), ShouldBeNil)
+		})
+
+		Convey("Then a request with a key should be limited to its allowed amount", func() {
+			req := &Request{Address: addr, APIKey: &key, Amount: types.NewCoins(types.NewInt64Coin("uknow", 1000))}
+			So(guard.Check(context.Background(), req), ShouldBeNil)
+			req.Amount = types.NewCoins(types.NewInt64Coin("uknow", 1001))
+			So(errors.Is(guard.Check(context.Background(), req), apikey.ErrAmountNotAllowed), ShouldBeTrue)
+		})
+
+		Convey("Then a request with a key without assets should be accepted with the default amount", func() {
+			key := apikey.Key{Name: "default", SecretHash: apikey.Hash("default")}
+			store := ledger.NewMemoryStore()
+			chain := NewChain(guard).Reserve(store, func() types.Coins {
+				return types.NewCoins(types.NewInt64Coin("uknow", 100))
+			})
+
+			req := &Request{ID: "1", Address: addr, APIKey: &key}
+			So(chain.Check(context.Background(), req), ShouldBeNil)
+			So(req.Amount, ShouldResemble, types.NewCoins(types.NewInt64Coin("uknow", 100)))
+
+			record, err := store.Get("1")
+			So(err, ShouldBeNil)
+			So(record.APIKey, ShouldEqual, "default")
+		})
+	})
+
+	Convey("Given a screening guard", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"flagged":true}`))
+		}))
+		defer server.Close()
+
+		Convey("Then the addresses flagged by the screening service should be rejected", func() {
+			err := Screening(screening.NewClient(server.URL)).Check(context.Background(), &Request{Address: addr})
+			So(errors.Is(err, screening.ErrFlagged), ShouldBeTrue)
+		})
+
+		Convey("Then all the addresses should be accepted without screening client", func() {
+			So(Screening(nil).Check(context.Background(), &Request{Address: addr}), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an identity guard", t, func() {
+		store := ledger.NewMemoryStore()
+		defer store.Close()
+		guard := Identity(identity.NewPolicy(store, 0, 24*time.Hour))
+
+		Convey("Then a request of a requester not logged in should be rejected", func() {
+			err := guard.Check(context.Background(), &Request{Address: addr})
+			So(errors.Is(err, identity.ErrUnauthenticated), ShouldBeTrue)
+		})
+
+		Convey("Then a request of a logged in requester should be accepted", func() {
+			req := &Request{Address: addr, Identity: &identity.Identity{Provider: identity.ProviderGitHub, ID: "42"}}
+			So(guard.Check(context.Background(), req), ShouldBeNil)
+		})
+
+		Convey("Then a request authenticated with an api key should be accepted", func() {
+			So(guard.Check(context.Background(), &Request{Address: addr, APIKey: &apikey.Key{Name: "ci"}}), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an ip limit guard allowing 2 requests per hour from an ip and 3 from a subnet", t, func() {
+		store := ledger.NewMemoryStore()
+		defer store.Close()
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		guard := ipLimit{
+			store:  store,
+			limits: IPLimits{PerIP: 2, PerSubnet: 3, Period: time.Hour},
+			now:    func() time.Time { return now },
+		}
+		req := &Request{Address: addr, RequesterIP: "10.0.0.1", RequesterSubnet: "10.0.0.0/24"}
+		put := func(id, ip string, age time.Duration, status ledger.Status) {
+			So(store.Put(ledger.Record{
+				ID:              id,
+				RequesterIP:     ip,
+				RequesterSubnet: "10.0.0.0/24",
+				Status:          status,
+				CreatedAt:       now.Add(-age),
+			}), ShouldBeNil)
+		}
+
+		put("1", "10.0.0.1", 90*time.Minute, ledger.StatusConfirmed)
+		put("2", "10.0.0.1", 30*time.Minute, ledger.StatusConfirmed)
+		put("3", "10.0.0.1", 15*time.Minute, ledger.StatusFailed)
+
+		Convey("When the ip address requested funds once over the period", func() {
+			Convey("Then its request should be accepted", func() {
+				So(guard.Check(context.Background(), req), ShouldBeNil)
+			})
+		})
+
+		Convey("When the ip address reached its limit", func() {
+			put("4", "10.0.0.1", 10*time.Minute, ledger.StatusConfirmed)
+			err := guard.Check(context.Background(), req)
+
+			Convey("Then its request should be rejected until the oldest one leaves the period", func() {
+				var limitErr *IPLimitError
+				So(errors.As(err, &limitErr), ShouldBeTrue)
+				So(limitErr.RetryAfter, ShouldEqual, 30*time.Minute)
+				So(errors.Is(err, ErrIPLimited), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the subnet reached its limit", func() {
+			put("4", "10.0.0.2", 20*time.Minute, ledger.StatusConfirmed)
+			put("5", "10.0.0.3", 10*time.Minute, ledger.StatusConfirmed)
+			err := guard.Check(context.Background(), req)
+
+			Convey("Then the requests from the subnet should be rejected", func() {
+				var limitErr *IPLimitError
+				So(errors.As(err, &limitErr), ShouldBeTrue)
+				So(limitErr.RetryAfter, ShouldEqual, 30*time.Minute)
+				So(errors.Is(err, ErrSubnetLimited), ShouldBeTrue)
+			})
+		})
+	})
+}
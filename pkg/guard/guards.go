@@ -0,0 +1,281 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"okp4/cosmos-faucet/pkg/access"
+	"okp4/cosmos-faucet/pkg/apikey"
+	"okp4/cosmos-faucet/pkg/captcha"
+	"okp4/cosmos-faucet/pkg/eligibility"
+	"okp4/cosmos-faucet/pkg/identity"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"okp4/cosmos-faucet/pkg/ownership"
+	"okp4/cosmos-faucet/pkg/screening"
+	"time"
+)
+
+// Names of the built-in guards, as given in the configuration.
+const (
+	NameAPIKey      = "apikey"
+	NameAccessList  = "access"
+	NameCaptcha     = "captcha"
+	NameEligibility = "eligibility"
+	NameIdentity    = "identity"
+	NameIPLimit     = "ip"
+	NameOwnership   = "ownership"
+	NameScreening   = "screening"
+)
+
+// ErrIPLimited is returned when too many funds have been requested from the same IP address.
+var ErrIPLimited = errors.New("too many requests from this ip address")
+
+// ErrSubnetLimited is returned when too many funds have been requested from the same subnet.
+var ErrSubnetLimited = errors.New("too many requests from this network")
+
+// IPLimitError is the error of a request refused because of the number of requests from its IP address or subnet.
+type IPLimitError struct {
+	// Subnet tells whether the limit of the subnet has been reached, rather than the one of the IP address.
+	Subnet bool
+	// RetryAfter is the duration after which a new request may be accepted.
+	RetryAfter time.Duration
+}
+
+func (e *IPLimitError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", e.Unwrap(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *IPLimitError) Unwrap() error {
+	if e.Subnet {
+		return ErrSubnetLimited
+	}
+	return ErrIPLimited
+}
+
+// funcGuard is a guard made of a function.
+type funcGuard struct {
+	name  string
+	check func(ctx context.Context, req *Request) error
+}
+
+// Func returns a guard of the given name applying the given check function.
+func Func(name string, check func(ctx context.Context, req *Request) error) Guard {
+	return funcGuard{name: name, check: check}
+}
+
+func (g funcGuard) Name() string {
+	return g.name
+}
+
+func (g funcGuard) Check(ctx context.Context, req *Request) error {
+	return g.check(ctx, req)
+}
+
+// AccessList rejects the addresses denied by the given access lists.
+func AccessList(list *access.List) Guard {
+	return Func(NameAccessList, func(_ context.Context, req *Request) error {
+		return list.Check(req.Address.String())
+	})
+}
+
+// Eligibility rejects the addresses the given checker deems not eligible.
+func Eligibility(checker *eligibility.Checker) Guard {
+	return eligibilityGuard{checker: checker}
+}
+
+type eligibilityGuard struct {
+	checker *eligibility.Checker
+}
+
+func (g eligibilityGuard) Name() string {
+	return NameEligibility
+}
+
+func (g eligibilityGuard) Check(ctx context.Context, req *Request) error {
+	decision, err := g.checker.CheckRequest(ctx, req.Address.String(), req.Amount)
+	if err != nil {
+		return err
+	}
+	return decision.Err()
+}
+
+func (g eligibilityGuard) CheckRecords(_ context.Context, req *Request) error {
+	decision, err := g.checker.CheckRecords(req.Address.String(), req.Amount)
+	if err != nil {
+		return err
+	}
+	return decision.Err()
+}
+
+// Ownership rejects the requests not proving the ownership of their address, accepting all of them if the verifier is
+// nil.
+func Ownership(verifier *ownership.Verifier) Guard {
+	return Func(NameOwnership, func(_ context.Context, req *Request) error {
+		if verifier == nil {
+			return nil
+		}
+		return verifier.Verify(req.Address.String(), req.PubKey, req.Signature)
+	})
+}
+
+// Screening rejects the addresses flagged by the given screening client, accepting all of them if the client is nil.
+func Screening(client *screening.Client) Guard {
+	return Func(NameScreening, func(ctx context.Context, req *Request) error {
+		if client == nil {
+			return nil
+		}
+		return client.Screen(ctx, req.Address.String())
+	})
+}
+
+// Identity rejects the requests whose requester identity is not accepted by the given policy, except those
+// authenticated with an API key. All the requests are accepted if the policy is nil.
+func Identity(policy *identity.Policy) Guard {
+	return identityGuard{policy: policy}
+}
+
+type identityGuard struct {
+	policy *identity.Policy
+}
+
+func (g identityGuard) Name() string {
+	return NameIdentity
+}
+
+func (g identityGuard) Check(_ context.Context, req *Request) error {
+	if g.policy == nil || req.APIKey != nil {
+		return nil
+	}
+	return g.policy.Check(req.Identity)
+}
+
+func (g identityGuard) CheckRecords(ctx context.Context, req *Request) error {
+	return g.Check(ctx, req)
+}
+
+// Captcha rejects the requests whose captcha token is not verified by the given resolver, except those authenticated
+// with an API key. When the provider gives a score, the amount of the tier it reaches is granted, if any and if no
+// amount has been requested.
+func Captcha(resolver captcha.Resolver, tiers captcha.Tiers) Guard {
+	return Func(NameCaptcha, func(ctx context.Context, req *Request) error {
+		if req.APIKey != nil {
+			return nil
+		}
+		result, err := resolver.CheckRecaptcha(ctx, req.CaptchaToken)
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+
+		req.Captcha = captcha.Verified
+		if result.Score != nil && len(tiers) > 0 && req.Amount.Empty() {
+			req.Amount = tiers.Amount(*result.Score)
+		}
+		return nil
+	})
+}
+
+// APIKey rejects the requests authenticated with an API key which does not allow their amount or whose quota is
+// exceeded.
+func APIKey(keyring *apikey.Keyring) Guard {
+	return apiKeyGuard{keyring: keyring}
+}
+
+type apiKeyGuard struct {
+	keyring *apikey.Keyring
+}
+
+func (g apiKeyGuard) Name() string {
+	return NameAPIKey
+}
+
+func (g apiKeyGuard) Check(ctx context.Context, req *Request) error {
+	if req.APIKey == nil {
+		return nil
+	}
+
+	if err := req.APIKey.Allows(req.Amount); err != nil {
+		return err
+	}
+	return g.CheckRecords(ctx, req)
+}
+
+func (g apiKeyGuard) CheckRecords(_ context.Context, req *Request) error {
+	if req.APIKey == nil {
+		return nil
+	}
+	return g.keyring.CheckQuota(req.APIKey)
+}
+
+// IPLimits are the maximum numbers of fund requests from a same IP address and from a same /24 IPv4 or /64 IPv6
+// subnet over a period, each limit being disabled if 0.
+type IPLimits struct {
+	PerIP     int
+	PerSubnet int
+	Period    time.Duration
+}
+
+// IPLimit rejects the requests from an IP address or a subnet which already requested funds as many times as allowed
+// over the period, looking up the past distributions in the ledger.
+func IPLimit(store ledger.Store, limits IPLimits) Guard {
+	return ipLimit{store: store, limits: limits, now: time.Now}
+}
+
+type ipLimit struct {
+	store  ledger.Store
+	limits IPLimits
+	now    func() time.Time
+}
+
+func (g ipLimit) Name() string {
+	return NameIPLimit
+}
+
+func (g ipLimit) CheckRecords(ctx context.Context, req *Request) error {
+	return g.Check(ctx, req)
+}
+
+func (g ipLimit) Check(_ context.Context, req *Request) error {
+	perIP := g.limits.PerIP > 0 && req.RequesterIP != ""
+	perSubnet := g.limits.PerSubnet > 0 && req.RequesterSubnet != ""
+	if !perIP && !perSubnet {
+		return nil
+	}
+
+	now := g.now()
+	since := now.Add(-g.limits.Period)
+	ipCount, subnetCount := 0, 0
+	var limitErr error
+	err := ledger.Walk(g.store, ledger.Query{}, func(record ledger.Record) bool {
+		if !record.CreatedAt.After(since) {
+			return false
+		}
+		if record.Status == ledger.StatusFailed {
+			return true
+		}
+
+		retryAfter := record.CreatedAt.Add(g.limits.Period).Sub(now)
+		if perIP && record.RequesterIP == req.RequesterIP {
+			ipCount++
+			if ipCount >= g.limits.PerIP {
+				limitErr = &IPLimitError{RetryAfter: retryAfter}
+				return false
+			}
+		}
+		if perSubnet && record.RequesterSubnet == req.RequesterSubnet {
+			subnetCount++
+			if subnetCount >= g.limits.PerSubnet {
+				limitErr = &IPLimitError{Subnet: true, RetryAfter: retryAfter}
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return limitErr
+}
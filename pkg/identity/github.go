@@ -0,0 +1,96 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const gitHubAPIURL = "https://api.github.com"
+
+// GitHub authenticates the requesters with their GitHub account.
+type GitHub struct {
+	oauth  oauth2.Config
+	apiURL string
+}
+
+type gitHubUser struct {
+	ID        int64     `json:"id"`
+	Login     string    `json:"login"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewGitHub returns the GitHub identity provider, no scope being requested as only the public profile is read.
+func NewGitHub(config Config) *GitHub {
+	endpoint := github.Endpoint
+	if config.AuthURL != "" {
+		endpoint.AuthURL = config.AuthURL
+	}
+	if config.TokenURL != "" {
+		endpoint.TokenURL = config.TokenURL
+	}
+	apiURL := gitHubAPIURL
+	if config.APIURL != "" {
+		apiURL = config.APIURL
+	}
+
+	return &GitHub{
+		oauth: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     endpoint,
+		},
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+	}
+}
+
+func (g *GitHub) Name() string {
+	return ProviderGitHub
+}
+
+func (g *GitHub) AuthCodeURL(state string) string {
+	return g.oauth.AuthCodeURL(state)
+}
+
+func (g *GitHub) Exchange(ctx context.Context, code string) (*Identity, error) {
+	token, err := g.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("could not exchange authorization code: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.apiURL+"/user", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := g.oauth.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get user: unexpected status %d", resp.StatusCode)
+	}
+
+	var user gitHubUser
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("could not decode user: %w", err)
+	}
+
+	return &Identity{
+		Provider:  ProviderGitHub,
+		ID:        strconv.FormatInt(user.ID, 10),
+		Login:     user.Login,
+		CreatedAt: user.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnauthenticated is returned when a fund request requires an identity and the requester is not logged in.
+var ErrUnauthenticated = errors.New("login required")
+
+// ErrAccountTooRecent is returned when the account of the requester has been created too recently.
+var ErrAccountTooRecent = errors.New("account is too recent")
+
+// ErrAlreadyFunded is returned when the account of the requester has already received funds during the period.
+var ErrAlreadyFunded = errors.New("account has recently received funds")
+
+// Identity is an account of the requester at an identity provider.
+type Identity struct {
+	// Provider is the name of the identity provider.
+	Provider string `json:"provider"`
+	// ID uniquely identifies the account at the provider.
+	ID string `json:"id"`
+	// Login is the name of the account, as displayed by the provider.
+	Login string `json:"login"`
+	// CreatedAt is the time at which the account has been created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Key uniquely identifies the account across the providers, as recorded along with the fund requests.
+func (i Identity) Key() string {
+	return i.Provider + ":" + i.ID
+}
+
+// Provider authenticates the requesters through an OAuth authorization code flow.
+type Provider interface {
+	// Name identifies the provider in the configuration and in the identities.
+	Name() string
+	// AuthCodeURL returns the URL of the provider to redirect the requester to in order to log in.
+	AuthCodeURL(state string) string
+	// Exchange exchanges the authorization code given back by the provider for the identity of the requester.
+	Exchange(ctx context.Context, code string) (*Identity, error)
+}
+
+// Provider names, as given in the configuration.
+const (
+	ProviderGitHub = "github"
+)
+
+// Config configures an identity provider.
+type Config struct {
+	// ClientID and ClientSecret are the credentials of the OAuth application registered at the provider.
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL of the faucet registered at the provider.
+	RedirectURL string
+	// AuthURL, TokenURL and APIURL override the endpoints of the provider, if not empty.
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// NewProvider returns the identity provider with the given name.
+func NewProvider(name string, config Config) (Provider, error) {
+	switch name {
+	case ProviderGitHub:
+		return NewGitHub(config), nil
+	default:
+		return nil, fmt.Errorf("unknown identity provider %q", name)
+	}
+}
+
+type contextKey struct{}
+
+// WithIdentity returns a copy of the context holding the identity of the requester.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity of the requester held by the context, or nil if not logged in.
+func FromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(contextKey{}).(*Identity)
+	return identity
+}
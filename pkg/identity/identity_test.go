@@ -0,0 +1,196 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newOAuthServer returns a local stand-in of the GitHub OAuth and API endpoints, granting a token for the given code
+// and serving the profile of the given user to its bearer.
+func newOAuthServer(code, user string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.FormValue("client_id"), r.FormValue("client_secret")
+		}
+		if clientID != "client" || clientSecret != "secret" || r.FormValue("code") != code {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(user))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGitHub(t *testing.T) {
+	Convey("Given a GitHub provider and a fake OAuth server", t, func() {
+		server := newOAuthServer("code", `{"id":42,"login":"octocat","created_at":"2011-01-25T18:44:36Z"}`)
+		defer server.Close()
+
+		provider, err := NewProvider(ProviderGitHub, Config{
+			ClientID:     "client",
+			ClientSecret: "secret",
+			RedirectURL:  "https://faucet.okp4.network/auth/callback",
+			AuthURL:      server.URL + "/login/oauth/authorize",
+			TokenURL:     server.URL + "/login/oauth/access_token",
+			APIURL:       server.URL,
+		})
+		So(err, ShouldBeNil)
+
+		Convey("When building the login URL", func() {
+			loginURL, err := url.Parse(provider.AuthCodeURL("state"))
+			So(err, ShouldBeNil)
+
+			Convey("Then it should lead to the provider with the client ID and state", func() {
+				So(loginURL.Path, ShouldEqual, "/login/oauth/authorize")
+				So(loginURL.Query().Get("client_id"), ShouldEqual, "client")
+				So(loginURL.Query().Get("state"), ShouldEqual, "state")
+				So(loginURL.Query().Get("redirect_uri"), ShouldEqual, "https://faucet.okp4.network/auth/callback")
+			})
+		})
+
+		Convey("When exchanging a valid code", func() {
+			identity, err := provider.Exchange(context.Background(), "code")
+
+			Convey("Then the identity of the user should be returned", func() {
+				So(err, ShouldBeNil)
+				So(*identity, ShouldResemble, Identity{
+					Provider:  ProviderGitHub,
+					ID:        "42",
+					Login:     "octocat",
+					CreatedAt: time.Date(2011, 1, 25, 18, 44, 36, 0, time.UTC),
+				})
+				So(identity.Key(), ShouldEqual, "github:42")
+			})
+		})
+
+		Convey("When exchanging an invalid code", func() {
+			_, err := provider.Exchange(context.Background(), "invalid")
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given an unknown provider", t, func() {
+		_, err := NewProvider("unknown", Config{})
+
+		Convey("Then it should not be created", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSessions(t *testing.T) {
+	Convey("Given sessions lasting an hour", t, func() {
+		sessions, err := NewSessions("secret", time.Hour)
+		So(err, ShouldBeNil)
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		sessions.now = func() time.Time { return now }
+		identity := Identity{Provider: ProviderGitHub, ID: "42", Login: "octocat", CreatedAt: now.AddDate(-1, 0, 0)}
+
+		Convey("When issuing a session", func() {
+			token, err := sessions.Issue(identity)
+			So(err, ShouldBeNil)
+
+			Convey("Then its token should give back the identity", func() {
+				got, err := sessions.Verify(token)
+				So(err, ShouldBeNil)
+				So(*got, ShouldResemble, identity)
+			})
+
+			Convey("Then its token should not be valid anymore once expired", func() {
+				now = now.Add(time.Hour + time.Second)
+				_, err := sessions.Verify(token)
+				So(err, ShouldEqual, ErrInvalidSession)
+			})
+
+			Convey("Then its token should not be accepted by sessions with another secret", func() {
+				other, err := NewSessions("other", time.Hour)
+				So(err, ShouldBeNil)
+				_, err = other.Verify(token)
+				So(err, ShouldEqual, ErrInvalidSession)
+			})
+		})
+
+		Convey("When verifying a forged token", func() {
+			_, err := sessions.Verify("eyJpZGVudGl0eSI6e319.forged")
+
+			Convey("Then it should be rejected", func() {
+				So(err, ShouldEqual, ErrInvalidSession)
+			})
+		})
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	Convey("Given a policy requiring 30 days old accounts funded once a day", t, func() {
+		store := ledger.NewMemoryStore()
+		defer store.Close()
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		policy := NewPolicy(store, 30*24*time.Hour, 24*time.Hour)
+		policy.now = func() time.Time { return now }
+		identity := &Identity{Provider: ProviderGitHub, ID: "42", CreatedAt: now.AddDate(0, -2, 0)}
+
+		Convey("Then a requester not logged in should be rejected", func() {
+			So(policy.Check(nil), ShouldEqual, ErrUnauthenticated)
+		})
+
+		Convey("Then a recent account should be rejected until old enough", func() {
+			recent := &Identity{Provider: ProviderGitHub, ID: "43", CreatedAt: now.AddDate(0, 0, -20)}
+			err := policy.Check(recent)
+			So(errors.Is(err, ErrAccountTooRecent), ShouldBeTrue)
+			var deniedErr *DeniedError
+			So(errors.As(err, &deniedErr), ShouldBeTrue)
+			So(deniedErr.RetryAfter, ShouldEqual, 10*24*time.Hour)
+		})
+
+		Convey("Then an old enough account never funded should be accepted", func() {
+			So(policy.Check(identity), ShouldBeNil)
+		})
+
+		Convey("When the account has been funded during the period", func() {
+			So(store.Put(ledger.Record{
+				ID: "1", Identity: "github:42", Status: ledger.StatusConfirmed, CreatedAt: now.Add(-6 * time.Hour),
+			}), ShouldBeNil)
+
+			Convey("Then it should be rejected until the end of the period", func() {
+				err := policy.Check(identity)
+				So(errors.Is(err, ErrAlreadyFunded), ShouldBeTrue)
+				var deniedErr *DeniedError
+				So(errors.As(err, &deniedErr), ShouldBeTrue)
+				So(deniedErr.RetryAfter, ShouldEqual, 18*time.Hour)
+			})
+		})
+
+		Convey("When the funding of the account failed", func() {
+			So(store.Put(ledger.Record{
+				ID: "1", Identity: "github:42", Status: ledger.StatusFailed, CreatedAt: now.Add(-6 * time.Hour),
+			}), ShouldBeNil)
+
+			Convey("Then it should be accepted", func() {
+				So(policy.Check(identity), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,76 @@
+package identity
+
+import (
+	"fmt"
+	"okp4/cosmos-faucet/pkg/ledger"
+	"time"
+)
+
+// DeniedError is the error of an identity refused by the policy, wrapping the reason of the refusal.
+type DeniedError struct {
+	Err error
+	// RetryAfter is the duration after which the identity may request funds again, if known.
+	RetryAfter time.Duration
+}
+
+func (e *DeniedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s, retry in %s", e.Err, e.RetryAfter.Round(time.Second))
+	}
+	return e.Err.Error()
+}
+
+func (e *DeniedError) Unwrap() error {
+	return e.Err
+}
+
+// Policy decides whether an identity can request funds: its account must be old enough, and it can only receive funds
+// once per period.
+type Policy struct {
+	store  ledger.Store
+	minAge time.Duration
+	period time.Duration
+	now    func() time.Time
+}
+
+// NewPolicy returns the policy requiring the accounts to be at least minAge old, and looking up in the ledger the
+// distributions made to the identities during the period, disabled if 0.
+func NewPolicy(store ledger.Store, minAge, period time.Duration) *Policy {
+	return &Policy{store: store, minAge: minAge, period: period, now: time.Now}
+}
+
+// Check returns an error if the given identity cannot currently request funds.
+func (p *Policy) Check(identity *Identity) error {
+	if identity == nil {
+		return ErrUnauthenticated
+	}
+
+	now := p.now()
+	if age := now.Sub(identity.CreatedAt); age < p.minAge {
+		return &DeniedError{Err: ErrAccountTooRecent, RetryAfter: p.minAge - age}
+	}
+
+	if p.store == nil || p.period <= 0 {
+		return nil
+	}
+
+	since := now.Add(-p.period)
+	key := identity.Key()
+	var deniedErr error
+	err := ledger.Walk(p.store, ledger.Query{}, func(record ledger.Record) bool {
+		if !record.CreatedAt.After(since) {
+			return false
+		}
+		if record.Identity != key || record.Status == ledger.StatusFailed {
+			return true
+		}
+
+		deniedErr = &DeniedError{Err: ErrAlreadyFunded, RetryAfter: record.CreatedAt.Add(p.period).Sub(now)}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+
+	return deniedErr
+}
@@ -0,0 +1,86 @@
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSession is returned when a session token has not been issued by the faucet or has expired.
+var ErrInvalidSession = errors.New("invalid session")
+
+// Sessions issues the tokens of the logged in requesters. The tokens are signed and hold the identity, so that the
+// sessions do not need to be stored.
+type Sessions struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+type session struct {
+	Identity  Identity  `json:"identity"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewSessions returns the sessions signed with the given secret, a random one being generated if empty, which expire
+// after the given duration.
+func NewSessions(secret string, ttl time.Duration) (*Sessions, error) {
+	key := []byte(secret)
+	if secret == "" {
+		key = make([]byte, sha256.Size)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Sessions{key: key, ttl: ttl, now: time.Now}, nil
+}
+
+// TTL returns the duration after which the sessions expire.
+func (s *Sessions) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue returns the token of a new session of the given identity.
+func (s *Sessions) Issue(identity Identity) (string, error) {
+	payload, err := json.Marshal(session{Identity: identity, ExpiresAt: s.now().Add(s.ttl)})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + s.sign(encoded), nil
+}
+
+// Verify returns the identity of the session of the given token.
+func (s *Sessions) Verify(token string) (*Identity, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return nil, ErrInvalidSession
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+	var sess session
+	if err := json.Unmarshal(payload, &sess); err != nil {
+		return nil, ErrInvalidSession
+	}
+	if s.now().After(sess.ExpiresAt) {
+		return nil, ErrInvalidSession
+	}
+
+	return &sess.Identity, nil
+}
+
+func (s *Sessions) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,142 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	recordsBucket = []byte("records")
+	// timeIndexBucket indexes the records IDs by sort key.
+	timeIndexBucket = []byte("time-index")
+	// addressIndexBucket indexes the records IDs by address then sort key.
+	addressIndexBucket = []byte("address-index")
+)
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore returns a Store persisting records in the embedded bolt database file at the given path, creating it if
+// needed.
+func NewBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{recordsBucket, timeIndexBucket, addressIndexBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Put(record Record) error {
+	bz, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(recordsBucket).Put([]byte(record.ID), bz); err != nil {
+			return err
+		}
+
+		key := sortKey(record)
+		if err := tx.Bucket(timeIndexBucket).Put(key, []byte(record.ID)); err != nil {
+			return err
+		}
+		return tx.Bucket(addressIndexBucket).Put(append(addressPrefix(record.Address), key...), []byte(record.ID))
+	})
+}
+
+func (s *boltStore) Get(id string) (*Record, error) {
+	var record *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bz := tx.Bucket(recordsBucket).Get([]byte(id))
+		if bz == nil {
+			return ErrNotFound
+		}
+
+		record = &Record{}
+		return json.Unmarshal(bz, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *boltStore) List(query Query) ([]Record, bool, error) {
+	after, err := decodeCursor(query.After)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var records []Record
+	hasNext := false
+	err = s.db.View(func(tx *bolt.Tx) error {
+		bucket, prefix := tx.Bucket(timeIndexBucket), []byte{}
+		if query.Address != "" {
+			bucket, prefix = tx.Bucket(addressIndexBucket), addressPrefix(query.Address)
+		}
+
+		c := bucket.Cursor()
+		for k, v := seekBefore(c, prefix, after); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Prev() {
+			if len(records) == query.First {
+				hasNext = true
+				return nil
+			}
+
+			var record Record
+			if err := json.Unmarshal(tx.Bucket(recordsBucket).Get(v), &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return records, hasNext, nil
+}
+
+// seekBefore positions the cursor on the last key having the given prefix and strictly before prefix + after, or on
+// the last key having the given prefix if after is nil.
+func seekBefore(c *bolt.Cursor, prefix, after []byte) ([]byte, []byte) {
+	var upperBound []byte
+	if after != nil {
+		upperBound = append(append([]byte{}, prefix...), after...)
+	} else {
+		upperBound = append(append([]byte{}, prefix...), 0xff)
+	}
+
+	if k, _ := c.Seek(upperBound); k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// addressPrefix returns the address index key prefix of the given address.
+func addressPrefix(address string) []byte {
+	return append([]byte(address), 0)
+}
@@ -0,0 +1,163 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrNotFound is returned when no record matches the requested identifier.
+var ErrNotFound = errors.New("record not found")
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Status represents the processing status of a fund request.
+type Status string
+
+const (
+	// StatusQueued denotes a request waiting to be included in a transaction.
+	StatusQueued Status = "queued"
+	// StatusSubmitted denotes a request included in a transaction successfully submitted.
+	StatusSubmitted Status = "submitted"
+	// StatusConfirmed denotes a request included in a transaction confirmed in a block.
+	StatusConfirmed Status = "confirmed"
+	// StatusFailed denotes a request whose transaction could not be made, submitted or confirmed, or has a non 0 code.
+	StatusFailed Status = "failed"
+)
+
+// Record represents a fund request handled by the faucet, along with the outcome of the transaction it has been
+// included in.
+type Record struct {
+	// ID uniquely identifies the fund request.
+	ID string `json:"id"`
+
+	// Address on which the funds are sent.
+	Address string `json:"address"`
+
+	// Amount of sent funds.
+	Amount types.Coins `json:"amount"`
+
+	// RequesterIP is the IP address of the requester, if known.
+	RequesterIP string `json:"requesterIp,omitempty"`
+
+	// RequesterSubnet is the /24 IPv4 or /64 IPv6 subnet of the requester IP address, if known.
+	RequesterSubnet string `json:"requesterSubnet,omitempty"`
+
+	// Captcha describes the outcome of the captcha verification, empty if no verification took place.
+	Captcha string `json:"captcha,omitempty"`
+
+	// APIKey is the name of the API key the request has been made with, if any.
+	APIKey string `json:"apiKey,omitempty"`
+
+	// Identity identifies the account of the requester at an identity provider, if logged in.
+	Identity string `json:"identity,omitempty"`
+
+	// Status is the processing status of the request.
+	Status Status `json:"status"`
+
+	// TxHash is the hash of the transaction including the request, once submitted.
+	TxHash string `json:"txHash,omitempty"`
+
+	// Code is the result code of the transaction, once submitted.
+	Code uint32 `json:"code"`
+
+	// Error describes the reason of the failure, if any.
+	Error string `json:"error,omitempty"`
+
+	// Height is the block height of the transaction, if known.
+	Height int64 `json:"height"`
+
+	// CreatedAt is the time at which the request has been received.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt is the time of the last change of the record.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Store is a persistent storage of the faucet distributions.
+type Store interface {
+	// Put inserts the record, or replaces it if a record with the same ID already exists.
+	Put(record Record) error
+
+	// Get returns the record with the given ID, or ErrNotFound.
+	Get(id string) (*Record, error)
+
+	// List returns the records matching the query from the most recent to the oldest, and whether more records follow.
+	List(query Query) ([]Record, bool, error)
+
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// Query describes a page of records to list.
+type Query struct {
+	// Address restricts the records to the ones sent to this address, if not empty.
+	Address string
+
+	// First is the maximum number of records to return.
+	First int
+
+	// After is the cursor of the record after which to start, starting from the most recent record if empty.
+	After string
+}
+
+// walkPageSize is the number of records fetched at once when walking through the records.
+const walkPageSize = 100
+
+// Walk calls fn for each record matching the query, from the most recent to the oldest, until fn returns false. The
+// query First field is ignored.
+func Walk(store Store, query Query, fn func(record Record) bool) error {
+	query.First = walkPageSize
+	for {
+		records, hasNext, err := store.List(query)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if !fn(record) {
+				return nil
+			}
+		}
+
+		if !hasNext || len(records) == 0 {
+			return nil
+		}
+		query.After = Cursor(records[len(records)-1])
+	}
+}
+
+// Cursor returns the opaque pagination cursor designating the position of the given record.
+func Cursor(record Record) string {
+	return base64.RawURLEncoding.EncodeToString(sortKey(record))
+}
+
+// sortKey returns the key ordering the records by creation time, then by ID.
+func sortKey(record Record) []byte {
+	key := make([]byte, 8, 8+len(record.ID))
+	binary.BigEndian.PutUint64(key, uint64(record.CreatedAt.UnixNano()))
+	return append(key, record.ID...)
+}
+
+func decodeCursor(cursor string) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(key) < 8 {
+		return nil, ErrInvalidCursor
+	}
+
+	return key, nil
+}
+
+// before tells whether the sort key a is strictly before b.
+func before(a, b []byte) bool {
+	return bytes.Compare(a, b) < 0
+}
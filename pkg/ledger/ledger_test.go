@@ -0,0 +1,195 @@
+package ledger
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func() Store{
+		"memory": func() Store { return NewMemoryStore() },
+		"bolt": func() Store {
+			store, err := NewBoltStore(filepath.Join(t.TempDir(), "ledger.db"))
+			if err != nil {
+				panic(err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		Convey("Given an empty "+name+" store", t, func() {
+			store := newStore()
+			defer store.Close()
+
+			Convey("When getting an unknown record", func() {
+				record, err := store.Get("unknown")
+
+				Convey("Then it should not be found", func() {
+					So(record, ShouldBeNil)
+					So(err, ShouldEqual, ErrNotFound)
+				})
+			})
+
+			Convey("When putting a record", func() {
+				record := Record{
+					ID:          "id",
+					Address:     "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27",
+					Amount:      types.NewCoins(types.NewInt64Coin("uknow", 1000000)),
+					RequesterIP: "127.0.0.1",
+					CreatedAt:   time.Now().UTC().Truncate(time.Second),
+					UpdatedAt:   time.Now().UTC().Truncate(time.Second),
+				}
+				So(store.Put(record), ShouldBeNil)
+
+				Convey("Then it should be retrieved", func() {
+					got, err := store.Get("id")
+					So(err, ShouldBeNil)
+					So(*got, ShouldResemble, record)
+				})
+
+				Convey("And updating it", func() {
+					record.TxHash = "hash"
+					record.Height = 42
+					So(store.Put(record), ShouldBeNil)
+
+					Convey("Then the updated record should be retrieved", func() {
+						got, err := store.Get("id")
+						So(err, ShouldBeNil)
+						So(*got, ShouldResemble, record)
+					})
+				})
+			})
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	stores := map[string]func() Store{
+		"memory": func() Store { return NewMemoryStore() },
+		"bolt": func() Store {
+			store, err := NewBoltStore(filepath.Join(t.TempDir(), "ledger.db"))
+			if err != nil {
+				panic(err)
+			}
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		Convey("Given a "+name+" store containing records for several addresses", t, func() {
+			store := newStore()
+			defer store.Close()
+
+			start := time.Date(2022, 8, 30, 0, 0, 0, 0, time.UTC)
+			var records []Record
+			for i := 0; i < 5; i++ {
+				record := Record{
+					ID:        fmt.Sprintf("id-%d", i),
+					Address:   []string{"alice", "bob"}[i%2],
+					CreatedAt: start.Add(time.Duration(i) * time.Minute),
+				}
+				records = append(records, record)
+				So(store.Put(record), ShouldBeNil)
+			}
+
+			Convey("When listing all the records", func() {
+				got, hasNext, err := store.List(Query{First: 10})
+
+				Convey("Then they should be returned from the most recent", func() {
+					So(err, ShouldBeNil)
+					So(hasNext, ShouldBeFalse)
+					So(ids(got), ShouldResemble, []string{"id-4", "id-3", "id-2", "id-1", "id-0"})
+				})
+			})
+
+			Convey("When paginating over the records", func() {
+				first, hasNext, err := store.List(Query{First: 2})
+				So(err, ShouldBeNil)
+				So(hasNext, ShouldBeTrue)
+				So(ids(first), ShouldResemble, []string{"id-4", "id-3"})
+
+				Convey("Then the next page should start after the cursor", func() {
+					second, hasNext, err := store.List(Query{First: 2, After: Cursor(first[1])})
+					So(err, ShouldBeNil)
+					So(hasNext, ShouldBeTrue)
+					So(ids(second), ShouldResemble, []string{"id-2", "id-1"})
+
+					last, hasNext, err := store.List(Query{First: 2, After: Cursor(second[1])})
+					So(err, ShouldBeNil)
+					So(hasNext, ShouldBeFalse)
+					So(ids(last), ShouldResemble, []string{"id-0"})
+				})
+			})
+
+			Convey("When listing the records of an address", func() {
+				first, hasNext, err := store.List(Query{Address: "alice", First: 2})
+				So(err, ShouldBeNil)
+				So(hasNext, ShouldBeTrue)
+				So(ids(first), ShouldResemble, []string{"id-4", "id-2"})
+
+				Convey("Then only its records should be returned", func() {
+					next, hasNext, err := store.List(Query{Address: "alice", First: 2, After: Cursor(first[1])})
+					So(err, ShouldBeNil)
+					So(hasNext, ShouldBeFalse)
+					So(ids(next), ShouldResemble, []string{"id-0"})
+				})
+			})
+
+			Convey("When listing with an invalid cursor", func() {
+				_, _, err := store.List(Query{First: 2, After: "!"})
+
+				Convey("Then it should fail", func() {
+					So(err, ShouldEqual, ErrInvalidCursor)
+				})
+			})
+		})
+	}
+}
+
+func ids(records []Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func TestMemoryRetention(t *testing.T) {
+	Convey("Given a memory store keeping the records for an hour", t, func() {
+		now := time.Date(2022, 8, 30, 12, 0, 0, 0, time.UTC)
+		store := NewMemoryStore(WithRetention(time.Hour))
+		store.(*memoryStore).now = func() time.Time { return now }
+
+		for i, age := range []time.Duration{3 * time.Hour, 2 * time.Hour, 30 * time.Minute, time.Minute} {
+			So(store.Put(Record{ID: fmt.Sprintf("id-%d", i), CreatedAt: now.Add(-age)}), ShouldBeNil)
+		}
+
+		Convey("When listing the records", func() {
+			got, hasNext, err := store.List(Query{First: 10})
+
+			Convey("Then only the recent ones should be kept", func() {
+				So(err, ShouldBeNil)
+				So(hasNext, ShouldBeFalse)
+				So(ids(got), ShouldResemble, []string{"id-3", "id-2"})
+				_, err := store.Get("id-0")
+				So(err, ShouldEqual, ErrNotFound)
+			})
+		})
+
+		Convey("When a record is updated with another creation time", func() {
+			So(store.Put(Record{ID: "id-2", CreatedAt: now}), ShouldBeNil)
+
+			Convey("Then it should be moved to its new position", func() {
+				got, _, err := store.List(Query{First: 10})
+				So(err, ShouldBeNil)
+				So(ids(got), ShouldResemble, []string{"id-2", "id-3"})
+			})
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu        sync.RWMutex
+	records   map[string]Record
+	keys      [][]byte
+	retention time.Duration
+	now       func() time.Time
+}
+
+// MemoryOption configures the in memory Store.
+type MemoryOption func(*memoryStore)
+
+// WithRetention makes the store drop the records created more than the given duration ago, keeping all of them if 0.
+func WithRetention(retention time.Duration) MemoryOption {
+	return func(s *memoryStore) {
+		s.retention = retention
+	}
+}
+
+// NewMemoryStore returns a Store keeping the records in memory, which are lost once the process exits.
+//
+// The records are kept ordered by creation time, so listing a page only goes through the records it skips and returns.
+func NewMemoryStore(opts ...MemoryOption) Store {
+	store := &memoryStore{
+		records: map[string]Record{},
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	return store
+}
+
+func (s *memoryStore) Put(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.records[record.ID]
+	if exists && !old.CreatedAt.Equal(record.CreatedAt) {
+		s.removeKey(sortKey(old))
+		exists = false
+	}
+	if !exists {
+		s.insertKey(sortKey(record))
+	}
+	s.records[record.ID] = record
+	s.prune()
+
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &record, nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
+
+func (s *memoryStore) List(query Query) ([]Record, bool, error) {
+	after, err := decodeCursor(query.After)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Going from the most recent record, or the one right before the cursor, to the oldest.
+	i := len(s.keys) - 1
+	if after != nil {
+		i = s.search(after) - 1
+	}
+
+	records := make([]Record, 0, query.First)
+	for ; i >= 0; i-- {
+		record := s.records[string(s.keys[i][8:])]
+		if query.Address != "" && record.Address != query.Address {
+			continue
+		}
+		if len(records) == query.First {
+			return records, true, nil
+		}
+		records = append(records, record)
+	}
+
+	return records, false, nil
+}
+
+// search returns the index of the first key not before the given one.
+func (s *memoryStore) search(key []byte) int {
+	return sort.Search(len(s.keys), func(i int) bool {
+		return !before(s.keys[i], key)
+	})
+}
+
+func (s *memoryStore) insertKey(key []byte) {
+	i := s.search(key)
+	s.keys = append(s.keys, nil)
+	copy(s.keys[i+1:], s.keys[i:])
+	s.keys[i] = key
+}
+
+func (s *memoryStore) removeKey(key []byte) {
+	i := s.search(key)
+	if i < len(s.keys) && !before(key, s.keys[i]) {
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	}
+}
+
+// prune drops the records older than the retention, which are at the beginning of the ordered keys.
+func (s *memoryStore) prune() {
+	if s.retention <= 0 {
+		return
+	}
+
+	cutoff := uint64(s.now().Add(-s.retention).UnixNano())
+	n := 0
+	for ; n < len(s.keys); n++ {
+		if binary.BigEndian.Uint64(s.keys[n]) >= cutoff {
+			break
+		}
+		delete(s.records, string(s.keys[n][8:]))
+	}
+	s.keys = s.keys[n:]
+}
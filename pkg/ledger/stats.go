@@ -0,0 +1,102 @@
+package ledger
+
+import (
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// Stats aggregates the fund requests received over a period.
+type Stats struct {
+	// Start is the beginning of the period.
+	Start time.Time
+
+	// Distributed is the total amount of the confirmed fund requests.
+	Distributed types.Coins
+
+	// Requests is the number of fund requests.
+	Requests int
+
+	// Recipients is the number of unique addresses having requested funds.
+	Recipients int
+
+	// Failed is the number of fund requests whose transaction failed.
+	Failed int
+
+	// AverageConfirmation is the average duration between the reception of a fund request and the confirmation of its
+	// transaction, 0 if none has been confirmed.
+	AverageConfirmation time.Duration
+}
+
+type aggregate struct {
+	stats        Stats
+	recipients   map[string]struct{}
+	confirmed    int
+	confirmation time.Duration
+}
+
+func newAggregate(start time.Time) *aggregate {
+	return &aggregate{
+		stats:      Stats{Start: start, Distributed: types.NewCoins()},
+		recipients: map[string]struct{}{},
+	}
+}
+
+func (a *aggregate) add(record Record) {
+	a.stats.Requests++
+	a.recipients[record.Address] = struct{}{}
+	switch record.Status {
+	case StatusConfirmed:
+		a.stats.Distributed = a.stats.Distributed.Add(record.Amount...)
+		a.confirmed++
+		a.confirmation += record.UpdatedAt.Sub(record.CreatedAt)
+	case StatusFailed:
+		a.stats.Failed++
+	case StatusQueued, StatusSubmitted:
+	}
+}
+
+func (a *aggregate) result() Stats {
+	stats := a.stats
+	stats.Recipients = len(a.recipients)
+	if a.confirmed > 0 {
+		stats.AverageConfirmation = a.confirmation / time.Duration(a.confirmed)
+	}
+
+	return stats
+}
+
+// ComputeStats aggregates the fund requests received in the [from, to) period, returning the total over the period and
+// the stats of each interval it is made of, intervals being aligned on UTC (e.g. hours or days). The time of
+// confirmation of a request is considered to be the last update of its record.
+func ComputeStats(store Store, from, to time.Time, interval time.Duration) (Stats, []Stats, error) {
+	from = from.UTC().Truncate(interval)
+	total := newAggregate(from)
+	buckets := make([]*aggregate, 0, int(to.Sub(from)/interval)+1)
+	for start := from; start.Before(to); start = start.Add(interval) {
+		buckets = append(buckets, newAggregate(start))
+	}
+
+	err := Walk(store, Query{}, func(record Record) bool {
+		if record.CreatedAt.Before(from) {
+			return false
+		}
+		if !record.CreatedAt.Before(to) {
+			return true
+		}
+
+		total.add(record)
+		buckets[int(record.CreatedAt.Sub(from)/interval)].add(record)
+		return true
+	})
+	if err != nil {
+		return Stats{}, nil, err
+	}
+
+	result := make([]Stats, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, bucket.result())
+	}
+
+	return total.result(), result, nil
+}
@@ -0,0 +1,61 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestComputeStats(t *testing.T) {
+	Convey("Given a store with records over several hours", t, func() {
+		start := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+		coins := types.NewCoins(types.NewInt64Coin("uknow", 100))
+		store := NewMemoryStore()
+		for _, record := range []Record{
+			{ID: "before", Address: "a", Amount: coins, Status: StatusConfirmed, CreatedAt: start.Add(-time.Minute)},
+			{ID: "1", Address: "a", Amount: coins, Status: StatusConfirmed,
+				CreatedAt: start.Add(time.Minute), UpdatedAt: start.Add(time.Minute + 10*time.Second)},
+			{ID: "2", Address: "b", Amount: coins, Status: StatusConfirmed,
+				CreatedAt: start.Add(2 * time.Minute), UpdatedAt: start.Add(2*time.Minute + 20*time.Second)},
+			{ID: "3", Address: "a", Amount: coins, Status: StatusFailed, CreatedAt: start.Add(3 * time.Minute)},
+			{ID: "4", Address: "c", Amount: coins, Status: StatusQueued, CreatedAt: start.Add(2*time.Hour + time.Minute)},
+			{ID: "after", Address: "a", Amount: coins, Status: StatusConfirmed, CreatedAt: start.Add(3 * time.Hour)},
+		} {
+			So(store.Put(record), ShouldBeNil)
+		}
+
+		Convey("When computing the hourly stats over the period", func() {
+			total, buckets, err := ComputeStats(store, start.Add(30*time.Minute), start.Add(3*time.Hour), time.Hour)
+
+			Convey("Then the stats should be aggregated per hour", func() {
+				So(err, ShouldBeNil)
+				So(len(buckets), ShouldEqual, 3)
+
+				So(buckets[0].Start, ShouldEqual, start)
+				So(buckets[0].Requests, ShouldEqual, 3)
+				So(buckets[0].Recipients, ShouldEqual, 2)
+				So(buckets[0].Failed, ShouldEqual, 1)
+				So(buckets[0].Distributed, ShouldResemble, types.NewCoins(types.NewInt64Coin("uknow", 200)))
+				So(buckets[0].AverageConfirmation, ShouldEqual, 15*time.Second)
+
+				So(buckets[1].Start, ShouldEqual, start.Add(time.Hour))
+				So(buckets[1].Requests, ShouldEqual, 0)
+				So(buckets[1].Distributed.IsZero(), ShouldBeTrue)
+
+				So(buckets[2].Requests, ShouldEqual, 1)
+				So(buckets[2].Recipients, ShouldEqual, 1)
+				So(buckets[2].AverageConfirmation, ShouldEqual, 0)
+			})
+
+			Convey("And the total should cover the whole period", func() {
+				So(total.Start, ShouldEqual, start)
+				So(total.Requests, ShouldEqual, 4)
+				So(total.Recipients, ShouldEqual, 3)
+				So(total.Failed, ShouldEqual, 1)
+				So(total.Distributed, ShouldResemble, types.NewCoins(types.NewInt64Coin("uknow", 200)))
+			})
+		})
+	})
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"okp4/cosmos-faucet/pkg/actor/event"
+	"sync"
+	"time"
+
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "faucet"
+
+type request struct {
+	amount    types.Coins
+	createdAt time.Time
+}
+
+// Metrics maintains the Prometheus counters of the faucet distributions from the events published by the actors.
+type Metrics struct {
+	mu           sync.Mutex
+	pending      map[string]request
+	requests     prometheus.Counter
+	failed       prometheus.Counter
+	distributed  *prometheus.CounterVec
+	confirmation prometheus.Histogram
+}
+
+// New returns the faucet metrics, registered on the given registerer.
+func New(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		pending: map[string]request{},
+		requests: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of fund requests accepted.",
+		}),
+		failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "failed_requests_total",
+			Help:      "Number of fund requests whose transaction failed.",
+		}),
+		distributed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "distributed_total",
+			Help:      "Amount of tokens distributed through confirmed transactions.",
+		}, []string{"denom"}),
+		confirmation: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "confirmation_seconds",
+			Help:      "Duration between the acceptance of a fund request and the confirmation of its transaction.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+	}
+	registerer.MustRegister(m.requests, m.failed, m.distributed, m.confirmation)
+
+	return m
+}
+
+// Subscribe updates the metrics from the events published on the given stream, until unsubscribed.
+func (m *Metrics) Subscribe(stream *eventstream.EventStream) *eventstream.Subscription {
+	return stream.Subscribe(m.handle)
+}
+
+func (m *Metrics) handle(evt interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch evt := evt.(type) {
+	case *event.RequestAccepted:
+		m.requests.Inc()
+		m.pending[evt.RequestID] = request{amount: evt.Amount, createdAt: evt.Time}
+	case *event.TxBroadcast:
+		if evt.Code != 0 {
+			m.fail(evt.RequestIDs)
+		}
+	case *event.TxConfirmed:
+		if evt.Code != 0 {
+			m.fail(evt.RequestIDs)
+			break
+		}
+		for _, id := range evt.RequestIDs {
+			req, ok := m.pending[id]
+			if !ok {
+				continue
+			}
+			for _, coin := range req.amount {
+				m.distributed.WithLabelValues(coin.Denom).Add(float64(coin.Amount.Int64()))
+			}
+			m.confirmation.Observe(evt.Time.Sub(req.createdAt).Seconds())
+			delete(m.pending, id)
+		}
+	case *event.TxFailed:
+		m.fail(evt.RequestIDs)
+	}
+}
+
+func (m *Metrics) fail(ids []string) {
+	m.failed.Add(float64(len(ids)))
+	for _, id := range ids {
+		delete(m.pending, id)
+	}
+}
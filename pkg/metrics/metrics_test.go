@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"okp4/cosmos-faucet/pkg/actor/event"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetrics(t *testing.T) {
+	Convey("Given metrics subscribed to an event stream", t, func() {
+		now := time.Now()
+		stream := eventstream.NewEventStream()
+		m := New(prometheus.NewRegistry())
+		m.Subscribe(stream)
+
+		Convey("When publishing the lifecycle events of fund requests", func() {
+			amount := types.NewCoins(types.NewInt64Coin("uknow", 100))
+			stream.Publish(&event.RequestAccepted{RequestID: "1", Address: "a", Amount: amount, Time: now})
+			stream.Publish(&event.RequestAccepted{RequestID: "2", Address: "b", Amount: amount, Time: now})
+			stream.Publish(&event.RequestAccepted{RequestID: "3", Address: "c", Amount: amount, Time: now})
+			stream.Publish(&event.TxBroadcast{TxHash: "hash", RequestIDs: []string{"1", "2"}, Time: now})
+			stream.Publish(&event.TxConfirmed{TxHash: "hash", RequestIDs: []string{"1", "2"}, Time: now.Add(5 * time.Second)})
+			stream.Publish(&event.TxFailed{Error: "deadline exceeded", RequestIDs: []string{"3"}, Time: now})
+
+			Convey("Then the counters should be updated", func() {
+				So(testutil.ToFloat64(m.requests), ShouldEqual, 3)
+				So(testutil.ToFloat64(m.failed), ShouldEqual, 1)
+				So(testutil.ToFloat64(m.distributed.WithLabelValues("uknow")), ShouldEqual, 200)
+				So(testutil.CollectAndCount(m.confirmation), ShouldEqual, 1)
+				So(m.pending, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,227 @@
+package ownership
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrMissingProof is returned when the ownership proof is required and no signature is given.
+var ErrMissingProof = errors.New("no proof of address ownership specified")
+
+// ErrNoChallenge is returned when no valid challenge has been issued for the address, or it has expired.
+var ErrNoChallenge = errors.New("no pending ownership challenge for address")
+
+// ErrInvalidProof is returned when the signature doesn't prove the ownership of the address.
+var ErrInvalidProof = errors.New("invalid proof of address ownership")
+
+// nonceSize is the number of random bytes of a challenge nonce.
+const nonceSize = 32
+
+// Challenge is a nonce the requester must sign with the key of the address to prove its ownership.
+type Challenge struct {
+	// Nonce to sign, as the data of an ADR-036 offline signature.
+	Nonce string
+	// Address whose ownership must be proven.
+	Address string
+	// ExpiresAt is the time after which the challenge cannot be answered anymore.
+	ExpiresAt time.Time
+}
+
+// Verifier issues challenges and verifies their ADR-036 signatures, each challenge being answered at most once.
+type Verifier struct {
+	mu     sync.Mutex
+	prefix string
+	ttl    time.Duration
+	// challenges are the pending challenges by address, from the oldest to the most recent.
+	challenges map[string][]Challenge
+	// issued are all the pending challenges in the order of their issuance, hence of their expiry.
+	issued []Challenge
+	now    func() time.Time
+}
+
+// NewVerifier returns a Verifier of the addresses having the given prefix, the challenges expiring after the given
+// duration.
+func NewVerifier(prefix string, ttl time.Duration) *Verifier {
+	return &Verifier{
+		prefix:     prefix,
+		ttl:        ttl,
+		challenges: map[string][]Challenge{},
+		now:        time.Now,
+	}
+}
+
+// Issue returns a new challenge for the given address, the challenges previously issued for it remaining valid until
+// they expire or are answered.
+func (v *Verifier) Issue(address string) (Challenge, error) {
+	addr, err := types.GetFromBech32(address, v.prefix)
+	if err != nil {
+		return Challenge{}, err
+	}
+
+	bz := make([]byte, nonceSize)
+	if _, err := rand.Read(bz); err != nil {
+		return Challenge{}, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+	v.expire(now)
+
+	challenge := Challenge{
+		Nonce:     hex.EncodeToString(bz),
+		Address:   v.normalize(addr),
+		ExpiresAt: now.Add(v.ttl),
+	}
+	v.challenges[challenge.Address] = append(v.challenges[challenge.Address], challenge)
+	v.issued = append(v.issued, challenge)
+
+	return challenge, nil
+}
+
+// Verify checks that the given base64 encoded secp256k1 public key derives to the address and that the base64 encoded
+// signature is the ADR-036 signature by this key of one of the challenges issued for the address. Only the answered
+// challenge is consumed, so an invalid proof cannot discard the challenges of the address owner.
+func (v *Verifier) Verify(address, pubKey, signature string) error {
+	if pubKey == "" || signature == "" {
+		return ErrMissingProof
+	}
+
+	addr, err := types.GetFromBech32(address, v.prefix)
+	if err != nil {
+		return err
+	}
+	address = v.normalize(addr)
+
+	v.mu.Lock()
+	v.expire(v.now())
+	challenges := append([]Challenge(nil), v.challenges[address]...)
+	v.mu.Unlock()
+	if len(challenges) == 0 {
+		return ErrNoChallenge
+	}
+
+	pubKeyBz, err := base64.StdEncoding.DecodeString(pubKey)
+	if err != nil || len(pubKeyBz) != secp256k1.PubKeySize {
+		return fmt.Errorf("%w: public key must be a base64 encoded compressed secp256k1 key", ErrInvalidProof)
+	}
+	key := &secp256k1.PubKey{Key: pubKeyBz}
+	if !types.AccAddress(key.Address()).Equals(types.AccAddress(addr)) {
+		return fmt.Errorf("%w: public key doesn't derive to the address", ErrInvalidProof)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature must be base64 encoded", ErrInvalidProof)
+	}
+
+	for _, challenge := range challenges {
+		signBytes, err := SignBytes(address, []byte(challenge.Nonce))
+		if err != nil {
+			return err
+		}
+		if key.VerifySignature(signBytes, sig) {
+			return v.consume(challenge)
+		}
+	}
+
+	return fmt.Errorf("%w: signature doesn't match any challenge", ErrInvalidProof)
+}
+
+// normalize returns the canonical encoding of the address, under which its challenges are kept and signed.
+func (v *Verifier) normalize(addr []byte) string {
+	return types.MustBech32ifyAddressBytes(v.prefix, addr)
+}
+
+// consume removes the given answered challenge, returning ErrNoChallenge if it has already been answered meanwhile.
+func (v *Verifier) consume(answered Challenge) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	challenges := v.challenges[answered.Address]
+	for i, challenge := range challenges {
+		if challenge.Nonce != answered.Nonce {
+			continue
+		}
+
+		if len(challenges) == 1 {
+			delete(v.challenges, answered.Address)
+		} else {
+			v.challenges[answered.Address] = append(challenges[:i:i], challenges[i+1:]...)
+		}
+		return nil
+	}
+
+	return ErrNoChallenge
+}
+
+// expire drops the challenges expired at the given time, which are the first issued ones.
+func (v *Verifier) expire(now time.Time) {
+	n := 0
+	for ; n < len(v.issued) && !now.Before(v.issued[n].ExpiresAt); n++ {
+		address := v.issued[n].Address
+		challenges := v.challenges[address]
+		for len(challenges) > 0 && !now.Before(challenges[0].ExpiresAt) {
+			challenges = challenges[1:]
+		}
+		if len(challenges) == 0 {
+			delete(v.challenges, address)
+		} else {
+			v.challenges[address] = challenges
+		}
+	}
+	v.issued = v.issued[n:]
+}
+
+// signDoc is the amino JSON sign document of an ADR-036 offline signature, its fields being declared in the
+// alphabetical order of their JSON name so it is serialized in its canonical form.
+type signDoc struct {
+	AccountNumber string    `json:"account_number"`
+	ChainID       string    `json:"chain_id"`
+	Fee           signFee   `json:"fee"`
+	Memo          string    `json:"memo"`
+	Msgs          []signMsg `json:"msgs"`
+	Sequence      string    `json:"sequence"`
+}
+
+type signFee struct {
+	Amount []struct{} `json:"amount"`
+	Gas    string     `json:"gas"`
+}
+
+type signMsg struct {
+	Type  string        `json:"type"`
+	Value signDataValue `json:"value"`
+}
+
+type signDataValue struct {
+	Data   string `json:"data"`
+	Signer string `json:"signer"`
+}
+
+// SignBytes returns the bytes signed by the given signer for the given data following ADR-036, i.e. the sign document
+// of a `sign/MsgSignData` message with an empty chain ID, a zero account number, sequence and fee.
+func SignBytes(signer string, data []byte) ([]byte, error) {
+	return json.Marshal(signDoc{
+		AccountNumber: "0",
+		Fee:           signFee{Amount: []struct{}{}, Gas: "0"},
+		Msgs: []signMsg{{
+			Type: "sign/MsgSignData",
+			Value: signDataValue{
+				Data:   base64.StdEncoding.EncodeToString(data),
+				Signer: signer,
+			},
+		}},
+		Sequence: "0",
+	})
+}
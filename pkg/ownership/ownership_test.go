@@ -0,0 +1,129 @@
+package ownership
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const prefix = "okp4"
+
+func TestSignBytes(t *testing.T) {
+	Convey("When building the sign bytes of some data", t, func() {
+		bz, err := SignBytes("okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27", []byte("nonce"))
+
+		Convey("Then they should be the canonical ADR-036 sign document", func() {
+			So(err, ShouldBeNil)
+			So(string(bz), ShouldEqual, `{"account_number":"0","chain_id":"","fee":{"amount":[],"gas":"0"},"memo":"",`+
+				`"msgs":[{"type":"sign/MsgSignData","value":{"data":"bm9uY2U=",`+
+				`"signer":"okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27"}}],"sequence":"0"}`)
+		})
+	})
+}
+
+func TestVerify(t *testing.T) {
+	Convey("Given a verifier and a key", t, func() {
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		verifier := NewVerifier(prefix, time.Minute)
+		verifier.now = func() time.Time { return now }
+
+		privKey := secp256k1.GenPrivKey()
+		address, err := bech32.ConvertAndEncode(prefix, privKey.PubKey().Address())
+		So(err, ShouldBeNil)
+		pubKey := base64.StdEncoding.EncodeToString(privKey.PubKey().Bytes())
+
+		sign := func(signer, nonce string) string {
+			bz, err := SignBytes(signer, []byte(nonce))
+			So(err, ShouldBeNil)
+			sig, err := privKey.Sign(bz)
+			So(err, ShouldBeNil)
+			return base64.StdEncoding.EncodeToString(sig)
+		}
+
+		Convey("When a challenge is issued for the address", func() {
+			challenge, err := verifier.Issue(address)
+			So(err, ShouldBeNil)
+			So(challenge.Address, ShouldEqual, address)
+			So(challenge.ExpiresAt, ShouldEqual, now.Add(time.Minute))
+
+			Convey("Then its signature should prove the ownership, only once", func() {
+				signature := sign(address, challenge.Nonce)
+				So(verifier.Verify(address, pubKey, signature), ShouldBeNil)
+				So(verifier.Verify(address, pubKey, signature), ShouldEqual, ErrNoChallenge)
+			})
+
+			Convey("Then the signature of another nonce should be rejected", func() {
+				err := verifier.Verify(address, pubKey, sign(address, "other"))
+				So(errors.Is(err, ErrInvalidProof), ShouldBeTrue)
+			})
+
+			Convey("Then the key of another address should be rejected", func() {
+				otherKey := base64.StdEncoding.EncodeToString(secp256k1.GenPrivKey().PubKey().Bytes())
+				err := verifier.Verify(address, otherKey, sign(address, challenge.Nonce))
+				So(errors.Is(err, ErrInvalidProof), ShouldBeTrue)
+			})
+
+			Convey("Then an invalid signature should not consume the challenge", func() {
+				err := verifier.Verify(address, pubKey, sign(address, "other"))
+				So(errors.Is(err, ErrInvalidProof), ShouldBeTrue)
+				So(verifier.Verify(address, pubKey, sign(address, challenge.Nonce)), ShouldBeNil)
+			})
+
+			Convey("Then issuing another challenge should not discard it", func() {
+				other, err := verifier.Issue(address)
+				So(err, ShouldBeNil)
+				So(other.Nonce, ShouldNotEqual, challenge.Nonce)
+				So(verifier.Verify(address, pubKey, sign(address, challenge.Nonce)), ShouldBeNil)
+				So(verifier.Verify(address, pubKey, sign(address, other.Nonce)), ShouldBeNil)
+				So(verifier.Verify(address, pubKey, sign(address, other.Nonce)), ShouldEqual, ErrNoChallenge)
+			})
+
+			Convey("Then it should be answered whatever the case of the address", func() {
+				upper := strings.ToUpper(address)
+				So(verifier.Verify(upper, pubKey, sign(address, challenge.Nonce)), ShouldBeNil)
+			})
+
+			Convey("Then a missing signature should be rejected", func() {
+				So(verifier.Verify(address, pubKey, ""), ShouldEqual, ErrMissingProof)
+			})
+
+			Convey("Then an expired challenge should be rejected and dropped", func() {
+				now = now.Add(time.Minute)
+				So(verifier.Verify(address, pubKey, sign(address, challenge.Nonce)), ShouldEqual, ErrNoChallenge)
+				So(verifier.challenges, ShouldBeEmpty)
+				So(verifier.issued, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When no challenge is issued for the address", func() {
+			Convey("Then any signature should be rejected", func() {
+				So(verifier.Verify(address, pubKey, sign(address, "nonce")), ShouldEqual, ErrNoChallenge)
+			})
+		})
+
+		Convey("When a challenge is issued for the address in upper case", func() {
+			challenge, err := verifier.Issue(strings.ToUpper(address))
+			So(err, ShouldBeNil)
+
+			Convey("Then it should be issued for the canonical address", func() {
+				So(challenge.Address, ShouldEqual, address)
+				So(verifier.Verify(address, pubKey, sign(address, challenge.Nonce)), ShouldBeNil)
+			})
+		})
+
+		Convey("When issuing a challenge for an address with another prefix", func() {
+			_, err := verifier.Issue(types.AccAddress(privKey.PubKey().Address()).String())
+
+			Convey("Then it should fail", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,120 @@
+package queue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	entriesBucket = []byte("entries")
+	indexBucket   = []byte("index")
+)
+
+type boltQueue struct {
+	db *bolt.DB
+}
+
+// NewBoltQueue returns a Queue persisted in the embedded bolt database file at the given path, creating it if needed.
+func NewBoltQueue(path string) (Queue, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{entriesBucket, indexBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &boltQueue{db: db}, nil
+}
+
+func (q *boltQueue) Push(entry Entry) error {
+	bz, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return q.db.Update(func(tx *bolt.Tx) error {
+		entries := tx.Bucket(entriesBucket)
+		seq, err := entries.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		// Entries are keyed by sequence to preserve the insertion order, the index allowing to find them by ID.
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := entries.Put(key, bz); err != nil {
+			return err
+		}
+		return tx.Bucket(indexBucket).Put([]byte(entry.ID), key)
+	})
+}
+
+func (q *boltQueue) Update(entry Entry) error {
+	bz, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return q.db.Update(func(tx *bolt.Tx) error {
+		key := tx.Bucket(indexBucket).Get([]byte(entry.ID))
+		if key == nil {
+			return nil
+		}
+		return tx.Bucket(entriesBucket).Put(key, bz)
+	})
+}
+
+func (q *boltQueue) Done(ids ...string) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		entries, index := tx.Bucket(entriesBucket), tx.Bucket(indexBucket)
+		for _, id := range ids {
+			key := index.Get([]byte(id))
+			if key == nil {
+				continue
+			}
+			if err := entries.Delete(key); err != nil {
+				return err
+			}
+			if err := index.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (q *boltQueue) Pending() ([]Entry, error) {
+	var pending []Entry
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(_, v []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			pending = append(pending, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func (q *boltQueue) Close() error {
+	return q.db.Close()
+}
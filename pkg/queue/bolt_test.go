@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBoltQueue(t *testing.T) {
+	Convey("Given an empty queue", t, func() {
+		path := filepath.Join(t.TempDir(), "queue.db")
+		queue, err := NewBoltQueue(path)
+		So(err, ShouldBeNil)
+		defer func() { _ = queue.Close() }()
+
+		Convey("Then there should be no pending entries", func() {
+			pending, err := queue.Pending()
+			So(err, ShouldBeNil)
+			So(pending, ShouldBeEmpty)
+		})
+
+		Convey("When pushing entries", func() {
+			entries := []Entry{
+				{ID: "3", Address: []byte("addr3"), CreatedAt: time.Now().UTC().Truncate(time.Second)},
+				{ID: "1", Address: []byte("addr1"), RequesterIP: "127.0.0.1", Captcha: "verified"},
+				{ID: "2", Address: []byte("addr2")},
+			}
+			for _, entry := range entries {
+				So(queue.Push(entry), ShouldBeNil)
+			}
+
+			Convey("Then they should be pending in their insertion order", func() {
+				pending, err := queue.Pending()
+				So(err, ShouldBeNil)
+				So(pending, ShouldResemble, entries)
+			})
+
+			Convey("And marking some of them as done", func() {
+				So(queue.Done("1", "unknown", "3"), ShouldBeNil)
+
+				Convey("Then only the others should remain pending", func() {
+					pending, err := queue.Pending()
+					So(err, ShouldBeNil)
+					So(pending, ShouldResemble, entries[2:])
+				})
+			})
+
+			Convey("And updating some of them", func() {
+				updated := entries[1]
+				updated.Attempts = 2
+				So(queue.Update(updated), ShouldBeNil)
+				So(queue.Update(Entry{ID: "unknown"}), ShouldBeNil)
+
+				Convey("Then they should be updated in place", func() {
+					pending, err := queue.Pending()
+					So(err, ShouldBeNil)
+					So(pending, ShouldResemble, []Entry{entries[0], updated, entries[2]})
+				})
+			})
+
+			Convey("And reopening the queue", func() {
+				So(queue.Close(), ShouldBeNil)
+				queue, err = NewBoltQueue(path)
+				So(err, ShouldBeNil)
+
+				Convey("Then the entries should still be pending", func() {
+					pending, err := queue.Pending()
+					So(err, ShouldBeNil)
+					So(pending, ShouldResemble, entries)
+				})
+			})
+		})
+	})
+}
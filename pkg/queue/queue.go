@@ -0,0 +1,51 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// Entry represents a pending fund request.
+type Entry struct {
+	// ID uniquely identifies the fund request.
+	ID string `json:"id"`
+
+	// Address on which to send requested funds.
+	Address []byte `json:"address"`
+
+	// Amount to send, as resolved when the request has been received. The current faucet amount is sent if empty, e.g.
+	// for the entries queued by former versions.
+	Amount types.Coins `json:"amount,omitempty"`
+
+	// RequesterIP is the IP address of the requester, if known.
+	RequesterIP string `json:"requesterIp,omitempty"`
+
+	// Captcha describes the outcome of the captcha verification, empty if no verification took place.
+	Captcha string `json:"captcha,omitempty"`
+
+	// CreatedAt is the time at which the request has been received.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Attempts is the number of failed transactions the request has been included in.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// Queue is a write-ahead log of the fund requests, keeping them until their successful submission so they can be
+// replayed after a restart.
+type Queue interface {
+	// Push appends an entry to the queue.
+	Push(entry Entry) error
+
+	// Update replaces the entry having the same ID, keeping its position in the queue, unknown IDs being ignored.
+	Update(entry Entry) error
+
+	// Done removes the entries with the given IDs from the queue, unknown IDs being ignored.
+	Done(ids ...string) error
+
+	// Pending returns the entries still in the queue, in their insertion order.
+	Pending() ([]Entry, error)
+
+	// Close releases the resources held by the queue.
+	Close() error
+}
@@ -0,0 +1,202 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrFlagged is returned when the screening service flags the address.
+var ErrFlagged = errors.New("address is flagged by screening")
+
+// ErrUnavailable is returned when the screening service cannot be reached and the client fails closed.
+var ErrUnavailable = errors.New("address screening is unavailable")
+
+// Request is the JSON body POSTed to the screening service.
+type Request struct {
+	Address string `json:"address"`
+}
+
+// Response is the JSON body expected from the screening service.
+type Response struct {
+	// Flagged tells whether the address must not be funded.
+	Flagged bool `json:"flagged"`
+	// Reason optionally describes why the address is flagged.
+	Reason string `json:"reason,omitempty"`
+}
+
+// result is a screening result held in the cache.
+type result struct {
+	response  Response
+	expiresAt time.Time
+}
+
+// Client screens the recipient addresses against an external compliance service, caching its results.
+type Client struct {
+	url        string
+	token      string
+	timeout    time.Duration
+	failOpen   bool
+	cacheTTL   time.Duration
+	httpClient *http.Client
+	mu         sync.Mutex
+	cache      map[string]result
+	now        func() time.Time
+}
+
+// Option configures the Client.
+type Option func(*Client)
+
+// NewClient returns a client POSTing the addresses to screen to the given URL.
+func NewClient(url string, opts ...Option) *Client {
+	client := &Client{
+		url:        url,
+		timeout:    2 * time.Second,
+		httpClient: http.DefaultClient,
+		cache:      map[string]result{},
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// WithToken sets the bearer token authenticating the faucet to the screening service.
+func WithToken(token string) Option {
+	return func(client *Client) {
+		client.token = token
+	}
+}
+
+// WithTimeout sets the maximum duration to wait for the screening service.
+func WithTimeout(timeout time.Duration) Option {
+	return func(client *Client) {
+		client.timeout = timeout
+	}
+}
+
+// WithFailOpen makes the addresses accepted when the screening service cannot be reached, instead of rejected.
+func WithFailOpen(failOpen bool) Option {
+	return func(client *Client) {
+		client.failOpen = failOpen
+	}
+}
+
+// WithCacheTTL sets the duration during which the result of a screening is reused, nothing being cached if 0.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(client *Client) {
+		client.cacheTTL = ttl
+	}
+}
+
+// WithHTTPClient sets the client calling the screening service.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// Screen returns ErrFlagged if the screening service flags the given address. When the service cannot be reached,
+// ErrUnavailable is returned unless the client fails open.
+func (c *Client) Screen(ctx context.Context, address string) error {
+	resp, ok := c.cached(address)
+	if !ok {
+		var err error
+		resp, err = c.call(ctx, address)
+		if err != nil {
+			if c.failOpen {
+				log.Warn().Err(err).Str("address", address).Msg("😥 Could not screen address, accepting it")
+				return nil
+			}
+			log.Err(err).Str("address", address).Msg("❌ Could not screen address, rejecting it")
+			return fmt.Errorf("%w: %s", ErrUnavailable, err)
+		}
+		c.store(address, resp)
+	}
+
+	if resp.Flagged {
+		if resp.Reason != "" {
+			return fmt.Errorf("%w: %s", ErrFlagged, resp.Reason)
+		}
+		return ErrFlagged
+	}
+	return nil
+}
+
+func (c *Client) cached(address string) (Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[address]
+	if !ok {
+		return Response{}, false
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.cache, address)
+		return Response{}, false
+	}
+	return entry.response, true
+}
+
+func (c *Client) store(address string, resp Response) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for addr, entry := range c.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(c.cache, addr)
+		}
+	}
+	c.cache[address] = result{response: resp, expiresAt: now.Add(c.cacheTTL)}
+}
+
+func (c *Client) call(ctx context.Context, address string) (Response, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(Request{Address: address})
+	if err != nil {
+		return Response{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Response{}, fmt.Errorf("screening service responded with status %d", resp.StatusCode)
+	}
+
+	var screening Response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&screening); err != nil {
+		return Response{}, fmt.Errorf("could not decode screening response: %w", err)
+	}
+	return screening, nil
+}
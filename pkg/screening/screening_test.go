@@ -0,0 +1,118 @@
+package screening
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newScreeningServer returns a local stand-in of the screening service flagging the given address, counting the
+// calls it receives and delaying its responses by the given duration.
+func newScreeningServer(flagged string, delay time.Duration) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		resp := Response{}
+		if req.Address == flagged {
+			resp = Response{Flagged: true, Reason: "sanctioned"}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	return server, &calls
+}
+
+func TestClient(t *testing.T) {
+	Convey("Given a client of a screening service caching its results for an hour", t, func() {
+		server, calls := newScreeningServer("okp41flagged", 0)
+		defer server.Close()
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		client := NewClient(server.URL, WithToken("token"), WithCacheTTL(time.Hour))
+		client.now = func() time.Time { return now }
+
+		Convey("Then a flagged address should be rejected with the reason", func() {
+			err := client.Screen(context.Background(), "okp41flagged")
+			So(errors.Is(err, ErrFlagged), ShouldBeTrue)
+			So(err.Error(), ShouldContainSubstring, "sanctioned")
+		})
+
+		Convey("Then a clean address should be accepted", func() {
+			So(client.Screen(context.Background(), "okp41clean"), ShouldBeNil)
+		})
+
+		Convey("When screening the same addresses again", func() {
+			So(client.Screen(context.Background(), "okp41clean"), ShouldBeNil)
+			So(client.Screen(context.Background(), "okp41flagged"), ShouldNotBeNil)
+			So(client.Screen(context.Background(), "okp41clean"), ShouldBeNil)
+			So(errors.Is(client.Screen(context.Background(), "okp41flagged"), ErrFlagged), ShouldBeTrue)
+
+			Convey("Then the cached results should be used", func() {
+				So(atomic.LoadInt32(calls), ShouldEqual, 2)
+			})
+
+			Convey("Then the service should be called again once the results expired", func() {
+				now = now.Add(time.Hour)
+				So(client.Screen(context.Background(), "okp41clean"), ShouldBeNil)
+				So(atomic.LoadInt32(calls), ShouldEqual, 3)
+			})
+		})
+	})
+
+	Convey("Given a screening service not responding in time", t, func() {
+		server, _ := newScreeningServer("", time.Second)
+		defer server.Close()
+
+		Convey("When the client fails closed", func() {
+			client := NewClient(server.URL, WithToken("token"), WithTimeout(50*time.Millisecond))
+
+			Convey("Then the address should be rejected", func() {
+				So(errors.Is(client.Screen(context.Background(), "okp41clean"), ErrUnavailable), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the client fails open", func() {
+			client := NewClient(server.URL, WithToken("token"), WithTimeout(50*time.Millisecond), WithFailOpen(true))
+
+			Convey("Then the address should be accepted", func() {
+				So(client.Screen(context.Background(), "okp41clean"), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a screening service refusing the requests", t, func() {
+		server, calls := newScreeningServer("", 0)
+		defer server.Close()
+		client := NewClient(server.URL, WithCacheTTL(time.Hour))
+
+		Convey("When screening an address twice", func() {
+			err := client.Screen(context.Background(), "okp41clean")
+			_ = client.Screen(context.Background(), "okp41clean")
+
+			Convey("Then it should be rejected without the failure being cached", func() {
+				So(errors.Is(err, ErrUnavailable), ShouldBeTrue)
+				So(atomic.LoadInt32(calls), ShouldEqual, 2)
+			})
+		})
+	})
+}
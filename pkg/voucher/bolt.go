@@ -0,0 +1,101 @@
+package voucher
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var vouchersBucket = []byte("vouchers")
+
+type boltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore returns a Store persisting vouchers in the embedded bolt database file at the given path, creating it if
+// needed.
+func NewBoltStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(vouchersBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Put(vouchers ...Voucher) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, voucher := range vouchers {
+			if err := put(tx, voucher); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) Redeem(code, address, requestID string, now time.Time) (*Voucher, error) {
+	var voucher Voucher
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bz := tx.Bucket(vouchersBucket).Get([]byte(code))
+		if bz == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(bz, &voucher); err != nil {
+			return err
+		}
+		if err := redeem(&voucher, address, requestID, now); err != nil {
+			return err
+		}
+		return put(tx, voucher)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &voucher, nil
+}
+
+func (s *boltStore) List(batch string) ([]Voucher, error) {
+	var vouchers []Voucher
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(vouchersBucket).ForEach(func(_, v []byte) error {
+			var voucher Voucher
+			if err := json.Unmarshal(v, &voucher); err != nil {
+				return err
+			}
+			if batch == "" || voucher.Batch == batch {
+				vouchers = append(vouchers, voucher)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByCreation(vouchers)
+	return vouchers, nil
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func put(tx *bolt.Tx, voucher Voucher) error {
+	bz, err := json.Marshal(voucher)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(vouchersBucket).Put([]byte(voucher.Code), bz)
+}
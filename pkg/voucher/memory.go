@@ -0,0 +1,60 @@
+package voucher
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu       sync.RWMutex
+	vouchers map[string]Voucher
+}
+
+// NewMemoryStore returns a Store keeping the vouchers in memory, which are lost once the process exits.
+func NewMemoryStore() Store {
+	return &memoryStore{vouchers: map[string]Voucher{}}
+}
+
+func (s *memoryStore) Put(vouchers ...Voucher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, voucher := range vouchers {
+		s.vouchers[voucher.Code] = voucher
+	}
+	return nil
+}
+
+func (s *memoryStore) Redeem(code, address, requestID string, now time.Time) (*Voucher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	voucher, ok := s.vouchers[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := redeem(&voucher, address, requestID, now); err != nil {
+		return nil, err
+	}
+	s.vouchers[code] = voucher
+
+	return &voucher, nil
+}
+
+func (s *memoryStore) List(batch string) ([]Voucher, error) {
+	s.mu.RLock()
+	vouchers := make([]Voucher, 0, len(s.vouchers))
+	for _, voucher := range s.vouchers {
+		if batch == "" || voucher.Batch == batch {
+			vouchers = append(vouchers, voucher)
+		}
+	}
+	s.mu.RUnlock()
+
+	sortByCreation(vouchers)
+	return vouchers, nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
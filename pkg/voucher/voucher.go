@@ -0,0 +1,138 @@
+package voucher
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+)
+
+// ErrNotFound is returned when no voucher matches the given code.
+var ErrNotFound = errors.New("unknown voucher code")
+
+// ErrRedeemed is returned when redeeming a voucher already redeemed.
+var ErrRedeemed = errors.New("voucher already redeemed")
+
+// ErrExpired is returned when redeeming a voucher after its expiry.
+var ErrExpired = errors.New("voucher expired")
+
+// codeSize is the number of random bytes of a voucher code, encoded as 16 base32 characters.
+const codeSize = 10
+
+// codeGroupSize is the number of characters of the dash separated groups of a voucher code.
+const codeGroupSize = 4
+
+// Voucher represents a single use code unlocking an amount of token.
+type Voucher struct {
+	// Code redeemed to get the funds.
+	Code string `json:"code"`
+
+	// Batch is the name of the batch the voucher has been generated with, if any.
+	Batch string `json:"batch,omitempty"`
+
+	// Amount of funds unlocked by the voucher.
+	Amount types.Coins `json:"amount"`
+
+	// ExpiresAt is the time after which the voucher cannot be redeemed anymore.
+	ExpiresAt time.Time `json:"expiresAt"`
+
+	// CreatedAt is the time at which the voucher has been generated.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// RedeemedAt is the time at which the voucher has been redeemed, if so.
+	RedeemedAt *time.Time `json:"redeemedAt,omitempty"`
+
+	// Address to which the funds have been sent once redeemed.
+	Address string `json:"address,omitempty"`
+
+	// RequestID is the identifier of the fund request made once redeemed.
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// Store is a persistent storage of the vouchers.
+type Store interface {
+	// Put inserts the given vouchers, or replaces the ones with the same code.
+	Put(vouchers ...Voucher) error
+
+	// Redeem atomically marks the voucher having the given code as redeemed for the given address and fund request,
+	// returning ErrNotFound, ErrRedeemed or ErrExpired if it cannot be redeemed.
+	Redeem(code, address, requestID string, now time.Time) (*Voucher, error)
+
+	// List returns the vouchers of the given batch, or all of them if empty, from the oldest to the most recent.
+	List(batch string) ([]Voucher, error)
+
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// Generate returns the given number of new vouchers unlocking the given amount until the given expiry.
+func Generate(count int, amount types.Coins, expiresAt time.Time, batch string) ([]Voucher, error) {
+	now := time.Now().UTC()
+	vouchers := make([]Voucher, 0, count)
+	for i := 0; i < count; i++ {
+		code, err := newCode()
+		if err != nil {
+			return nil, err
+		}
+
+		vouchers = append(vouchers, Voucher{
+			Code:      code,
+			Batch:     batch,
+			Amount:    amount,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		})
+	}
+
+	return vouchers, nil
+}
+
+// NormalizeCode returns the canonical form of a code as typed by a user, ignoring the case and surrounding spaces.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newCode returns a random code made of dash separated groups of characters, e.g. `K3QX-7HZD-M2PA-W5TB`.
+func newCode() (string, error) {
+	bz := make([]byte, codeSize)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(bz)
+	groups := make([]string, 0, len(encoded)/codeGroupSize)
+	for i := 0; i < len(encoded); i += codeGroupSize {
+		groups = append(groups, encoded[i:i+codeGroupSize])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// redeem checks the given voucher can be redeemed and marks it as redeemed.
+func redeem(voucher *Voucher, address, requestID string, now time.Time) error {
+	if voucher.RedeemedAt != nil {
+		return ErrRedeemed
+	}
+	if !now.Before(voucher.ExpiresAt) {
+		return ErrExpired
+	}
+
+	voucher.RedeemedAt = &now
+	voucher.Address = address
+	voucher.RequestID = requestID
+	return nil
+}
+
+// sortByCreation sorts the given vouchers from the oldest to the most recent.
+func sortByCreation(vouchers []Voucher) {
+	sort.Slice(vouchers, func(i, j int) bool {
+		if vouchers[i].CreatedAt.Equal(vouchers[j].CreatedAt) {
+			return vouchers[i].Code < vouchers[j].Code
+		}
+		return vouchers[i].CreatedAt.Before(vouchers[j].CreatedAt)
+	})
+}
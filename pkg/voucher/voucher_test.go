@@ -0,0 +1,111 @@
+package voucher
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var amount = types.NewCoins(types.NewInt64Coin("uknow", 50000000))
+
+func TestGenerate(t *testing.T) {
+	Convey("When generating vouchers", t, func() {
+		expiresAt := time.Now().Add(time.Hour)
+		vouchers, err := Generate(20, amount, expiresAt, "workshop")
+		So(err, ShouldBeNil)
+
+		Convey("Then they should have distinct readable codes", func() {
+			So(len(vouchers), ShouldEqual, 20)
+			codes := map[string]bool{}
+			for _, voucher := range vouchers {
+				So(voucher.Code, ShouldEqual, NormalizeCode(voucher.Code))
+				So(regexp.MustCompile(`^[A-Z2-7]{4}(-[A-Z2-7]{4}){3}$`).MatchString(voucher.Code), ShouldBeTrue)
+				So(voucher.Amount, ShouldResemble, amount)
+				So(voucher.ExpiresAt, ShouldEqual, expiresAt)
+				So(voucher.Batch, ShouldEqual, "workshop")
+				codes[voucher.Code] = true
+			}
+			So(len(codes), ShouldEqual, 20)
+		})
+	})
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func() Store{
+		"memory": NewMemoryStore,
+		"bolt": func() Store {
+			store, err := NewBoltStore(filepath.Join(t.TempDir(), "vouchers.db"))
+			if err != nil {
+				panic(err)
+			}
+			return store
+		},
+	}
+
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	for name, newStore := range stores {
+		Convey("Given a "+name+" store containing vouchers", t, func() {
+			store := newStore()
+			defer store.Close()
+
+			So(store.Put(
+				Voucher{Code: "VALID", Batch: "workshop", Amount: amount, ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+				Voucher{Code: "EXPIRED", Batch: "hackathon", Amount: amount, ExpiresAt: now, CreatedAt: now.Add(-time.Hour)},
+			), ShouldBeNil)
+
+			Convey("When redeeming a valid voucher", func() {
+				voucher, err := store.Redeem("VALID", "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27", "request", now)
+
+				Convey("Then it should be redeemed", func() {
+					So(err, ShouldBeNil)
+					So(voucher.Amount, ShouldResemble, amount)
+					So(*voucher.RedeemedAt, ShouldEqual, now)
+					So(voucher.RequestID, ShouldEqual, "request")
+				})
+
+				Convey("Then it should not be redeemed twice", func() {
+					_, err := store.Redeem("VALID", "okp41pmkq300lrngpkeprygfrtag0xpgp9z92c7eskm", "other", now)
+					So(err, ShouldEqual, ErrRedeemed)
+				})
+			})
+
+			Convey("When redeeming an expired voucher", func() {
+				_, err := store.Redeem("EXPIRED", "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27", "request", now)
+
+				Convey("Then it should be refused", func() {
+					So(err, ShouldEqual, ErrExpired)
+
+					vouchers, err := store.List("hackathon")
+					So(err, ShouldBeNil)
+					So(vouchers[0].RedeemedAt, ShouldBeNil)
+				})
+			})
+
+			Convey("When redeeming an unknown voucher", func() {
+				_, err := store.Redeem("UNKNOWN", "okp41rhd8744u4vqvcjuvyfm8fea4k9mefe3k57qz27", "request", now)
+
+				Convey("Then it should be refused", func() {
+					So(err, ShouldEqual, ErrNotFound)
+				})
+			})
+
+			Convey("When listing the vouchers", func() {
+				all, err := store.List("")
+				So(err, ShouldBeNil)
+				batch, err := store.List("workshop")
+				So(err, ShouldBeNil)
+
+				Convey("Then they should be returned from the oldest, optionally restricted to a batch", func() {
+					So(len(all), ShouldEqual, 2)
+					So(all[0].Code, ShouldEqual, "EXPIRED")
+					So(len(batch), ShouldEqual, 1)
+					So(batch[0].Code, ShouldEqual, "VALID")
+				})
+			})
+		})
+	}
+}
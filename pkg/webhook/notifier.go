@@ -0,0 +1,298 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"okp4/cosmos-faucet/pkg/actor/event"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// queueSize is the number of notifications buffered per endpoint, the notifications being dropped once full so a
+	// slow endpoint cannot block the actors publishing the events.
+	queueSize = 256
+	// requestTimeout is the maximum duration of a single delivery attempt.
+	requestTimeout = 10 * time.Second
+	// maxBackoff bounds the delay between two delivery attempts.
+	maxBackoff = time.Minute
+)
+
+// delivery is a notification waiting to be delivered to an endpoint.
+type delivery struct {
+	eventType string
+	body      []byte
+}
+
+// Notifier notifies the configured endpoints of the events published by the actors, each endpoint being delivered
+// its notifications in order by a dedicated worker retrying the failed deliveries with an exponential backoff.
+type Notifier struct {
+	endpoints  []Endpoint
+	queues     []chan delivery
+	client     *http.Client
+	attempts   int
+	backoff    time.Duration
+	lowBalance types.Coins
+	now        func() time.Time
+
+	mu      sync.Mutex
+	closed  bool
+	pending map[string]Request
+	low     map[string]bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option configures the Notifier.
+type Option func(*Notifier)
+
+// WithRetries sets the number of delivery attempts of a notification and the delay before the first retry, doubled at
+// each attempt.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(n *Notifier) {
+		n.attempts = attempts
+		n.backoff = backoff
+	}
+}
+
+// WithLowBalance sets the balances under which a low_balance event is notified, once until the balance gets back
+// above its threshold.
+func WithLowBalance(threshold types.Coins) Option {
+	return func(n *Notifier) {
+		n.lowBalance = threshold
+	}
+}
+
+// WithHTTPClient sets the client delivering the notifications.
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = client
+	}
+}
+
+// NewNotifier returns a notifier delivering the events to the given endpoints until closed.
+func NewNotifier(endpoints []Endpoint, opts ...Option) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		endpoints: endpoints,
+		client:    &http.Client{Timeout: requestTimeout},
+		attempts:  5,
+		backoff:   time.Second,
+		now:       time.Now,
+		pending:   map[string]Request{},
+		low:       map[string]bool{},
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	for _, endpoint := range endpoints {
+		queue := make(chan delivery, queueSize)
+		n.queues = append(n.queues, queue)
+		n.wg.Add(1)
+		go n.work(endpoint, queue)
+	}
+
+	return n
+}
+
+// Subscribe notifies the endpoints of the events published on the given stream, until unsubscribed.
+func (n *Notifier) Subscribe(stream *eventstream.EventStream) *eventstream.Subscription {
+	return stream.Subscribe(n.handle)
+}
+
+// Close stops the delivery of the notifications, the pending ones being dropped.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+
+	n.cancel()
+	for _, queue := range n.queues {
+		close(queue)
+	}
+	n.wg.Wait()
+}
+
+func (n *Notifier) handle(evt interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return
+	}
+	for _, e := range n.toEvents(evt) {
+		n.notify(e)
+	}
+}
+
+// toEvents maps the events published by the actors to the notified events, tracking the fund requests to describe
+// the transactions including them. The lock must be held.
+func (n *Notifier) toEvents(evt interface{}) []Event {
+	switch evt := evt.(type) {
+	case *event.RequestAccepted:
+		n.pending[evt.RequestID] = Request{ID: evt.RequestID, Address: evt.Address, Amount: evt.Amount}
+	case *event.TxBroadcast:
+		if evt.Code != 0 {
+			return []Event{n.newEvent(TypeTxFailed, evt.Time, TxFailure{
+				TxHash:   evt.TxHash,
+				Code:     evt.Code,
+				Requests: n.take(evt.RequestIDs),
+			})}
+		}
+	case *event.TxConfirmed:
+		if evt.Code != 0 {
+			return []Event{n.newEvent(TypeTxFailed, evt.Time, TxFailure{
+				TxHash:   evt.TxHash,
+				Code:     evt.Code,
+				Requests: n.take(evt.RequestIDs),
+			})}
+		}
+		return []Event{n.newEvent(TypeDistribution, evt.Time, Distribution{
+			TxHash:   evt.TxHash,
+			Height:   evt.Height,
+			Requests: n.take(evt.RequestIDs),
+		})}
+	case *event.TxFailed:
+		return []Event{n.newEvent(TypeTxFailed, evt.Time, TxFailure{
+			Error:    evt.Error,
+			Requests: n.take(evt.RequestIDs),
+		})}
+	case *event.FaucetPaused:
+		return []Event{n.newEvent(TypeFaucetPaused, evt.Time, nil)}
+	case *event.FaucetResumed:
+		return []Event{n.newEvent(TypeFaucetResumed, evt.Time, nil)}
+	case *event.BalancesRefreshed:
+		var events []Event
+		for _, threshold := range n.lowBalance {
+			balance := types.NewCoin(threshold.Denom, evt.Balances.AmountOf(threshold.Denom))
+			if !balance.IsLT(threshold) {
+				delete(n.low, threshold.Denom)
+				continue
+			}
+			if !n.low[threshold.Denom] {
+				n.low[threshold.Denom] = true
+				events = append(events, n.newEvent(TypeLowBalance, evt.Time, LowBalance{
+					Balance:   balance,
+					Threshold: threshold,
+				}))
+			}
+		}
+		return events
+	}
+	return nil
+}
+
+// take returns the description of the given fund requests, no longer tracked. The lock must be held.
+func (n *Notifier) take(ids []string) []Request {
+	requests := make([]Request, 0, len(ids))
+	for _, id := range ids {
+		request, ok := n.pending[id]
+		if !ok {
+			request = Request{ID: id}
+		}
+		requests = append(requests, request)
+		delete(n.pending, id)
+	}
+	return requests
+}
+
+func (n *Notifier) newEvent(eventType string, at time.Time, data interface{}) Event {
+	return Event{ID: uuid.NewString(), Type: eventType, Time: at.UTC(), Data: data}
+}
+
+// notify queues the given event for the endpoints accepting it. The lock must be held.
+func (n *Notifier) notify(e Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Err(err).Str("type", e.Type).Msg("❌ Could not encode webhook event")
+		return
+	}
+
+	for i, endpoint := range n.endpoints {
+		if !endpoint.Accepts(e.Type) {
+			continue
+		}
+		select {
+		case n.queues[i] <- delivery{eventType: e.Type, body: body}:
+		default:
+			log.Warn().Str("url", endpoint.URL).Str("type", e.Type).Msg("😥 Drop webhook event, endpoint is lagging")
+		}
+	}
+}
+
+func (n *Notifier) work(endpoint Endpoint, queue <-chan delivery) {
+	defer n.wg.Done()
+	for d := range queue {
+		if n.ctx.Err() == nil {
+			n.deliver(endpoint, d)
+		}
+	}
+}
+
+// deliver posts the notification to the endpoint, retrying with an exponential backoff until delivered, rejected by
+// the endpoint, out of attempts or closed.
+func (n *Notifier) deliver(endpoint Endpoint, d delivery) {
+	backoff := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(endpoint, d)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= n.attempts || n.ctx.Err() != nil {
+			log.Warn().Err(err).Str("url", endpoint.URL).Str("type", d.eventType).Int("attempts", attempt).
+				Msg("😥 Could not deliver webhook event")
+			return
+		}
+
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// post makes a delivery attempt, returning whether it is worth retrying if it failed.
+func (n *Notifier) post(endpoint Endpoint, d delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, endpoint.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	now := n.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.eventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, now, d.body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < http.StatusMultipleChoices:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return true, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+}
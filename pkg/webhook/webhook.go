@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"gopkg.in/yaml.v2"
+)
+
+// Types of the events notified to the endpoints.
+const (
+	// TypeDistribution is notified when a transaction distributing funds has been included in a block.
+	TypeDistribution = "distribution"
+	// TypeTxFailed is notified when a transaction could not be made, submitted or confirmed.
+	TypeTxFailed = "tx_failed"
+	// TypeLowBalance is notified when a balance of the faucet goes below its threshold.
+	TypeLowBalance = "low_balance"
+	// TypeFaucetPaused is notified when the faucet gets paused.
+	TypeFaucetPaused = "faucet_paused"
+	// TypeFaucetResumed is notified when the faucet gets resumed.
+	TypeFaucetResumed = "faucet_resumed"
+)
+
+// Headers of the notifications.
+const (
+	// HeaderEvent gives the type of the notified event.
+	HeaderEvent = "X-Faucet-Event"
+	// HeaderTimestamp gives the unix time at which the notification has been signed.
+	HeaderTimestamp = "X-Faucet-Timestamp"
+	// HeaderSignature gives the hex encoded HMAC-SHA256 of the "<timestamp>.<body>" string keyed by the endpoint
+	// secret, prefixed by "sha256=".
+	HeaderSignature = "X-Faucet-Signature"
+)
+
+var eventTypes = map[string]bool{
+	TypeDistribution:  true,
+	TypeTxFailed:      true,
+	TypeLowBalance:    true,
+	TypeFaucetPaused:  true,
+	TypeFaucetResumed: true,
+}
+
+// Event is the JSON body of a notification.
+type Event struct {
+	// ID uniquely identifies the event, letting the endpoints ignore the notifications delivered more than once.
+	ID string `json:"id"`
+	// Type of the event.
+	Type string `json:"type"`
+	// Time at which the event occurred.
+	Time time.Time `json:"time"`
+	// Data holds the details of the event, depending on its type.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Request describes a fund request included in a transaction.
+type Request struct {
+	ID      string      `json:"id"`
+	Address string      `json:"address,omitempty"`
+	Amount  types.Coins `json:"amount,omitempty"`
+}
+
+// Distribution holds the details of a distribution event.
+type Distribution struct {
+	TxHash   string    `json:"txHash"`
+	Height   int64     `json:"height"`
+	Requests []Request `json:"requests"`
+}
+
+// TxFailure holds the details of a tx_failed event.
+type TxFailure struct {
+	TxHash   string    `json:"txHash,omitempty"`
+	Code     uint32    `json:"code,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Requests []Request `json:"requests"`
+}
+
+// LowBalance holds the details of a low_balance event.
+type LowBalance struct {
+	Balance   types.Coin `json:"balance"`
+	Threshold types.Coin `json:"threshold"`
+}
+
+// Endpoint is a URL notified of the events, as configured in the webhooks file.
+type Endpoint struct {
+	// URL to POST the events to.
+	URL string `yaml:"url"`
+	// Secret signing the notifications.
+	Secret string `yaml:"secret"`
+	// Events restricts the notified event types, all of them being notified if empty.
+	Events []string `yaml:"events"`
+}
+
+// Accepts returns true if the endpoint is notified of the events of the given type.
+func (e Endpoint) Accepts(eventType string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, t := range e.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the endpoint has no valid URL, no secret or an unknown event type.
+func (e Endpoint) Validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url %q", e.URL)
+	}
+	if e.Secret == "" {
+		return fmt.Errorf("webhook %q has no secret", e.URL)
+	}
+	for _, t := range e.Events {
+		if !eventTypes[t] {
+			return fmt.Errorf("webhook %q has unknown event type %q", e.URL, t)
+		}
+	}
+	return nil
+}
+
+// Endpoints is the content of the webhooks file.
+type Endpoints struct {
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+// Load returns the endpoints configured in the given yaml file.
+func Load(path string) ([]Endpoint, error) {
+	bz, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints Endpoints
+	if err := yaml.Unmarshal(bz, &endpoints); err != nil {
+		return nil, err
+	}
+	if len(endpoints.Endpoints) == 0 {
+		return nil, errors.New("no webhook endpoint configured")
+	}
+	for _, endpoint := range endpoints.Endpoints {
+		if err := endpoint.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return endpoints.Endpoints, nil
+}
+
+// Sign returns the signature of the given body sent at the given time, as given in the HeaderSignature header.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"okp4/cosmos-faucet/pkg/actor/event"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/eventstream"
+	"github.com/cosmos/cosmos-sdk/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// notification is a request received by the stand-in endpoint.
+type notification struct {
+	Event     Event
+	Type      string
+	Timestamp string
+	Signature string
+	Body      []byte
+}
+
+// newEndpoint returns a local stand-in of a webhook endpoint responding with the given statuses in turn, then with
+// 204, and recording the notifications it receives.
+func newEndpoint(statuses ...int) (*httptest.Server, chan notification) {
+	var mu sync.Mutex
+	received := make(chan notification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var e Event
+		_ = json.Unmarshal(body, &e)
+		received <- notification{
+			Event:     e,
+			Type:      r.Header.Get(HeaderEvent),
+			Timestamp: r.Header.Get(HeaderTimestamp),
+			Signature: r.Header.Get(HeaderSignature),
+			Body:      body,
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[0])
+			statuses = statuses[1:]
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, received
+}
+
+// receive returns the next notification received, or nil if none is received in time.
+func receive(received chan notification) *notification {
+	select {
+	case n := <-received:
+		return &n
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func TestLoad(t *testing.T) {
+	Convey("Given a webhooks file", t, func() {
+		path := filepath.Join(t.TempDir(), "webhooks.yml")
+		write := func(content string) {
+			So(os.WriteFile(path, []byte(content), 0o600), ShouldBeNil)
+		}
+
+		Convey("When it configures valid endpoints", func() {
+			write(`
+endpoints:
+  - url: https://ops.example.com/hooks/faucet
+    secret: s3cr3t
+    events: [low_balance, faucet_paused]
+  - url: http://localhost:8000
+    secret: other
+`)
+			endpoints, err := Load(path)
+
+			Convey("Then they should be loaded", func() {
+				So(err, ShouldBeNil)
+				So(endpoints, ShouldHaveLength, 2)
+				So(endpoints[0].Accepts(TypeLowBalance), ShouldBeTrue)
+				So(endpoints[0].Accepts(TypeDistribution), ShouldBeFalse)
+				So(endpoints[1].Accepts(TypeDistribution), ShouldBeTrue)
+			})
+		})
+
+		for _, content := range []string{
+			"endpoints: []",
+			"endpoints:\n  - url: ftp://example.com\n    secret: s",
+			"endpoints:\n  - url: https://example.com",
+			"endpoints:\n  - url: https://example.com\n    secret: s\n    events: [unknown]",
+		} {
+			content := content
+			Convey("When it contains "+strconv.Quote(content), func() {
+				write(content)
+				_, err := Load(path)
+
+				Convey("Then it should be refused", func() {
+					So(err, ShouldNotBeNil)
+				})
+			})
+		}
+	})
+}
+
+func TestNotifier(t *testing.T) {
+	Convey("Given a notifier subscribed to an event stream", t, func() {
+		now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+		stream := eventstream.NewEventStream()
+		server, received := newEndpoint(http.StatusServiceUnavailable)
+		defer server.Close()
+		filtered, filteredReceived := newEndpoint()
+		defer filtered.Close()
+
+		notifier := NewNotifier([]Endpoint{
+			{URL: server.URL, Secret: "secret"},
+			{URL: filtered.URL, Secret: "other", Events: []string{TypeFaucetPaused}},
+		},
+			WithRetries(3, 10*time.Millisecond),
+			WithLowBalance(types.NewCoins(types.NewInt64Coin("uknow", 1000))),
+		)
+		notifier.now = func() time.Time { return now }
+		defer notifier.Close()
+		defer stream.Unsubscribe(notifier.Subscribe(stream))
+
+		amount := types.NewCoins(types.NewInt64Coin("uknow", 100))
+
+		Convey("When a transaction including fund requests is confirmed", func() {
+			stream.Publish(&event.RequestAccepted{RequestID: "1", Address: "okp41a", Amount: amount, Time: now})
+			stream.Publish(&event.TxBroadcast{TxHash: "HASH", RequestIDs: []string{"1"}, Time: now})
+			stream.Publish(&event.TxConfirmed{TxHash: "HASH", Height: 42, RequestIDs: []string{"1"}, Time: now})
+
+			Convey("Then the distribution should be delivered, retrying after the endpoint failure", func() {
+				first, retry := receive(received), receive(received)
+				So(first, ShouldNotBeNil)
+				So(retry, ShouldNotBeNil)
+				So(retry.Event.ID, ShouldEqual, first.Event.ID)
+				So(retry.Type, ShouldEqual, TypeDistribution)
+				So(retry.Event.Time, ShouldEqual, now)
+				So(string(retry.Body), ShouldContainSubstring, `"txHash":"HASH","height":42`)
+				So(string(retry.Body), ShouldContainSubstring, `"address":"okp41a","amount":[{"denom":"uknow","amount":"100"}]`)
+			})
+
+			Convey("Then the notification should be signed with the endpoint secret", func() {
+				n := receive(received)
+				So(n, ShouldNotBeNil)
+				So(n.Timestamp, ShouldEqual, strconv.FormatInt(now.Unix(), 10))
+				So(n.Signature, ShouldEqual, Sign("secret", now, n.Body))
+				So(n.Signature, ShouldNotEqual, Sign("other", now, n.Body))
+			})
+
+			Convey("Then the endpoints not accepting it should not be notified", func() {
+				So(receive(filteredReceived), ShouldBeNil)
+			})
+		})
+
+		Convey("When a transaction fails", func() {
+			stream.Publish(&event.RequestAccepted{RequestID: "1", Address: "okp41a", Amount: amount, Time: now})
+			stream.Publish(&event.TxFailed{Error: "deadline exceeded", RequestIDs: []string{"1"}, Time: now})
+
+			Convey("Then the failure should be delivered", func() {
+				_ = receive(received)
+				n := receive(received)
+				So(n, ShouldNotBeNil)
+				So(n.Type, ShouldEqual, TypeTxFailed)
+				So(string(n.Body), ShouldContainSubstring, `"error":"deadline exceeded"`)
+				So(string(n.Body), ShouldContainSubstring, `"address":"okp41a"`)
+			})
+		})
+
+		Convey("When the faucet gets paused", func() {
+			stream.Publish(&event.FaucetPaused{Time: now})
+
+			Convey("Then all the endpoints accepting it should be notified", func() {
+				n := receive(filteredReceived)
+				So(n, ShouldNotBeNil)
+				So(n.Type, ShouldEqual, TypeFaucetPaused)
+				So(n.Signature, ShouldEqual, Sign("other", now, n.Body))
+			})
+		})
+
+		Convey("When the balances are refreshed", func() {
+			stream.Publish(&event.BalancesRefreshed{Balances: types.NewCoins(types.NewInt64Coin("uknow", 999)), Time: now})
+			stream.Publish(&event.BalancesRefreshed{Balances: types.NewCoins(types.NewInt64Coin("uknow", 500)), Time: now})
+			stream.Publish(&event.BalancesRefreshed{Balances: types.NewCoins(types.NewInt64Coin("uknow", 5000)), Time: now})
+			stream.Publish(&event.BalancesRefreshed{Balances: types.NewCoins(), Time: now})
+
+			Convey("Then a low balance should be notified once each time it goes below the threshold", func() {
+				var low []notification
+				for n := receive(received); n != nil; n = receive(received) {
+					if n.Type == TypeLowBalance {
+						low = append(low, *n)
+					}
+				}
+				So(low, ShouldHaveLength, 3)
+				So(low[0].Event.ID, ShouldEqual, low[1].Event.ID)
+				So(string(low[0].Body), ShouldContainSubstring, `"balance":{"denom":"uknow","amount":"999"}`)
+				So(string(low[2].Body), ShouldContainSubstring, `"balance":{"denom":"uknow","amount":"0"}`)
+			})
+		})
+	})
+
+	Convey("Given a notifier whose endpoint rejects the notifications", t, func() {
+		stream := eventstream.NewEventStream()
+		server, received := newEndpoint(http.StatusBadRequest)
+		defer server.Close()
+
+		notifier := NewNotifier([]Endpoint{{URL: server.URL, Secret: "secret"}}, WithRetries(3, 10*time.Millisecond))
+		defer notifier.Close()
+		defer stream.Unsubscribe(notifier.Subscribe(stream))
+
+		Convey("When an event is published", func() {
+			stream.Publish(&event.FaucetResumed{Time: time.Now()})
+
+			Convey("Then it should not be retried", func() {
+				So(receive(received), ShouldNotBeNil)
+				So(receive(received), ShouldBeNil)
+			})
+		})
+	})
+}